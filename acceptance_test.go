@@ -6,15 +6,21 @@ package objectstorage_test
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io"
+	"io/fs"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 
 	"github.com/minio/minio-go/v7"
-	"github.com/minio/minio-go/v7/pkg/credentials"
 	objectstorage "github.com/sacloud/object-storage-api-go"
 	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/sacloud/object-storage-api-go/cassette"
+	"github.com/sacloud/object-storage-api-go/objectstoragetest"
+	"github.com/sacloud/object-storage-api-go/s3compat"
 	"github.com/sacloud/packages-go/envvar"
 	"github.com/sacloud/packages-go/testutil"
 	"github.com/sacloud/saclient-go"
@@ -22,32 +28,35 @@ import (
 )
 
 var siteId = envvar.StringFromEnv("SAKURA_OJS_SITE", "isk01")
-var theClient saclient.Client
+var theClient = initClient()
 var accTestFedClient = initFedClient()
 var accTestSiteClient = initSiteClient(siteId)
 
+// accTestCassetteMode TESTACC_CASSETTEで指定されたカセットの動作モード(record/replay)。空の場合はカセットを用いない
+var accTestCassetteMode = cassette.Mode(os.Getenv("TESTACC_CASSETTE"))
+
+// accTestRecorder 実行中のテストのカセット
+var accTestRecorder *cassette.Recorder
+
 func s3Client(t *testing.T, token, secret string) *minio.Client {
 	t.Helper()
 
-	siteOp := objectstorage.NewSiteOp(accTestFedClient)
-	site, err := siteOp.Read(context.Background(), siteId)
-	if err != nil {
-		t.Fatal(err)
+	// サイトの情報のキャッシュがテスト間で共有されないよう、テストごとにClientFactoryを生成する
+	factory := s3compat.NewClientFactory(objectstorage.NewSiteOp(accTestFedClient))
+	if accTestRecorder != nil {
+		factory.Transport = accTestRecorder.RoundTripper(nil)
 	}
-
-	s3Client, err := minio.New(site.S3Endpoint.Value, &minio.Options{
-		Creds:        credentials.NewStaticV4(token, secret, ""),
-		Region:       site.Region.Value,
-		Secure:       true,
-		BucketLookup: minio.BucketLookupPath,
-	})
+	client, err := factory.New(context.Background(), siteId, s3compat.Credentials{AccessKeyID: token, SecretAccessKey: secret})
 	if err != nil {
 		t.Fatal(err)
 	}
-	return s3Client
+	return client
 }
 
 func s3ClientFromEnv(t *testing.T) *minio.Client {
+	if accTestReplaying() {
+		return s3Client(t, objectstorage.Redacted, objectstorage.Redacted)
+	}
 	return s3Client(t, os.Getenv("SAKURA_OJS_ACCESS_TOKEN"), os.Getenv("SAKURA_OJS_ACCESS_TOKEN_SECRET"))
 }
 
@@ -77,6 +86,28 @@ func TestAccSiteAndStatusAPI(t *testing.T) {
 	require.NotEmpty(t, status)
 }
 
+// TestAccConformance 実際のAPIに対して各APIの適合性テストを実行する
+//
+// Note: サイトアカウントが作成済みである必要がある
+func TestAccConformance(t *testing.T) {
+	skipIfNoTestAcc(t)
+	skipIfNoAPIKey(t)
+
+	backend, err := objectstorage.NewBackend(theClient, objectstorage.WithAPIRootURL(envvar.StringFromEnv("SAKURA_OJS_ROOT_URL", objectstorage.DefaultAPIRootURL)))
+	require.NoError(t, err)
+
+	var n int
+	objectstoragetest.Suite{
+		Factory: func(t *testing.T) objectstorage.Backend { return backend },
+		SiteID:  siteId,
+		NewName: func() string {
+			n++
+			return accTestRandomName("name" + strconv.Itoa(n))
+		},
+		KMSKeyID: accTestEnv("SAKURA_KMS_KEY_ID"),
+	}.Run(t)
+}
+
 // TestAccBucketHandling バケット周りの一連の操作のテスト
 //
 // 一連の操作は以下のステップで行う
@@ -91,7 +122,7 @@ func TestAccBucketHandling(t *testing.T) {
 	skipIfNoEnv(t, "SAKURA_OJS_ACCESS_TOKEN", "SAKURA_OJS_ACCESS_TOKEN_SECRET")
 
 	ctx := context.Background()
-	bucketName := "api-go-acc-" + testutil.Random(28, testutil.CharSetAlpha)
+	bucketName := accTestRandomName("bucketName")
 
 	// Step1: バケット作成
 	bucketOp := objectstorage.NewBucketOp(accTestFedClient, accTestSiteClient)
@@ -129,7 +160,7 @@ func TestAccAccessToBucketObjectWithPermissionKey(t *testing.T) {
 	skipIfNoAPIKey(t)
 
 	ctx := context.Background()
-	bucketName := "api-go-acc-" + testutil.Random(28, testutil.CharSetAlpha)
+	bucketName := accTestRandomName("bucketName")
 
 	// Step1: バケット作成
 	bucketOp := objectstorage.NewBucketOp(accTestFedClient, accTestSiteClient)
@@ -204,8 +235,8 @@ func TestAccBucketExtraOperation(t *testing.T) {
 	tky := "tky01"
 	sc1 := initSiteClient(isk)
 	sc2 := initSiteClient(tky)
-	bucketName1 := "api-go-acc-" + testutil.Random(28, testutil.CharSetAlpha)
-	bucketName2 := "api-go-acc-" + testutil.Random(28, testutil.CharSetAlpha)
+	bucketName1 := accTestRandomName("bucketName1")
+	bucketName2 := accTestRandomName("bucketName2")
 
 	b1Op := objectstorage.NewBucketOp(accTestFedClient, sc1)
 	{
@@ -234,7 +265,7 @@ func TestAccBucketExtraOperation(t *testing.T) {
 	// 暗号化
 	{
 		beOp := objectstorage.NewBucketExtraOp(sc2, accTestFedClient, bucketName2)
-		keyId := os.Getenv("SAKURA_KMS_KEY_ID")
+		keyId := accTestEnv("SAKURA_KMS_KEY_ID")
 		err := beOp.EnableEncryption(ctx, keyId)
 		require.NoError(t, err)
 
@@ -262,8 +293,25 @@ func TestAccBucketExtraOperation(t *testing.T) {
 	}
 }
 
+func initClient() *saclient.Client {
+	var client saclient.Client
+	if err := client.SetWith(saclient.WithMiddleware(cassetteMiddleware)); err != nil {
+		panic(err)
+	}
+	return &client
+}
+
+// cassetteMiddleware 実行中のテストのカセットへの記録/カセットからの再生を行う
+func cassetteMiddleware(req *http.Request, pull func() (saclient.Middleware, bool)) (*http.Response, error) {
+	if accTestRecorder != nil {
+		return accTestRecorder.Middleware()(req, pull)
+	}
+	next, _ := pull()
+	return next(req, pull)
+}
+
 func initFedClient() *objectstorage.FedClient {
-	client, err := objectstorage.NewFedClientWithAPIRootURL(&theClient, envvar.StringFromEnv("SAKURA_OJS_ROOT_URL", objectstorage.DefaultAPIRootURL))
+	client, err := objectstorage.NewFedClientWithAPIRootURL(theClient, envvar.StringFromEnv("SAKURA_OJS_ROOT_URL", objectstorage.DefaultAPIRootURL))
 	if err != nil {
 		panic(err)
 	}
@@ -271,15 +319,18 @@ func initFedClient() *objectstorage.FedClient {
 }
 
 func initSiteClient(sid string) *objectstorage.SiteClient {
-	client, err := objectstorage.NewSiteClientWithAPIRootURL(&theClient, envvar.StringFromEnv("SAKURA_OJS_ROOT_URL", objectstorage.DefaultAPIRootURL), sid)
+	client, err := objectstorage.NewSiteClientWithAPIRootURL(theClient, envvar.StringFromEnv("SAKURA_OJS_ROOT_URL", objectstorage.DefaultAPIRootURL), sid)
 	if err != nil {
 		panic(err)
 	}
 	return client
 }
 
-// skipIfNoEnv 指定の環境変数のいずれかが空の場合はt.SkipNow()する
+// skipIfNoEnv 指定の環境変数のいずれかが空の場合はt.SkipNow()する。カセットの再生時は何もしない
 func skipIfNoEnv(t *testing.T, envs ...string) {
+	if accTestReplaying() {
+		return
+	}
 	var emptyEnvs []string
 	for _, env := range envs {
 		if os.Getenv(env) == "" {
@@ -298,8 +349,62 @@ func skipIfNoAPIKey(t *testing.T) {
 	skipIfNoEnv(t, "SAKURA_ACCESS_TOKEN", "SAKURA_ACCESS_TOKEN_SECRET")
 }
 
+// skipIfNoTestAcc TESTACC=1でない場合はt.SkipNow()する
+//
+// TESTACC_CASSETTE=recordの場合はやりとりをカセットに記録し、
+// TESTACC_CASSETTE=replayの場合はTESTACCや認証情報なしで記録済みのカセットを再生する
 func skipIfNoTestAcc(t *testing.T) {
-	if os.Getenv("TESTACC") != "1" {
+	if accTestCassetteMode != cassette.ModeReplay && os.Getenv("TESTACC") != "1" {
 		t.SkipNow()
 	}
+	if accTestCassetteMode != "" {
+		useCassette(t)
+	}
+}
+
+// useCassette testdata/cassettes/<テスト名>.jsonのカセットを実行中のテストで用いる
+func useCassette(t *testing.T) {
+	t.Helper()
+
+	path := filepath.Join("testdata", "cassettes", t.Name()+".json")
+	recorder, err := cassette.New(path, &cassette.Options{Mode: accTestCassetteMode, Strict: true})
+	if errors.Is(err, fs.ErrNotExist) {
+		// 再生時にカセットが無い場合にスキップすると何もテストせずに成功してしまうため失敗とする
+		t.Fatalf("cassette %q is not recorded: run `make testacc-record` with TESTACC=1 and API keys to record it", path)
+	}
+	require.NoError(t, err)
+
+	accTestRecorder = recorder
+	t.Cleanup(func() {
+		accTestRecorder = nil
+		if t.Failed() || t.Skipped() {
+			return
+		}
+		require.NoError(t, recorder.Save())
+		require.Zero(t, recorder.Unused(), "some recorded interactions were not replayed")
+	})
+}
+
+func accTestReplaying() bool {
+	return accTestRecorder != nil && accTestRecorder.Mode() == cassette.ModeReplay
+}
+
+// accTestRandomName テストで用いるランダムな名前を返す。カセットの再生時は記録時と同じ名前を返す
+func accTestRandomName(name string) string {
+	generate := func() string { return "api-go-acc-" + testutil.Random(28, testutil.CharSetAlpha) }
+	if accTestRecorder == nil {
+		return generate()
+	}
+	return accTestRecorder.Variable(name, generate)
+}
+
+// accTestEnv 環境変数の値を返す。カセットの再生時は記録時の値を返す
+//
+// シークレットを含む環境変数には用いないこと
+func accTestEnv(name string) string {
+	generate := func() string { return os.Getenv(name) }
+	if accTestRecorder == nil {
+		return generate()
+	}
+	return accTestRecorder.Variable(name, generate)
 }
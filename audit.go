@@ -0,0 +1,296 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstorage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sacloud/saclient-go"
+)
+
+const (
+	// AuditResultSuccess 呼び出しが成功した
+	AuditResultSuccess = "success"
+	// AuditResultFailure 呼び出しが失敗した
+	AuditResultFailure = "failure"
+)
+
+type actorKey struct{}
+
+// WithActor 監査ジャーナルに記録する操作者をコンテキストに設定する
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext コンテキストに設定された操作者を返す。設定されていない場合は空
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// AuditEntry 監査ジャーナルの1行
+//
+// Hashは自身のHashを空にしたJSONのSHA-256で、PrevHashで直前の行のHashを参照することで改ざんや欠落を検出できる
+type AuditEntry struct {
+	// Seq 1から始まる連番
+	Seq       uint64    `json:"seq"`
+	Time      time.Time `json:"time"`
+	Actor     string    `json:"actor,omitempty"`
+	SiteID    string    `json:"site_id,omitempty"`
+	Operation string    `json:"operation"`
+	Bucket    string    `json:"bucket,omitempty"`
+	// Params 呼び出しの引数。シークレットはマスクされる
+	Params json.RawMessage `json:"params,omitempty"`
+	// Result AuditResultSuccessもしくはAuditResultFailure
+	Result string `json:"result"`
+	// Response 成功した呼び出しの戻り値。シークレットはマスクされる
+	Response   json.RawMessage `json:"response,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	StatusCode int             `json:"status_code,omitempty"`
+	// TraceID APIのトレースID。エラー応答に含まれていた場合のみ記録される
+	TraceID  string `json:"trace_id,omitempty"`
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
+
+// computeHash Hashを除いたエントリのハッシュを返す
+func (e AuditEntry) computeHash() (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// AuditJournalOptions NewAuditJournalのオプション
+type AuditJournalOptions struct {
+	// Now 現在時刻を返す関数。nilの場合はtime.Now
+	Now func() time.Time
+	// OnError ジャーナルへの書き込みに失敗した場合に呼ばれる関数。nilの場合はslog.Defaultに記録する。
+	// 呼び出し自体は完了しているため、書き込みの失敗は呼び出し元に返さない
+	OnError func(err error)
+}
+
+// AuditJournal リソースを変更する呼び出しを、ハッシュチェーンで連結したJSONLとして記録する監査ジャーナル
+//
+// Interceptorをラッパーに適用すると、変更を伴う呼び出しのみを記録する。
+// APIのトレースIDも記録する場合はMiddlewareをsaclientに追加する
+type AuditJournal struct {
+	w    io.Writer
+	opts AuditJournalOptions
+
+	mu   sync.Mutex
+	seq  uint64
+	prev string
+}
+
+// NewAuditJournal wに書き込む監査ジャーナルを作成する。wは空であるものとして先頭から記録する
+func NewAuditJournal(w io.Writer, opts *AuditJournalOptions) *AuditJournal {
+	j := &AuditJournal{w: w}
+	if opts != nil {
+		j.opts = *opts
+	}
+	if j.opts.Now == nil {
+		j.opts.Now = time.Now
+	}
+	if j.opts.OnError == nil {
+		j.opts.OnError = func(err error) {
+			slog.Error("failed to write audit journal", "error", err)
+		}
+	}
+	return j
+}
+
+// OpenAuditJournal ファイルに追記する監査ジャーナルを開く
+//
+// ファイルが既に存在する場合は内容を検証し、最後の行に続けて記録する。検証に失敗した場合はエラーを返す
+func OpenAuditJournal(path string, opts *AuditJournalOptions) (*AuditJournal, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	last, err := VerifyAuditJournal(f)
+	if err != nil {
+		f.Close() //nolint:errcheck,gosec
+		return nil, err
+	}
+	j := NewAuditJournal(f, opts)
+	if last != nil {
+		j.seq, j.prev = last.Seq, last.Hash
+	}
+	return j, nil
+}
+
+// Close 書き込み先がio.Closerの場合は閉じる
+func (j *AuditJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if c, ok := j.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Interceptor リソースを変更する呼び出しを記録するInterceptorを返す
+func (j *AuditJournal) Interceptor() Interceptor {
+	return func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error {
+		if !call.Mutating {
+			return invoke(ctx)
+		}
+		var traceId string
+		callErr := invoke(context.WithValue(ctx, auditTraceKey{}, &traceId))
+
+		entry := &AuditEntry{
+			Time:      j.opts.Now().UTC(),
+			Actor:     ActorFromContext(ctx),
+			SiteID:    call.SiteID,
+			Operation: call.Method,
+			Bucket:    call.Bucket,
+			Result:    AuditResultSuccess,
+			TraceID:   traceId,
+		}
+		var errs []error
+		var err error
+		if entry.Params, err = redactedJSON(call.Params); err != nil {
+			errs = append(errs, err)
+		}
+		if callErr != nil {
+			entry.Result = AuditResultFailure
+			entry.Error = callErr.Error()
+			entry.StatusCode = StatusCode(callErr)
+		} else if entry.Response, err = redactedJSON(call.Result); err != nil {
+			errs = append(errs, err)
+		}
+		if err := j.append(entry); err != nil {
+			errs = append(errs, err)
+		}
+		if len(errs) > 0 {
+			j.opts.OnError(NewError(fmt.Sprintf("failed to audit %s", call.Method), errors.Join(errs...)))
+		}
+		return callErr
+	}
+}
+
+// redactedJSON vをJSONに変換し、シークレットをマスクする。vがnilの場合はnil
+func redactedJSON(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return RedactJSON(data)
+}
+
+// append エントリを連結して書き込む
+func (j *AuditJournal) append(entry *AuditEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entry.Seq = j.seq + 1
+	entry.PrevHash = j.prev
+	hash, err := entry.computeHash()
+	if err != nil {
+		return err
+	}
+	entry.Hash = hash
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := j.w.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	j.seq, j.prev = entry.Seq, entry.Hash
+	return nil
+}
+
+type auditTraceKey struct{}
+
+// Middleware エラー応答に含まれるAPIのトレースIDを、Interceptorが記録するエントリに設定するsaclientのミドルウェアを返す
+func (j *AuditJournal) Middleware() saclient.Middleware {
+	return func(req *http.Request, pull func() (saclient.Middleware, bool)) (*http.Response, error) {
+		next, ok := pull()
+		if !ok {
+			return nil, NewError("no next middleware", nil)
+		}
+		res, err := next(req, pull)
+		if traceId, ok := req.Context().Value(auditTraceKey{}).(*string); ok && err == nil && res.StatusCode >= 400 {
+			if id := peekAPITraceID(res); id != "" {
+				*traceId = id
+			}
+		}
+		return res, err
+	}
+}
+
+// AuditVerifyError 監査ジャーナルの検証に失敗した
+type AuditVerifyError struct {
+	// Line 検証に失敗した行の番号(1から始まる)
+	Line   int
+	Reason string
+}
+
+func (e *AuditVerifyError) Error() string {
+	return fmt.Sprintf("audit journal is broken at line %d: %s", e.Line, e.Reason)
+}
+
+// VerifyAuditJournal 監査ジャーナルのハッシュチェーンを検証し、最後のエントリを返す。空の場合はnil
+//
+// 行の改ざん、削除、挿入、並べ替えを検出した場合は*AuditVerifyErrorを返す。
+// 末尾の行の削除は検出できないため、必要に応じて返されたエントリのHashを別の場所に保存して照合すること
+func VerifyAuditJournal(r io.Reader) (*AuditEntry, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	var last *AuditEntry
+	line := 0
+	for scanner.Scan() {
+		line++
+		var entry AuditEntry
+		dec := json.NewDecoder(bytes.NewReader(scanner.Bytes()))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&entry); err != nil {
+			return nil, &AuditVerifyError{Line: line, Reason: fmt.Sprintf("invalid entry: %s", err)}
+		}
+
+		var wantSeq uint64 = 1
+		wantPrev := ""
+		if last != nil {
+			wantSeq, wantPrev = last.Seq+1, last.Hash
+		}
+		switch {
+		case entry.Seq != wantSeq:
+			return nil, &AuditVerifyError{Line: line, Reason: fmt.Sprintf("seq is %d, want %d", entry.Seq, wantSeq)}
+		case entry.PrevHash != wantPrev:
+			return nil, &AuditVerifyError{Line: line, Reason: "prev_hash does not match the previous entry"}
+		}
+		hash, err := entry.computeHash()
+		if err != nil {
+			return nil, &AuditVerifyError{Line: line, Reason: err.Error()}
+		}
+		if hash != entry.Hash {
+			return nil, &AuditVerifyError{Line: line, Reason: "hash does not match the entry"}
+		}
+		last = &entry
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return last, nil
+}
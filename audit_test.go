@@ -0,0 +1,139 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstorage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditJournal(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	journal := NewAuditJournal(&buf, &AuditJournalOptions{Now: func() time.Time { return now }})
+
+	client, apiRootURL := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodPut:
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"data":{"cluster_id":"isk01","name":"bucket1"}}`)) //nolint:errcheck,gosec
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"code":400,"message":"bad request","trace_id":"trace-123"}}`)) //nolint:errcheck,gosec
+		}
+	}), journal.Middleware())
+	fed, err := NewFedClientWithAPIRootURL(client, apiRootURL)
+	require.NoError(t, err)
+	buckets := InterceptBucketAPI(NewBucketOp(fed, nil), "isk01", journal.Interceptor())
+
+	ctx := WithActor(context.Background(), "alice")
+	_, err = buckets.Create(ctx, &BucketCreateParams{Bucket: "bucket1", SiteId: "isk01"})
+	require.NoError(t, err)
+	// 参照のみの呼び出しは記録しない
+	require.NoError(t, journal.Interceptor()(ctx, &Call{Method: "Buckets.List", SiteID: "isk01"}, func(ctx context.Context) error { return nil }))
+	require.Error(t, buckets.Delete(ctx, "bucket1"))
+
+	var entries []AuditEntry
+	for line := range strings.Lines(buf.String()) {
+		var entry AuditEntry
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	require.Len(t, entries, 2)
+
+	create, del := entries[0], entries[1]
+	require.Equal(t, uint64(1), create.Seq)
+	require.Equal(t, now, create.Time)
+	require.Equal(t, "alice", create.Actor)
+	require.Equal(t, "isk01", create.SiteID)
+	require.Equal(t, "Buckets.Create", create.Operation)
+	require.Equal(t, "bucket1", create.Bucket)
+	require.Equal(t, AuditResultSuccess, create.Result)
+	require.JSONEq(t, `{"plan":""}`, string(create.Params))
+	require.JSONEq(t, `{"cluster_id":"isk01","name":"bucket1"}`, string(create.Response))
+	require.Empty(t, create.PrevHash)
+
+	require.Equal(t, "Buckets.Delete", del.Operation)
+	require.Equal(t, AuditResultFailure, del.Result)
+	require.Equal(t, http.StatusBadRequest, del.StatusCode)
+	require.Equal(t, "trace-123", del.TraceID)
+	require.NotEmpty(t, del.Error)
+	require.Equal(t, create.Hash, del.PrevHash)
+
+	last, err := VerifyAuditJournal(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, del, *last)
+}
+
+func TestAuditJournal_Redact(t *testing.T) {
+	data, err := redactedJSON(&v2.PermissionKeyData{
+		ID:     v2.NewOptPermissionKeyID("key1"),
+		Secret: v2.NewOptPermissionSecret("very-secret"),
+	})
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "very-secret")
+	require.Contains(t, string(data), "key1")
+}
+
+func TestVerifyAuditJournal(t *testing.T) {
+	var buf bytes.Buffer
+	journal := NewAuditJournal(&buf, nil)
+	intercept := journal.Interceptor()
+	for _, method := range []string{"Buckets.Create", "Permissions.Create", "Permissions.Delete"} {
+		call := &Call{Method: method, SiteID: "isk01", Mutating: true, Params: map[string]any{"name": method}}
+		require.NoError(t, intercept(context.Background(), call, func(ctx context.Context) error { return nil }))
+	}
+	lines := strings.SplitAfter(buf.String(), "\n")[:3]
+
+	for name, tt := range map[string]struct {
+		journal string
+		line    int
+	}{
+		"tampered":  {lines[0] + strings.Replace(lines[1], "Permissions.Create", "Permissions.Update", 1) + lines[2], 2},
+		"deleted":   {lines[0] + lines[2], 2},
+		"reordered": {lines[1] + lines[0] + lines[2], 1},
+		"invalid":   {lines[0] + "{\n", 2},
+	} {
+		_, err := VerifyAuditJournal(strings.NewReader(tt.journal))
+		var e *AuditVerifyError
+		require.ErrorAs(t, err, &e, name)
+		require.Equal(t, tt.line, e.Line, name)
+	}
+}
+
+func TestOpenAuditJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	call := &Call{Method: "Buckets.Delete", SiteID: "isk01", Bucket: "bucket1", Mutating: true}
+	invoke := func(ctx context.Context) error { return nil }
+
+	for range 2 {
+		journal, err := OpenAuditJournal(path, nil)
+		require.NoError(t, err)
+		require.NoError(t, journal.Interceptor()(context.Background(), call, invoke))
+		require.NoError(t, journal.Close())
+	}
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close() //nolint:errcheck
+	last, err := VerifyAuditJournal(f)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), last.Seq)
+
+	// 検証に失敗したジャーナルには追記しない
+	require.NoError(t, os.WriteFile(path, []byte("{}\n"), 0o600))
+	_, err = OpenAuditJournal(path, nil)
+	require.Error(t, err)
+}
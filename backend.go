@@ -0,0 +1,266 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstorage
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sacloud/saclient-go"
+)
+
+// Backend 複数サイトにまたがってAPIを利用するためのインターフェース
+//
+// サイトごとのSiteClientの作成と使い回しを行い、各APIのラッパーを返す
+type Backend interface {
+	// Sites SiteAPIを返す。siteIdが空の場合はListPlansは利用できない
+	Sites(siteId string) (SiteAPI, error)
+	Buckets(siteId string) (BucketAPI, error)
+	BucketExtra(siteId, bucket string) (BucketExtraAPI, error)
+	Accounts(siteId string) (AccountAPI, error)
+	Permissions(siteId string) (PermissionsAPI, error)
+	SiteStatus(siteId string) (SiteStatusAPI, error)
+}
+
+// BackendOption NewBackendのオプション
+type BackendOption func(*backend)
+
+// WithAPIRootURL APIルートURLを指定する。デフォルトはDefaultAPIRootURL
+func WithAPIRootURL(apiRootURL string) BackendOption {
+	return func(b *backend) { b.apiRootURL = apiRootURL }
+}
+
+// WithInterceptors Backendが返す全てのAPIにInterceptorを適用する
+func WithInterceptors(interceptors ...Interceptor) BackendOption {
+	return func(b *backend) { b.interceptors = append(b.interceptors, interceptors...) }
+}
+
+// WithCache Backendが返すSiteAPI/BucketAPIの参照系のメソッドをcacheでキャッシュする
+//
+// キャッシュはInterceptorより外側で動作するため、キャッシュから返した呼び出しはInterceptorを経由しない
+func WithCache(cache *Cache) BackendOption {
+	return func(b *backend) { b.cache = cache }
+}
+
+// WithStaleCache Backendが返す各APIの参照系のメソッドの結果をcacheに保存し、APIの障害時に返す
+//
+// WithCacheと併用した場合はWithCacheのキャッシュより内側で動作する
+func WithStaleCache(cache *StaleCache) BackendOption {
+	return func(b *backend) { b.staleCache = cache }
+}
+
+var _ Backend = (*backend)(nil)
+
+type backend struct {
+	client       saclient.ClientAPI
+	apiRootURL   string
+	interceptors []Interceptor
+	cache        *Cache
+	staleCache   *StaleCache
+	readOnly     bool
+	dryRun       *dryRun
+	// quotaPreflight 作成系のメソッドの前に制限値を確認するか
+	quotaPreflight bool
+
+	fedClient *FedClient
+
+	mu          sync.Mutex
+	siteClients map[string]*SiteClient
+}
+
+// NewBackend Backendを作成する
+func NewBackend(client saclient.ClientAPI, opts ...BackendOption) (Backend, error) {
+	b := &backend{
+		client:      client,
+		apiRootURL:  DefaultAPIRootURL,
+		siteClients: map[string]*SiteClient{},
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.readOnly {
+		dupable, ok := client.(saclient.ClientOptionAPI)
+		if !ok {
+			return nil, NewError("client does not implement saclient.ClientOptionAPI", nil)
+		}
+		readOnly, err := dupable.DupWith(saclient.WithMiddleware(ReadOnlyMiddleware()))
+		if err != nil {
+			return nil, err
+		}
+		b.client = readOnly
+	}
+	fedClient, err := NewFedClientWithAPIRootURL(b.client, b.apiRootURL)
+	if err != nil {
+		return nil, err
+	}
+	b.fedClient = fedClient
+	return b, nil
+}
+
+func (b *backend) siteClient(siteId string) (*SiteClient, error) {
+	if siteId == "" {
+		return nil, NewError("site id is required", nil)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c, ok := b.siteClients[siteId]; ok {
+		return c, nil
+	}
+	c, err := NewSiteClientWithAPIRootURL(b.client, b.apiRootURL, siteId)
+	if err != nil {
+		return nil, err
+	}
+	b.siteClients[siteId] = c
+	return c, nil
+}
+
+func (b *backend) Sites(siteId string) (SiteAPI, error) {
+	var siteClient *SiteClient
+	if siteId != "" {
+		c, err := b.siteClient(siteId)
+		if err != nil {
+			return nil, err
+		}
+		siteClient = c
+	}
+	api := NewSiteWithPlansOp(b.fedClient, siteClient)
+	if len(b.interceptors) > 0 {
+		api = InterceptSiteAPI(api, b.interceptors...)
+	}
+	if b.staleCache != nil {
+		api = b.staleCache.SiteAPI(api, siteId)
+	}
+	if b.cache != nil {
+		api = b.cache.SiteAPI(api, siteId)
+	}
+	return api, nil
+}
+
+func (b *backend) Buckets(siteId string) (BucketAPI, error) {
+	c, err := b.siteClient(siteId)
+	if err != nil {
+		return nil, err
+	}
+	api := NewBucketOp(b.fedClient, c)
+	if len(b.interceptors) > 0 {
+		api = InterceptBucketAPI(api, siteId, b.interceptors...)
+	}
+	counts := api
+	if b.staleCache != nil {
+		api = b.staleCache.BucketAPI(api, siteId)
+	}
+	if b.cache != nil {
+		api = b.cache.BucketAPI(api, siteId)
+	}
+	if b.dryRun != nil {
+		api = &dryRunBucketAPI{BucketAPI: api, dryRun: b.dryRun, siteId: siteId}
+	}
+	if b.quotaPreflight {
+		api = &preflightBucketAPI{BucketAPI: api, counts: counts, quotaPreflight: b.newQuotaPreflight(c, siteId)}
+	}
+	return api, nil
+}
+
+func (b *backend) BucketExtra(siteId, bucket string) (BucketExtraAPI, error) {
+	c, err := b.siteClient(siteId)
+	if err != nil {
+		return nil, err
+	}
+	api := NewBucketExtraOp(c, b.fedClient, bucket)
+	if len(b.interceptors) > 0 {
+		api = InterceptBucketExtraAPI(api, siteId, bucket, b.interceptors...)
+	}
+	if b.staleCache != nil {
+		api = b.staleCache.BucketExtraAPI(api, siteId, bucket)
+	}
+	if b.dryRun != nil {
+		api = &dryRunBucketExtraAPI{BucketExtraAPI: api, dryRun: b.dryRun, siteId: siteId, bucket: bucket}
+	}
+	return api, nil
+}
+
+func (b *backend) Accounts(siteId string) (AccountAPI, error) {
+	c, err := b.siteClient(siteId)
+	if err != nil {
+		return nil, err
+	}
+	api := NewAccountOp(c)
+	if len(b.interceptors) > 0 {
+		api = InterceptAccountAPI(api, siteId, b.interceptors...)
+	}
+	counts := api
+	if b.dryRun != nil {
+		api = &dryRunAccountAPI{AccountAPI: api, dryRun: b.dryRun, siteId: siteId}
+	}
+	if b.quotaPreflight {
+		api = &preflightAccountAPI{AccountAPI: api, counts: counts, quotaPreflight: b.newQuotaPreflight(c, siteId)}
+	}
+	return api, nil
+}
+
+func (b *backend) Permissions(siteId string) (PermissionsAPI, error) {
+	c, err := b.siteClient(siteId)
+	if err != nil {
+		return nil, err
+	}
+	api := NewPermissionOp(c)
+	if len(b.interceptors) > 0 {
+		api = InterceptPermissionsAPI(api, siteId, b.interceptors...)
+	}
+	counts := api
+	if b.staleCache != nil {
+		api = b.staleCache.PermissionsAPI(api, siteId)
+	}
+	if b.dryRun != nil {
+		api = &dryRunPermissionsAPI{PermissionsAPI: api, dryRun: b.dryRun, siteId: siteId}
+	}
+	if b.quotaPreflight {
+		api = &preflightPermissionsAPI{PermissionsAPI: api, counts: counts, quotaPreflight: b.newQuotaPreflight(c, siteId)}
+	}
+	return api, nil
+}
+
+func (b *backend) SiteStatus(siteId string) (SiteStatusAPI, error) {
+	c, err := b.siteClient(siteId)
+	if err != nil {
+		return nil, err
+	}
+	api := b.siteStatus(c, siteId)
+	if b.staleCache != nil {
+		api = b.staleCache.SiteStatusAPI(api, siteId)
+	}
+	return api, nil
+}
+
+// siteStatus キャッシュを介さないSiteStatusAPIを返す
+func (b *backend) siteStatus(c *SiteClient, siteId string) SiteStatusAPI {
+	api := NewSiteStatusOp(c)
+	if len(b.interceptors) > 0 {
+		api = InterceptSiteStatusAPI(api, siteId, b.interceptors...)
+	}
+	return api
+}
+
+// newQuotaPreflight キャッシュを介さずに制限値を取得するquotaPreflightを返す。
+// キャッシュされた制限値や現在の数で確認すると、制限値を超える作成を許してしまうため
+func (b *backend) newQuotaPreflight(c *SiteClient, siteId string) quotaPreflight {
+	return quotaPreflight{status: b.siteStatus(c, siteId), siteId: siteId}
+}
+
+// ListSiteIDs backendのSiteAPI.Listで取得した全てのサイトのIDを返す
+func ListSiteIDs(ctx context.Context, backend Backend) ([]string, error) {
+	api, err := backend.Sites("")
+	if err != nil {
+		return nil, err
+	}
+	list, err := api.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	siteIds := make([]string, 0, len(list))
+	for _, site := range list {
+		siteIds = append(siteIds, site.ID.Value)
+	}
+	return siteIds, nil
+}
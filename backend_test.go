@@ -0,0 +1,229 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstorage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackend(t *testing.T) {
+	var paths []string
+	client, apiRootURL := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":[{"name":"bucket1"}]}`)) //nolint:errcheck,gosec
+	}))
+
+	var calls []*Call
+	backend, err := NewBackend(client, WithAPIRootURL(apiRootURL), WithInterceptors(
+		func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error {
+			calls = append(calls, call)
+			return invoke(ctx)
+		},
+	))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for _, siteId := range []string{"isk01", "tky01"} {
+		buckets, err := backend.Buckets(siteId)
+		require.NoError(t, err)
+		res, err := buckets.List(ctx)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+	}
+	require.Equal(t, []string{"/isk01/v2/buckets", "/tky01/v2/buckets"}, paths)
+	require.Equal(t, []*Call{
+		{Method: "Buckets.List", SiteID: "isk01"},
+		{Method: "Buckets.List", SiteID: "tky01"},
+	}, calls)
+
+	_, err = backend.Buckets("")
+	require.Error(t, err)
+
+	sites, err := backend.Sites("")
+	require.NoError(t, err)
+	_, err = sites.ListPlans(ctx)
+	require.Error(t, err)
+}
+
+func TestBackend_ReadOnly(t *testing.T) {
+	var methods []string
+	client, apiRootURL := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":[{"name":"bucket1"}]}`)) //nolint:errcheck,gosec
+	}))
+	backend, err := NewBackend(client, WithAPIRootURL(apiRootURL), WithReadOnly())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	buckets, err := backend.Buckets("isk01")
+	require.NoError(t, err)
+	_, err = buckets.List(ctx)
+	require.NoError(t, err)
+
+	_, err = buckets.Create(ctx, &BucketCreateParams{Bucket: "bucket2", SiteId: "isk01"})
+	require.ErrorIs(t, err, ErrReadOnly)
+	var e *ReadOnlyError
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, string(v2.CreateBucketOperation), e.Operation)
+
+	permissions, err := backend.Permissions("isk01")
+	require.NoError(t, err)
+	require.ErrorIs(t, permissions.DeleteAccessKey(ctx, "1", "key1"), ErrReadOnly)
+
+	// 変更を伴うオペレーションのリクエストは送信しない
+	require.Equal(t, []string{http.MethodGet}, methods)
+}
+
+func TestBackend_DryRun(t *testing.T) {
+	var methods []string
+	client, apiRootURL := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":[{"name":"bucket1"}]}`)) //nolint:errcheck,gosec
+	}))
+	var logs bytes.Buffer
+	var calls []string
+	backend, err := NewBackend(client, WithAPIRootURL(apiRootURL), WithDryRun(slog.New(slog.NewJSONHandler(&logs, nil))), WithInterceptors(
+		func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error {
+			calls = append(calls, call.Method)
+			return invoke(ctx)
+		},
+	))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	buckets, err := backend.Buckets("isk01")
+	require.NoError(t, err)
+	_, err = buckets.List(ctx)
+	require.NoError(t, err)
+	bucket, err := buckets.Create(ctx, &BucketCreateParams{Bucket: "bucket2"})
+	require.NoError(t, err)
+	require.Equal(t, "isk01", bucket.ClusterID.Value)
+	require.Equal(t, "bucket2", bucket.Name.Value)
+
+	permissions, err := backend.Permissions("isk01")
+	require.NoError(t, err)
+	permission, err := permissions.Update(ctx, "100", "perm1", v2.BucketControls{{BucketName: v2.NewOptBucketName("bucket2")}})
+	require.NoError(t, err)
+	require.Equal(t, v2.PermissionID(100), permission.ID.Value)
+	key, err := permissions.CreateAccessKey(ctx, "100")
+	require.NoError(t, err)
+	require.Equal(t, DryRunKeyID, string(key.ID.Value))
+
+	extra, err := backend.BucketExtra("isk01", "bucket2")
+	require.NoError(t, err)
+	require.NoError(t, extra.EnableEncryption(ctx, "kms-1"))
+
+	// 変更を伴う呼び出しはAPIもInterceptorも経由せずに記録のみ行う
+	require.Equal(t, []string{http.MethodGet}, methods)
+	require.Equal(t, []string{"Buckets.List"}, calls)
+	var methodsLogged []string
+	for line := range bytes.Lines(logs.Bytes()) {
+		var record struct {
+			Method string `json:"method"`
+		}
+		require.NoError(t, json.Unmarshal(line, &record))
+		methodsLogged = append(methodsLogged, record.Method)
+	}
+	require.Equal(t, []string{"Buckets.Create", "Permissions.Update", "Permissions.CreateAccessKey", "BucketExtra.EnableEncryption"}, methodsLogged)
+}
+
+func TestBackend_QuotaPreflight(t *testing.T) {
+	var methods []string
+	client, apiRootURL := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/quota") {
+			w.Write([]byte(`{"data":{"num_buckets":1}}`)) //nolint:errcheck,gosec
+			return
+		}
+		w.Write([]byte(`{"data":[{"name":"bucket1"}]}`)) //nolint:errcheck,gosec
+	}))
+	backend, err := NewBackend(client, WithAPIRootURL(apiRootURL), WithQuotaPreflight())
+	require.NoError(t, err)
+
+	buckets, err := backend.Buckets("isk01")
+	require.NoError(t, err)
+	_, err = buckets.Create(context.Background(), &BucketCreateParams{Bucket: "bucket2"})
+	var e *QuotaExceededError
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, QuotaBuckets, e.Dimension)
+	require.Equal(t, []string{http.MethodGet, http.MethodGet}, methods)
+}
+
+func TestBackend_QuotaPreflightWithCache(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		buckets     = []string{"bucket1"}
+		unavailable atomic.Bool
+	)
+	client, apiRootURL := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/quota") {
+			w.Write([]byte(`{"data":{"num_buckets":2}}`)) //nolint:errcheck,gosec
+			return
+		}
+		var items []string
+		for _, name := range buckets {
+			items = append(items, `{"name":"`+name+`"}`)
+		}
+		w.Write([]byte(`{"data":[` + strings.Join(items, ",") + `]}`)) //nolint:errcheck,gosec
+	}))
+	staleCache, err := NewStaleCache(t.TempDir())
+	require.NoError(t, err)
+	backend, err := NewBackend(client, WithAPIRootURL(apiRootURL), WithCache(NewCache()), WithStaleCache(staleCache), WithQuotaPreflight(),
+		WithInterceptors(func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error {
+			if unavailable.Load() {
+				return NewAPIError(call.Method, http.StatusInternalServerError, nil)
+			}
+			return invoke(ctx)
+		}))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	api, err := backend.Buckets("isk01")
+	require.NoError(t, err)
+	list, err := api.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	status, err := backend.SiteStatus("isk01")
+	require.NoError(t, err)
+	_, err = status.ReadQuota(ctx)
+	require.NoError(t, err)
+
+	// キャッシュされたバケットの一覧ではなく現在の数で確認する
+	mu.Lock()
+	buckets = append(buckets, "bucket2")
+	mu.Unlock()
+	list, err = api.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	_, err = api.Create(ctx, &BucketCreateParams{Bucket: "bucket3"})
+	var e *QuotaExceededError
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, 2, e.Current)
+
+	// 障害時は保存した古い制限値や一覧で確認せず、作成しない
+	unavailable.Store(true)
+	_, err = status.ReadQuota(ctx)
+	require.NoError(t, err)
+	_, err = api.Create(ctx, &BucketCreateParams{Bucket: "bucket3"})
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrQuotaExceeded)
+}
@@ -45,9 +45,9 @@ func (op *bucketOp) List(ctx context.Context) ([]v2.BucketListDataItem, error) {
 }
 
 type BucketCreateParams struct {
-	Bucket string
-	SiteId string
-	Plan   string
+	Bucket string `json:"bucket"`
+	SiteId string `json:"site_id"`
+	Plan   string `json:"plan,omitempty"`
 }
 
 func createRequest(params *BucketCreateParams) *v2.HandlerPutBucketReqBody {
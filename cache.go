@@ -0,0 +1,333 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstorage
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultCacheTTLs NewCacheでキャッシュするメソッドとTTLのデフォルト値
+var DefaultCacheTTLs = map[string]time.Duration{
+	"Site.List":      10 * time.Minute,
+	"Site.Read":      10 * time.Minute,
+	"Site.ListPlans": 10 * time.Minute,
+	"Buckets.List":   30 * time.Second,
+}
+
+// DefaultCacheLoadTimeout キャッシュのためのAPI呼び出しのデフォルトのタイムアウト
+const DefaultCacheLoadTimeout = time.Minute
+
+// cacheInvalidations 更新系のメソッドと、その呼び出しで無効化される同じサイトの参照系のメソッド
+var cacheInvalidations = map[string][]string{
+	"Buckets.Create": {"Buckets.List"},
+	"Buckets.Delete": {"Buckets.List"},
+}
+
+// CacheOption NewCacheのオプション
+type CacheOption func(*Cache)
+
+// WithCacheTTL メソッドのTTLを指定する。0以下の場合はそのメソッドをキャッシュしない
+//
+// 指定できるのはDefaultCacheTTLsに含まれるメソッドのみで、それ以外は無視される
+func WithCacheTTL(method string, ttl time.Duration) CacheOption {
+	return func(c *Cache) {
+		if _, ok := DefaultCacheTTLs[method]; ok {
+			c.ttls[method] = ttl
+		}
+	}
+}
+
+// WithCacheLoadTimeout キャッシュのためのAPI呼び出しのタイムアウトを指定する。デフォルトはDefaultCacheLoadTimeoutで、0以下の場合はタイムアウトしない
+//
+// API呼び出しは呼び出し元のctxのキャンセルや期限を引き継がないため、応答しない場合にいつまでも残らないようこのタイムアウトで打ち切る
+func WithCacheLoadTimeout(timeout time.Duration) CacheOption {
+	return func(c *Cache) { c.loadTimeout = timeout }
+}
+
+// WithCacheClock 有効期限の判定に用いる現在時刻を返す関数を指定する。デフォルトはtime.Now
+func WithCacheClock(now func() time.Time) CacheOption {
+	return func(c *Cache) { c.now = now }
+}
+
+// CacheStats メソッドごとのキャッシュの統計情報
+type CacheStats struct {
+	// Hits キャッシュから返した回数
+	Hits uint64
+	// Misses キャッシュになかった回数
+	Misses uint64
+	// Loads 実際にAPIを呼び出した回数。Missesとの差が同時の呼び出しをまとめた回数となる
+	Loads uint64
+	// Invalidations 更新系のメソッドの呼び出しなどで無効化された回数
+	Invalidations uint64
+}
+
+type cacheScope struct {
+	method string
+	siteId string
+}
+
+type cacheKey struct {
+	cacheScope
+	arg string
+}
+
+type cacheEntry struct {
+	value   any
+	expires time.Time
+}
+
+// Cache 参照系のメソッドの結果をTTLの間キャッシュする
+//
+// 同じキーでの同時の呼び出しは1回のAPI呼び出しにまとめられる。
+// 同じCacheから作成したラッパーで更新系のメソッドを呼び出すと、関連する参照系のメソッドのキャッシュは無効化される。
+// エラーはキャッシュしない
+type Cache struct {
+	ttls        map[string]time.Duration
+	loadTimeout time.Duration
+	now         func() time.Time
+
+	mu          sync.Mutex
+	entries     map[cacheKey]cacheEntry
+	generations map[cacheScope]uint64
+	stats       map[string]*CacheStats
+
+	group singleflight.Group
+}
+
+// NewCache Cacheを作成する
+func NewCache(opts ...CacheOption) *Cache {
+	c := &Cache{
+		ttls:        maps.Clone(DefaultCacheTTLs),
+		loadTimeout: DefaultCacheLoadTimeout,
+		now:         time.Now,
+		entries:     map[cacheKey]cacheEntry{},
+		generations: map[cacheScope]uint64{},
+		stats:       map[string]*CacheStats{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Stats メソッドごとの統計情報を返す
+func (c *Cache) Stats() map[string]CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res := make(map[string]CacheStats, len(c.stats))
+	for method, stats := range c.stats {
+		res[method] = *stats
+	}
+	return res
+}
+
+// Invalidate siteIdのmethodsのキャッシュを無効化する。methodsを省略した場合はsiteIdの全てのメソッドが対象となる
+//
+// Site.List/Site.ReadはサイトIDを持たないため、siteIdに空を指定する
+func (c *Cache) Invalidate(siteId string, methods ...string) {
+	if len(methods) == 0 {
+		methods = slices.Collect(maps.Keys(c.ttls))
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, method := range methods {
+		c.invalidate(cacheScope{method: method, siteId: siteId})
+	}
+}
+
+// Purge 全てのキャッシュを破棄する
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	scopes := map[cacheScope]struct{}{}
+	for key := range c.entries {
+		scopes[key.cacheScope] = struct{}{}
+	}
+	for scope := range c.generations {
+		scopes[scope] = struct{}{}
+	}
+	for scope := range scopes {
+		c.invalidate(scope)
+	}
+}
+
+func (c *Cache) invalidate(scope cacheScope) {
+	// 実行中の呼び出しの結果が保存されないように世代を進める
+	c.generations[scope]++
+	c.statsOf(scope.method).Invalidations++
+	for key := range c.entries {
+		if key.cacheScope == scope {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// mutated 更新系のメソッドの呼び出し後に関連するキャッシュを無効化する
+func (c *Cache) mutated(method, siteId string) {
+	if methods, ok := cacheInvalidations[method]; ok {
+		c.Invalidate(siteId, methods...)
+	}
+}
+
+func (c *Cache) statsOf(method string) *CacheStats {
+	stats, ok := c.stats[method]
+	if !ok {
+		stats = &CacheStats{}
+		c.stats[method] = stats
+	}
+	return stats
+}
+
+// get キャッシュの値を返す。ない場合はloadを呼び出して結果を保存する
+//
+// 同時の呼び出しはまとめて実行される。最初の呼び出しのctxがキャンセルされても他の呼び出しが失敗しないよう、
+// loadはキャンセルを引き継がず、WithCacheLoadTimeoutのタイムアウトを設定したctxで実行する。各呼び出しは自身のctxの終了時に待つのをやめる
+func (c *Cache) get(ctx context.Context, key cacheKey, load func(ctx context.Context) (any, error)) (any, error) {
+	ttl := c.ttls[key.method]
+	if ttl <= 0 {
+		return load(ctx)
+	}
+
+	c.mu.Lock()
+	stats := c.statsOf(key.method)
+	if e, ok := c.entries[key]; ok && c.now().Before(e.expires) {
+		stats.Hits++
+		c.mu.Unlock()
+		return e.value, nil
+	}
+	stats.Misses++
+	generation := c.generations[key.cacheScope]
+	c.mu.Unlock()
+
+	flight := key.method + "\x00" + key.siteId + "\x00" + key.arg + "\x00" + strconv.FormatUint(generation, 10)
+	ch := c.group.DoChan(flight, func() (any, error) {
+		c.mu.Lock()
+		c.statsOf(key.method).Loads++
+		c.mu.Unlock()
+
+		loadCtx := context.WithoutCancel(ctx)
+		if c.loadTimeout > 0 {
+			var cancel context.CancelFunc
+			loadCtx, cancel = context.WithTimeout(loadCtx, c.loadTimeout)
+			defer cancel()
+		}
+		loadCtx, report := TrackStale(loadCtx)
+		value, err := load(loadCtx)
+		if err != nil {
+			return nil, err
+		}
+		// StaleCacheが返した古い結果は保存しない
+		if report.Stale() {
+			return staleResult{value: value, since: report.Since(), err: report.Err()}, nil
+		}
+		c.mu.Lock()
+		if c.generations[key.cacheScope] == generation {
+			c.entries[key] = cacheEntry{value: value, expires: c.now().Add(ttl)}
+		}
+		c.mu.Unlock()
+		return value, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if stale, ok := res.Val.(staleResult); ok {
+			markStale(ctx, stale.since, stale.err)
+			return stale.value, nil
+		}
+		return res.Val, res.Err
+	}
+}
+
+type staleResult struct {
+	value any
+	since time.Time
+	err   error
+}
+
+// SiteAPI SiteAPIの参照系のメソッドをキャッシュする。siteIdはListPlansのキャッシュのキーに用いる
+func (c *Cache) SiteAPI(api SiteAPI, siteId string) SiteAPI {
+	return &cachedSiteAPI{api: api, cache: c, siteId: siteId}
+}
+
+type cachedSiteAPI struct {
+	api    SiteAPI
+	cache  *Cache
+	siteId string
+}
+
+func (a *cachedSiteAPI) List(ctx context.Context) ([]v2.ModelCluster, error) {
+	res, err := a.cache.get(ctx, cacheKey{cacheScope: cacheScope{method: "Site.List"}}, func(ctx context.Context) (any, error) {
+		return a.api.List(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(res.([]v2.ModelCluster)), nil
+}
+
+func (a *cachedSiteAPI) Read(ctx context.Context, siteId string) (*v2.ModelCluster, error) {
+	res, err := a.cache.get(ctx, cacheKey{cacheScope: cacheScope{method: "Site.Read"}, arg: siteId}, func(ctx context.Context) (any, error) {
+		return a.api.Read(ctx, siteId)
+	})
+	if err != nil {
+		return nil, err
+	}
+	site := *res.(*v2.ModelCluster)
+	return &site, nil
+}
+
+func (a *cachedSiteAPI) ListPlans(ctx context.Context) ([]v2.PlanItem, error) {
+	res, err := a.cache.get(ctx, cacheKey{cacheScope: cacheScope{method: "Site.ListPlans", siteId: a.siteId}}, func(ctx context.Context) (any, error) {
+		return a.api.ListPlans(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(res.([]v2.PlanItem)), nil
+}
+
+// BucketAPI BucketAPIの参照系のメソッドをキャッシュする。Create/Deleteの呼び出し後はListのキャッシュを無効化する
+func (c *Cache) BucketAPI(api BucketAPI, siteId string) BucketAPI {
+	return &cachedBucketAPI{api: api, cache: c, siteId: siteId}
+}
+
+type cachedBucketAPI struct {
+	api    BucketAPI
+	cache  *Cache
+	siteId string
+}
+
+func (a *cachedBucketAPI) List(ctx context.Context) ([]v2.BucketListDataItem, error) {
+	res, err := a.cache.get(ctx, cacheKey{cacheScope: cacheScope{method: "Buckets.List", siteId: a.siteId}}, func(ctx context.Context) (any, error) {
+		return a.api.List(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(res.([]v2.BucketListDataItem)), nil
+}
+
+func (a *cachedBucketAPI) Create(ctx context.Context, params *BucketCreateParams) (*v2.ModelBucket, error) {
+	siteId := a.siteId
+	if params.SiteId != "" {
+		siteId = params.SiteId
+	}
+	// エラーの場合も作成されている可能性があるため常に無効化する
+	defer a.cache.mutated("Buckets.Create", siteId)
+	return a.api.Create(ctx, params)
+}
+
+func (a *cachedBucketAPI) Delete(ctx context.Context, bucketName string) error {
+	defer a.cache.mutated("Buckets.Delete", a.siteId)
+	return a.api.Delete(ctx, bucketName)
+}
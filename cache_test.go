@@ -0,0 +1,186 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstorage_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/sacloud/object-storage-api-go/objectstoragetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := objectstoragetest.NewFake()
+	cache := objectstorage.NewCache(
+		objectstorage.WithCacheClock(func() time.Time { return now }),
+		objectstorage.WithCacheTTL("Site.Read", 0),
+	)
+
+	sitesAPI, err := fake.Sites("isk01")
+	require.NoError(t, err)
+	sites := cache.SiteAPI(sitesAPI, "isk01")
+
+	t.Run("ttl", func(t *testing.T) {
+		for range 3 {
+			res, err := sites.List(ctx)
+			require.NoError(t, err)
+			require.Len(t, res, 2)
+		}
+		require.Len(t, fake.CallsTo("Site.List"), 1)
+
+		now = now.Add(objectstorage.DefaultCacheTTLs["Site.List"])
+		_, err := sites.List(ctx)
+		require.NoError(t, err)
+		require.Len(t, fake.CallsTo("Site.List"), 2)
+
+		// TTLが0のメソッドはキャッシュしない
+		for range 2 {
+			_, err := sites.Read(ctx, "isk01")
+			require.NoError(t, err)
+		}
+		require.Len(t, fake.CallsTo("Site.Read"), 2)
+
+		require.Equal(t, objectstorage.CacheStats{Hits: 2, Misses: 2, Loads: 2}, cache.Stats()["Site.List"])
+	})
+
+	t.Run("invalidation by mutation", func(t *testing.T) {
+		api, err := fake.Buckets("isk01")
+		require.NoError(t, err)
+		// 同じCacheから作成したラッパー間で無効化される
+		reader, writer := cache.BucketAPI(api, "isk01"), cache.BucketAPI(api, "isk01")
+
+		res, err := reader.List(ctx)
+		require.NoError(t, err)
+		require.Empty(t, res)
+
+		_, err = writer.Create(ctx, &objectstorage.BucketCreateParams{Bucket: "bucket1"})
+		require.NoError(t, err)
+		res, err = reader.List(ctx)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		res, err = reader.List(ctx)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+
+		require.NoError(t, writer.Delete(ctx, "bucket1"))
+		res, err = reader.List(ctx)
+		require.NoError(t, err)
+		require.Empty(t, res)
+
+		require.Len(t, fake.CallsTo("Buckets.List"), 3)
+		require.Equal(t, objectstorage.CacheStats{Hits: 1, Misses: 3, Loads: 3, Invalidations: 2}, cache.Stats()["Buckets.List"])
+
+		// 別のサイトのキャッシュは無効化されない
+		other, err := fake.Buckets("tky01")
+		require.NoError(t, err)
+		_, err = cache.BucketAPI(other, "tky01").List(ctx)
+		require.NoError(t, err)
+		_, err = writer.Create(ctx, &objectstorage.BucketCreateParams{Bucket: "bucket2"})
+		require.NoError(t, err)
+		_, err = cache.BucketAPI(other, "tky01").List(ctx)
+		require.NoError(t, err)
+		require.Len(t, fake.CallsTo("Buckets.List"), 4)
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		fake.FailOn("Site.ListPlans", objectstoragetest.APIError("Site.ListPlans", http.StatusInternalServerError))
+		_, err := sites.ListPlans(ctx)
+		require.Error(t, err)
+		fake.ClearHooks()
+		_, err = sites.ListPlans(ctx)
+		require.NoError(t, err)
+		require.Len(t, fake.CallsTo("Site.ListPlans"), 2)
+	})
+
+	t.Run("concurrent calls are deduplicated", func(t *testing.T) {
+		cache.Purge()
+		fake.ResetCalls()
+		release := make(chan struct{})
+		fake.OnCall(func(call *objectstorage.Call) error {
+			<-release
+			return nil
+		})
+		defer fake.ClearHooks()
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Go(func() {
+				res, err := sites.List(ctx)
+				require.NoError(t, err)
+				require.Len(t, res, 2)
+			})
+		}
+		require.Eventually(t, func() bool { return cache.Stats()["Site.List"].Misses == 12 }, time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		require.Len(t, fake.CallsTo("Site.List"), 1)
+		require.Equal(t, uint64(3), cache.Stats()["Site.List"].Loads)
+	})
+
+	t.Run("canceled caller does not fail others", func(t *testing.T) {
+		fake.ResetCalls()
+		api := &blockingSiteAPI{SiteAPI: sitesAPI, release: make(chan struct{})}
+		cache := objectstorage.NewCache()
+		sites := cache.SiteAPI(api, "isk01")
+
+		canceledCtx, cancel := context.WithCancel(ctx)
+		canceled := make(chan error)
+		go func() {
+			_, err := sites.List(canceledCtx)
+			canceled <- err
+		}()
+		require.Eventually(t, func() bool { return cache.Stats()["Site.List"].Misses == 1 }, time.Second, time.Millisecond)
+		other := make(chan error)
+		go func() {
+			_, err := sites.List(ctx)
+			other <- err
+		}()
+		require.Eventually(t, func() bool { return cache.Stats()["Site.List"].Misses == 2 }, time.Second, time.Millisecond)
+
+		cancel()
+		require.ErrorIs(t, <-canceled, context.Canceled)
+		close(api.release)
+		require.NoError(t, <-other)
+		require.Len(t, fake.CallsTo("Site.List"), 1)
+	})
+
+	t.Run("load times out", func(t *testing.T) {
+		fake.ResetCalls()
+		// releaseを閉じないため、Listはctxが終了するまで応答しない
+		api := &blockingSiteAPI{SiteAPI: sitesAPI, release: make(chan struct{})}
+		cache := objectstorage.NewCache(objectstorage.WithCacheLoadTimeout(10 * time.Millisecond))
+		sites := cache.SiteAPI(api, "isk01")
+
+		_, err := sites.List(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		// 打ち切られた呼び出しは残らず、次の呼び出しで改めて呼び出す
+		_, err = sites.List(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Equal(t, uint64(2), cache.Stats()["Site.List"].Loads)
+	})
+}
+
+// blockingSiteAPI releaseが閉じられるかctxが終了するまでListを待たせる
+type blockingSiteAPI struct {
+	objectstorage.SiteAPI
+	release chan struct{}
+}
+
+func (a *blockingSiteAPI) List(ctx context.Context) ([]v2.ModelCluster, error) {
+	select {
+	case <-a.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return a.SiteAPI.List(ctx)
+}
@@ -0,0 +1,117 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package cassette APIとのやりとりをファイル(カセット)に記録/再生する
+//
+// 記録モードでは実際のリクエスト/レスポンスをシークレットやアカウントコードをマスクした上で保存し、
+// 再生モードでは保存されたレスポンスを返すことで、認証情報なしで決定的にテストを実行できるようにする
+package cassette
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+)
+
+// Version カセットファイルのフォーマットのバージョン
+const Version = 1
+
+// Cassette 記録されたやりとりの一覧
+type Cassette struct {
+	Version int `json:"version"`
+	// Variables テスト中に生成されたランダムな値など、再生時に同じ値を用いる必要がある変数
+	Variables map[string]string `json:"variables,omitempty"`
+	// Interactions 記録された順のリクエスト/レスポンス
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction 1回のリクエスト/レスポンス
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request 記録されたリクエスト。ボディはマッチング用に正規化されている
+type Request struct {
+	Method string `json:"method"`
+	// Path パスと正規化されたクエリ文字列
+	Path string `json:"path"`
+	Body Body   `json:"body,omitempty"`
+}
+
+// Response 記録されたレスポンス
+type Response struct {
+	StatusCode int                 `json:"status_code"`
+	Headers    map[string][]string `json:"headers,omitempty"`
+	Body       Body                `json:"body,omitempty"`
+}
+
+// Body リクエスト/レスポンスのボディ
+//
+// UTF-8として妥当な場合は文字列として、それ以外の場合はbase64でエンコードして保存される
+type Body []byte
+
+// MarshalJSON implements json.Marshaler
+func (b Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(map[string]string{"base64": base64.StdEncoding.EncodeToString(b)})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*b = Body(s)
+		return nil
+	}
+	var encoded struct {
+		Base64 string `json:"base64"`
+	}
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded.Base64)
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// Load カセットファイルを読み込む
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, objectstorage.NewError("failed to read cassette", err)
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, objectstorage.NewError("failed to parse cassette "+path, err)
+	}
+	if c.Version != Version {
+		return nil, objectstorage.NewError("unsupported cassette version in "+path, nil)
+	}
+	return &c, nil
+}
+
+// Save カセットをファイルに書き込む。ディレクトリが存在しない場合は作成する
+func (c *Cassette) Save(path string) error {
+	c.Version = Version
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return objectstorage.NewError("failed to marshal cassette", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:gosec
+		return objectstorage.NewError("failed to create cassette directory", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil { //nolint:gosec
+		return objectstorage.NewError("failed to write cassette", err)
+	}
+	return nil
+}
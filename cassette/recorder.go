@@ -0,0 +1,258 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package cassette
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	"github.com/sacloud/saclient-go"
+)
+
+// Mode Recorderの動作モード
+type Mode string
+
+const (
+	// ModeReplay カセットに記録されたレスポンスを返す。実際のAPIへはリクエストしない
+	ModeReplay Mode = "replay"
+	// ModeRecord 実際のAPIへリクエストし、やりとりをカセットに記録する
+	ModeRecord Mode = "record"
+)
+
+// Options Recorderのオプション
+type Options struct {
+	// Mode 動作モード。空の場合はModeReplay
+	Mode Mode
+	// Strict trueの場合、再生時にカセット内に一致するやりとりが見つからなければエラーとする。
+	// falseの場合は実際のAPIへリクエストする
+	Strict bool
+	// ScrubFields 追加でマスクするJSONのフィールド名
+	ScrubFields []string
+}
+
+// Recorder カセットへの記録/カセットからの再生を行う
+//
+// saclientのミドルウェア(FedClient/SiteClient向け)とhttp.RoundTripper(S3互換API向け)を提供する。
+// 同じRecorderから得たミドルウェアとRoundTripperは1つのカセットを共有する
+type Recorder struct {
+	path     string
+	mode     Mode
+	strict   bool
+	scrubber *scrubber
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// New Recorderを生成する
+//
+// 再生モードの場合はpathのカセットを読み込む。カセットが存在しない場合のエラーはfs.ErrNotExistをラップしている
+func New(path string, opts *Options) (*Recorder, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	r := &Recorder{
+		path:     path,
+		mode:     opts.Mode,
+		strict:   opts.Strict,
+		scrubber: &scrubber{fields: opts.ScrubFields},
+		cassette: &Cassette{Version: Version},
+	}
+	switch r.mode {
+	case "":
+		r.mode = ModeReplay
+		fallthrough
+	case ModeReplay:
+		c, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		r.cassette = c
+		r.used = make([]bool, len(c.Interactions))
+	case ModeRecord:
+	default:
+		return nil, objectstorage.NewError(fmt.Sprintf("unknown cassette mode: %q", r.mode), nil)
+	}
+	return r, nil
+}
+
+// Mode 動作モードを返す
+func (r *Recorder) Mode() Mode { return r.mode }
+
+// Variable nameに対応する変数の値を返す
+//
+// 記録モードではgenerateで生成した値を記録し、再生モードでは記録された値を返す。
+// テスト中にランダムに生成するバケット名などを再生時にも同じ値とするために用いる
+func (r *Recorder) Variable(name string, generate func() string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if v, ok := r.cassette.Variables[name]; ok {
+		return v
+	}
+	v := generate()
+	if r.cassette.Variables == nil {
+		r.cassette.Variables = map[string]string{}
+	}
+	r.cassette.Variables[name] = v
+	return v
+}
+
+// Save 記録モードの場合、記録したやりとりをカセットに書き込む。再生モードの場合は何もしない
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cassette.Save(r.path)
+}
+
+// Unused 再生モードで一度も返されなかったやりとりの数を返す
+func (r *Recorder) Unused() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, used := range r.used {
+		if !used {
+			n++
+		}
+	}
+	return n
+}
+
+// Middleware saclientのミドルウェアとして記録/再生を行う
+//
+// 再生モードでは後続のミドルウェア(認証を含む)を呼び出さずにレスポンスを返すため、認証情報は不要となる
+func (r *Recorder) Middleware() saclient.Middleware {
+	return func(req *http.Request, pull func() (saclient.Middleware, bool)) (*http.Response, error) {
+		return r.roundTrip(req, func(req *http.Request) (*http.Response, error) {
+			next, ok := pull()
+			if !ok {
+				return nil, objectstorage.NewError("no next middleware", nil)
+			}
+			return next(req, pull)
+		})
+	}
+}
+
+// RoundTripper http.RoundTripperとして記録/再生を行う。baseがnilの場合はhttp.DefaultTransportを用いる
+func (r *Recorder) RoundTripper(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return r.roundTrip(req, base.RoundTrip)
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func (r *Recorder) roundTrip(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	recorded := Request{
+		Method: req.Method,
+		Path:   r.scrubber.path(req.URL),
+		Body:   r.scrubber.body(body),
+	}
+
+	if r.mode == ModeReplay {
+		if interaction := r.match(&recorded); interaction != nil {
+			return interaction.Response.httpResponse(req), nil
+		}
+		if r.strict {
+			return nil, objectstorage.NewError(fmt.Sprintf("no recorded interaction matches %s %s in %s", recorded.Method, recorded.Path, r.path), nil)
+		}
+		return next(req)
+	}
+
+	res, err := next(req)
+	if err != nil {
+		return res, err
+	}
+	resBody, err := io.ReadAll(res.Body)
+	res.Body.Close() //nolint:errcheck,gosec
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, &Interaction{
+		Request: recorded,
+		Response: Response{
+			StatusCode: res.StatusCode,
+			Headers:    r.scrubber.headers(res.Header),
+			Body:       r.scrubber.body(resBody),
+		},
+	})
+	r.mu.Unlock()
+	return res, nil
+}
+
+// match メソッド、パス、正規化されたボディが一致するやりとりのうち、まだ返していない最初のものを返す
+func (r *Recorder) match(req *Request) *Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] {
+			continue
+		}
+		recorded := interaction.Request
+		if recorded.Method == req.Method && recorded.Path == req.Path && bytes.Equal(recorded.Body, req.Body) {
+			r.used[i] = true
+			return interaction
+		}
+	}
+	return nil
+}
+
+func (res *Response) httpResponse(req *http.Request) *http.Response {
+	header := http.Header{}
+	for k, v := range res.Headers {
+		header[k] = v
+	}
+	// マスクや正規化でボディの長さが変わるため、HEAD以外では実際のボディの長さとする
+	if req.Method != http.MethodHead {
+		header.Set("Content-Length", strconv.Itoa(len(res.Body)))
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", res.StatusCode, http.StatusText(res.StatusCode)),
+		StatusCode:    res.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(res.Body)),
+		ContentLength: int64(len(res.Body)),
+		Request:       req,
+	}
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body.Close() //nolint:errcheck,gosec
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	return body, nil
+}
@@ -0,0 +1,170 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package cassette
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	"github.com/sacloud/saclient-go"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSecret      = "jqRaUo5l+EiEYqP8wos9exbmFfq4/vG8CLPYI2XN"
+	testAccountCode = "acc1234567"
+)
+
+func TestRecorder(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/isk01/v2/permissions/12/keys":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"data":{"id":"AKID","secret":"` + testSecret + `","created_at":"2026-01-01T00:00:00Z"}}`)) //nolint:errcheck,gosec
+		case r.Method == http.MethodGet && r.URL.Path == "/isk01/v2/account":
+			w.Write([]byte(`{"data":{"resource_id":"100","code":"` + testAccountCode + `","created_at":"2026-01-01T00:00:00Z"}}`)) //nolint:errcheck,gosec
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":404,"message":"not found","trace_id":"trace-404"}}`)) //nolint:errcheck,gosec
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	newSiteClient := func(t *testing.T, r *Recorder) *objectstorage.SiteClient {
+		var client saclient.Client
+		require.NoError(t, client.SetWith(saclient.WithTestServer(server), saclient.WithMiddleware(r.Middleware())))
+		siteClient, err := objectstorage.NewSiteClientWithAPIRootURL(&client, server.URL+"/", "isk01")
+		require.NoError(t, err)
+		return siteClient
+	}
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cassettes", "recorder.json")
+	t.Setenv("SAKURA_ACCESS_TOKEN", "token")
+	t.Setenv("SAKURA_ACCESS_TOKEN_SECRET", "secret")
+
+	// 記録
+	{
+		r, err := New(path, &Options{Mode: ModeRecord})
+		require.NoError(t, err)
+		require.Equal(t, "bucket-1", r.Variable("bucketName", func() string { return "bucket-1" }))
+
+		siteClient := newSiteClient(t, r)
+		key, err := objectstorage.NewPermissionOp(siteClient).CreateAccessKey(ctx, "12")
+		require.NoError(t, err)
+		require.Equal(t, testSecret, string(key.Secret.Value), "the response must be passed through untouched while recording")
+
+		account, err := objectstorage.NewAccountOp(siteClient).Read(ctx)
+		require.NoError(t, err)
+		require.Equal(t, testAccountCode, string(account.Code.Value))
+
+		_, err = objectstorage.NewPermissionOp(siteClient).Read(ctx, "99")
+		require.Error(t, err)
+
+		require.NoError(t, r.Save())
+		require.EqualValues(t, 3, hits.Load())
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec
+	require.NoError(t, err)
+	require.NotContains(t, string(data), testSecret)
+	require.NotContains(t, string(data), testAccountCode)
+	require.NotContains(t, string(data), "session=abc")
+	require.NotContains(t, string(data), "dG9rZW46c2VjcmV0", "the Authorization header must not be recorded")
+
+	// 再生
+	t.Setenv("SAKURA_ACCESS_TOKEN", "")
+	t.Setenv("SAKURA_ACCESS_TOKEN_SECRET", "")
+	{
+		r, err := New(path, &Options{Mode: ModeReplay, Strict: true})
+		require.NoError(t, err)
+		require.Equal(t, "bucket-1", r.Variable("bucketName", func() string { return "bucket-2" }))
+		require.Equal(t, 3, r.Unused())
+
+		siteClient := newSiteClient(t, r)
+		key, err := objectstorage.NewPermissionOp(siteClient).CreateAccessKey(ctx, "12")
+		require.NoError(t, err)
+		require.Equal(t, "AKID", string(key.ID.Value))
+		require.Equal(t, objectstorage.Redacted, string(key.Secret.Value))
+
+		account, err := objectstorage.NewAccountOp(siteClient).Read(ctx)
+		require.NoError(t, err)
+		require.Equal(t, objectstorage.Redacted, string(account.Code.Value))
+		require.Equal(t, "100", string(account.ResourceID.Value))
+
+		_, err = objectstorage.NewPermissionOp(siteClient).Read(ctx, "99")
+		require.Error(t, err)
+		require.True(t, saclient.IsNotFoundError(err))
+
+		// 記録されたやりとりは一度しか返さない
+		_, err = objectstorage.NewAccountOp(siteClient).Read(ctx)
+		require.ErrorContains(t, err, "no recorded interaction matches GET /isk01/v2/account")
+
+		require.Equal(t, 0, r.Unused())
+		require.EqualValues(t, 3, hits.Load(), "replay must not reach the server")
+	}
+
+	t.Run("missing cassette", func(t *testing.T) {
+		_, err := New(filepath.Join(t.TempDir(), "missing.json"), nil)
+		require.True(t, errors.Is(err, fs.ErrNotExist))
+	})
+}
+
+func TestRecorder_RoundTripper(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(`<Result><Owner><ID>` + testAccountCode + `</ID></Owner><Body>` + string(body) + `</Body></Result>`)) //nolint:errcheck,gosec
+	}))
+	t.Cleanup(server.Close)
+
+	path := filepath.Join(t.TempDir(), "s3.json")
+	do := func(t *testing.T, r *Recorder, method, url, body string) string {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		require.NoError(t, err)
+		res, err := (&http.Client{Transport: r.RoundTripper(nil)}).Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close() //nolint:errcheck
+		data, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	r, err := New(path, &Options{Mode: ModeRecord})
+	require.NoError(t, err)
+	do(t, r, http.MethodPut, server.URL+"/bucket/key?X-Amz-Signature=aaaa&partNumber=1", "data;chunk-signature="+strings.Repeat("a", 64))
+	do(t, r, http.MethodPost, server.URL+"/bucket?delete", `{"b":1,"a":2}`)
+	require.NoError(t, r.Save())
+
+	data, err := os.ReadFile(path) //nolint:gosec
+	require.NoError(t, err)
+	require.NotContains(t, string(data), testAccountCode)
+	require.NotContains(t, string(data), "aaaa")
+
+	r, err = New(path, &Options{Mode: ModeReplay, Strict: true})
+	require.NoError(t, err)
+	// 署名が異なっていても一致する
+	got := do(t, r, http.MethodPut, server.URL+"/bucket/key?partNumber=1&X-Amz-Signature=bbbb", "data;chunk-signature="+strings.Repeat("b", 64))
+	require.Contains(t, got, "<ID>REDACTED</ID>")
+	// JSONのボディはキーの順序に関わらず一致する
+	got = do(t, r, http.MethodPost, server.URL+"/bucket?delete", `{"a":2, "b":1}`)
+	require.Contains(t, got, `<Body>{"b":1,"a":2}</Body>`)
+	require.EqualValues(t, 2, hits.Load())
+}
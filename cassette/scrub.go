@@ -0,0 +1,106 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package cassette
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+)
+
+var (
+	// droppedResponseHeaders 記録しないレスポンスヘッダ
+	droppedResponseHeaders = []string{"Set-Cookie", "Authorization", "Proxy-Authorization", "Connection", "Transfer-Encoding", "Date"}
+	// droppedQueryParams 記録しないクエリパラメータ。署名付きURLの署名や認証情報を含む
+	droppedQueryParams = []string{"X-Amz-Algorithm", "X-Amz-Credential", "X-Amz-Date", "X-Amz-Expires", "X-Amz-Security-Token", "X-Amz-Signature", "X-Amz-SignedHeaders"}
+
+	// chunkSignature aws-chunkedエンコーディングのチャンクごとの署名
+	chunkSignature = regexp.MustCompile(`chunk-signature=[0-9a-f]{64}`)
+	// s3Owner S3互換APIのレスポンスに含まれる所有者(アカウント)の情報
+	s3Owner = regexp.MustCompile(`(?s)<(Owner|Initiator)>.*?</(Owner|Initiator)>`)
+)
+
+type scrubber struct {
+	fields []string
+}
+
+// path マッチングに用いるパス。クエリパラメータはキー順に並べ、署名に関するものは除外する
+func (s *scrubber) path(u *url.URL) string {
+	query := u.Query()
+	for k := range query {
+		if slices.ContainsFunc(droppedQueryParams, func(p string) bool { return strings.EqualFold(p, k) }) {
+			query.Del(k)
+		}
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return path
+}
+
+// body ボディのシークレットやアカウントコードをマスクする。JSONの場合は正規化した上でマスクする
+func (s *scrubber) body(body []byte) []byte {
+	if len(body) == 0 {
+		return nil
+	}
+	if redacted, err := objectstorage.RedactJSON(body, s.fields...); err == nil {
+		var v any
+		if err := json.Unmarshal(redacted, &v); err == nil {
+			if scrubbed, err := json.Marshal(redactAccountCode(v)); err == nil {
+				return scrubbed
+			}
+		}
+		return redacted
+	}
+	body = chunkSignature.ReplaceAll(body, []byte("chunk-signature="+objectstorage.Redacted))
+	body = s3Owner.ReplaceAll(body, []byte("<$1><ID>"+objectstorage.Redacted+"</ID><DisplayName>"+objectstorage.Redacted+"</DisplayName></$1>"))
+	return body
+}
+
+func (s *scrubber) headers(h http.Header) map[string][]string {
+	res := map[string][]string{}
+	for k, v := range h {
+		if slices.ContainsFunc(droppedResponseHeaders, func(d string) bool { return strings.EqualFold(d, k) }) {
+			continue
+		}
+		res[http.CanonicalHeaderKey(k)] = slices.Clone(v)
+	}
+	if len(res) == 0 {
+		return nil
+	}
+	return res
+}
+
+// redactAccountCode アカウントコード("code"フィールドの文字列値)をマスクする
+//
+// エラーレスポンスの"code"は数値のため対象外となる
+func redactAccountCode(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if _, ok := child.(string); ok && strings.EqualFold(k, "code") {
+				v[k] = objectstorage.Redacted
+				continue
+			}
+			v[k] = redactAccountCode(child)
+		}
+		return v
+	case []any:
+		for i, child := range v {
+			v[i] = redactAccountCode(child)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+// object-storage-exporter バケットの使用量やサイトの状態をPrometheus形式で公開する
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	"github.com/sacloud/object-storage-api-go/exporter"
+	"github.com/sacloud/saclient-go"
+)
+
+var theClient saclient.Client
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "object-storage-exporter: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	fs := theClient.FlagSet(flag.ExitOnError)
+	var (
+		listen      = fs.String("listen", ":9436", "address to serve metrics on")
+		metricsPath = fs.String("metrics-path", "/metrics", "path to serve metrics on")
+		sites       = fs.String("sites", "", "comma separated site IDs to scrape (default: all sites)")
+		interval    = fs.Duration("interval", 5*time.Minute, "interval between scrapes of the API")
+		concurrency = fs.Int("concurrency", exporter.DefaultConcurrency, "maximum number of concurrent API requests")
+		apiRootURL  = fs.String("api-root-url", objectstorage.DefaultAPIRootURL, "root URL of the object storage API")
+	)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [options]\n\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		return err
+	}
+	if err := theClient.SetEnviron(os.Environ()); err != nil {
+		return err
+	}
+
+	backend, err := objectstorage.NewBackend(&theClient, objectstorage.WithAPIRootURL(*apiRootURL))
+	if err != nil {
+		return err
+	}
+	opts := &exporter.Options{Concurrency: *concurrency}
+	if *sites != "" {
+		opts.Sites = strings.Split(*sites, ",")
+	}
+	collector := exporter.NewCollector(backend, opts)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector, collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go collector.Run(ctx, *interval, func(err error) {
+		slog.ErrorContext(ctx, "failed to scrape", "error", err)
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle(*metricsPath, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	server := &http.Server{Addr: *listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx) //nolint:errcheck
+	}()
+
+	slog.InfoContext(ctx, "serving metrics", "listen", *listen, "path", *metricsPath)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
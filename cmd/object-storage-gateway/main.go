@@ -0,0 +1,93 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+// object-storage-gateway APIのトークンを保持し、チームごとの権限を適用した簡易なREST APIを提供する
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	"github.com/sacloud/object-storage-api-go/gateway"
+	"github.com/sacloud/saclient-go"
+)
+
+var theClient saclient.Client
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "object-storage-gateway: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	fs := theClient.FlagSet(flag.ExitOnError)
+	var (
+		listen      = fs.String("listen", ":8080", "address to serve the gateway on")
+		policyPath  = fs.String("policy", "", "path to the policy file (required)")
+		callTimeout = fs.Duration("call-timeout", gateway.DefaultCallTimeout, "timeout of each API call")
+		apiRootURL  = fs.String("api-root-url", objectstorage.DefaultAPIRootURL, "root URL of the object storage API")
+		hashToken   = fs.Bool("hash-token", false, "read a bearer token from stdin, print its token_sha256 for the policy file and exit")
+	)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [options]\n\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		return err
+	}
+
+	if *hashToken {
+		token, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && token == "" {
+			return err
+		}
+		fmt.Println(gateway.HashToken(strings.TrimRight(token, "\r\n")))
+		return nil
+	}
+
+	if *policyPath == "" {
+		return errors.New("-policy is required")
+	}
+	policy, err := gateway.LoadPolicy(*policyPath)
+	if err != nil {
+		return err
+	}
+	if err := theClient.SetEnviron(os.Environ()); err != nil {
+		return err
+	}
+	backend, err := objectstorage.NewBackend(&theClient, objectstorage.WithAPIRootURL(*apiRootURL))
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	handler := gateway.New(backend, policy, &gateway.Options{CallTimeout: *callTimeout})
+	server := &http.Server{Addr: *listen, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx) //nolint:errcheck
+	}()
+
+	slog.InfoContext(ctx, "serving gateway", "listen", *listen, "teams", len(policy.Teams))
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
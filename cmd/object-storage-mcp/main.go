@@ -0,0 +1,59 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+// object-storage-mcp オブジェクトストレージの操作を標準入出力のMCPサーバーとして公開する
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	"github.com/sacloud/object-storage-api-go/mcp"
+	"github.com/sacloud/saclient-go"
+)
+
+var theClient saclient.Client
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "object-storage-mcp: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	fs := theClient.FlagSet(flag.ExitOnError)
+	var (
+		enableWrite = fs.Bool("enable-write", false, "enable tools that create buckets, permissions and access keys")
+		apiRootURL  = fs.String("api-root-url", objectstorage.DefaultAPIRootURL, "root URL of the object storage API")
+		secretsDir  = fs.String("secrets-dir", "", "directory to write secrets of created access keys to; creating access keys is disabled if empty")
+	)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [options]\n\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		return err
+	}
+	if err := theClient.SetEnviron(os.Environ()); err != nil {
+		return err
+	}
+
+	backend, err := objectstorage.NewBackend(&theClient, objectstorage.WithAPIRootURL(*apiRootURL))
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	opts := &mcp.Options{EnableWrite: *enableWrite}
+	if *secretsDir != "" {
+		opts.SecretSink = mcp.DirSecretSink(*secretsDir)
+	}
+	return mcp.NewServer(backend, opts).Serve(ctx, os.Stdin, os.Stdout)
+}
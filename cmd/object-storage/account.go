@@ -0,0 +1,148 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var accountColumns = []column{
+	{"RESOURCE_ID", "resource_id"},
+	{"CODE", "code"},
+	{"CREATED_AT", "created_at"},
+}
+
+// accessKeyColumns アクセスキーの列。シークレットは作成時のみ値を持つ
+var accessKeyColumns = []column{
+	{"ID", "id"},
+	{"SECRET", "secret"},
+	{"CREATED_AT", "created_at"},
+}
+
+func newAccountCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "account",
+		Short: "Read, create and delete the site account of the site specified by --site",
+	}
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "read",
+			Short: "Read the site account",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				api, err := a.accounts()
+				if err != nil {
+					return err
+				}
+				account, err := api.Read(cmd.Context())
+				if err != nil {
+					return err
+				}
+				return a.print(cmd, account, accountColumns)
+			},
+		},
+		&cobra.Command{
+			Use:   "create",
+			Short: "Create the site account",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				api, err := a.accounts()
+				if err != nil {
+					return err
+				}
+				account, err := api.Create(cmd.Context())
+				if err != nil {
+					return err
+				}
+				return a.print(cmd, account, accountColumns)
+			},
+		},
+		&cobra.Command{
+			Use:   "delete",
+			Short: "Delete the site account",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				api, err := a.accounts()
+				if err != nil {
+					return err
+				}
+				return api.Delete(cmd.Context())
+			},
+		},
+		newAccountKeysCommand(a),
+	)
+	return cmd
+}
+
+func newAccountKeysCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "keys",
+		Short: "List, create, read and delete access keys of the site account",
+	}
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "list",
+			Short: "List access keys",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				api, err := a.accounts()
+				if err != nil {
+					return err
+				}
+				keys, err := api.ListAccessKeys(cmd.Context())
+				if err != nil {
+					return err
+				}
+				return a.print(cmd, keys, accessKeyColumns)
+			},
+		},
+		&cobra.Command{
+			Use:   "create",
+			Short: "Create an access key. The secret is only shown here",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				api, err := a.accounts()
+				if err != nil {
+					return err
+				}
+				key, err := api.CreateAccessKey(cmd.Context())
+				if err != nil {
+					return err
+				}
+				return a.print(cmd, key, accessKeyColumns)
+			},
+		},
+		&cobra.Command{
+			Use:               "read KEY_ID",
+			Short:             "Read an access key",
+			Args:              cobra.ExactArgs(1),
+			ValidArgsFunction: a.completeAccountKeys,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				api, err := a.accounts()
+				if err != nil {
+					return err
+				}
+				key, err := api.ReadAccessKey(cmd.Context(), args[0])
+				if err != nil {
+					return err
+				}
+				return a.print(cmd, key, accessKeyColumns)
+			},
+		},
+		&cobra.Command{
+			Use:               "delete KEY_ID",
+			Short:             "Delete an access key",
+			Args:              cobra.ExactArgs(1),
+			ValidArgsFunction: a.completeAccountKeys,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				api, err := a.accounts()
+				if err != nil {
+					return err
+				}
+				return api.DeleteAccessKey(cmd.Context(), args[0])
+			},
+		},
+	)
+	return cmd
+}
@@ -0,0 +1,208 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	"github.com/spf13/cobra"
+)
+
+var bucketColumns = []column{
+	{"NAME", "name"},
+	{"RESOURCE_ID", "resource_id"},
+	{"PLAN", "plan.type"},
+}
+
+var bucketSizeColumns = []column{
+	{"OBJECTS", "num_objects_per_bucket"},
+	{"GIB", "amount_gib_per_bucket"},
+}
+
+var replicationColumns = []column{
+	{"SOURCE", "source_bucket.name"},
+	{"SOURCE_SITE", "source_bucket.cluster_id"},
+	{"DEST", "dest_bucket.name"},
+	{"DEST_SITE", "dest_bucket.cluster_id"},
+}
+
+var encryptionColumns = []column{
+	{"KMS_KEY_ID", "kms_key_id"},
+	{"CONFIGURED_AT", "configured_at"},
+}
+
+func newBucketsCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "buckets",
+		Short: "List, create and delete buckets in the site specified by --site",
+	}
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "list",
+			Short: "List buckets",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				api, err := a.buckets()
+				if err != nil {
+					return err
+				}
+				buckets, err := api.List(cmd.Context())
+				if err != nil {
+					return err
+				}
+				return a.print(cmd, buckets, bucketColumns)
+			},
+		},
+		&cobra.Command{
+			Use:   "create BUCKET",
+			Short: "Create a bucket",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				api, err := a.buckets()
+				if err != nil {
+					return err
+				}
+				bucket, err := api.Create(cmd.Context(), &objectstorage.BucketCreateParams{SiteId: a.siteId, Bucket: args[0]})
+				if err != nil {
+					return err
+				}
+				return a.print(cmd, bucket, []column{{"NAME", "name"}, {"SITE", "cluster_id"}, {"PLAN", "plan.type"}})
+			},
+		},
+		&cobra.Command{
+			Use:               "delete BUCKET",
+			Short:             "Delete a bucket",
+			Args:              cobra.ExactArgs(1),
+			ValidArgsFunction: a.completeBuckets,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				api, err := a.buckets()
+				if err != nil {
+					return err
+				}
+				return api.Delete(cmd.Context(), args[0])
+			},
+		},
+	)
+	return cmd
+}
+
+func newBucketCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bucket",
+		Short: "Read and configure settings of a bucket in the site specified by --site",
+	}
+
+	// bucketCommand 第1引数のバケットに対するBucketExtraAPIの呼び出しを行うコマンドを返す
+	bucketCommand := func(use, short string, args cobra.PositionalArgs, run func(cmd *cobra.Command, api objectstorage.BucketExtraAPI, args []string) error) *cobra.Command {
+		return &cobra.Command{
+			Use:               use,
+			Short:             short,
+			Args:              args,
+			ValidArgsFunction: a.completeBuckets,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				api, err := a.bucketExtra(args[0])
+				if err != nil {
+					return err
+				}
+				return run(cmd, api, args[1:])
+			},
+		}
+	}
+	// readCommand 第1引数のバケットの設定を読み込んで出力するコマンドを返す
+	readCommand := func(use, short string, columns []column, read func(ctx context.Context, api objectstorage.BucketExtraAPI) (any, error)) *cobra.Command {
+		return bucketCommand(use, short, cobra.ExactArgs(1), func(cmd *cobra.Command, api objectstorage.BucketExtraAPI, args []string) error {
+			res, err := read(cmd.Context(), api)
+			if err != nil {
+				return err
+			}
+			return a.print(cmd, res, columns)
+		})
+	}
+
+	encryption := &cobra.Command{Use: "encryption", Short: "Read and configure server-side encryption of a bucket"}
+	encryption.AddCommand(
+		readCommand("read BUCKET", "Read the encryption setting", encryptionColumns, func(ctx context.Context, api objectstorage.BucketExtraAPI) (any, error) {
+			return api.ReadEncryption(ctx)
+		}),
+		bucketCommand("enable BUCKET KMS_KEY_ID", "Enable encryption with a KMS key", cobra.ExactArgs(2), func(cmd *cobra.Command, api objectstorage.BucketExtraAPI, args []string) error {
+			return api.EnableEncryption(cmd.Context(), args[0])
+		}),
+		bucketCommand("disable BUCKET", "Disable encryption", cobra.ExactArgs(1), func(cmd *cobra.Command, api objectstorage.BucketExtraAPI, args []string) error {
+			return api.DisableEncryption(cmd.Context())
+		}),
+	)
+
+	replication := &cobra.Command{Use: "replication", Short: "Read and configure replication of a bucket"}
+	replication.AddCommand(
+		readCommand("read BUCKET", "Read the replication setting", replicationColumns, func(ctx context.Context, api objectstorage.BucketExtraAPI) (any, error) {
+			return api.ReadReplication(ctx)
+		}),
+		bucketCommand("enable BUCKET DEST_BUCKET", "Enable replication to a bucket in another site", cobra.ExactArgs(2), func(cmd *cobra.Command, api objectstorage.BucketExtraAPI, args []string) error {
+			res, err := api.EnableReplication(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			return a.print(cmd, res, replicationColumns)
+		}),
+		bucketCommand("disable BUCKET", "Disable replication", cobra.ExactArgs(1), func(cmd *cobra.Command, api objectstorage.BucketExtraAPI, args []string) error {
+			return api.DisableReplication(cmd.Context())
+		}),
+	)
+
+	metering := &cobra.Command{
+		Use:               "metering BUCKET",
+		Short:             "Read metering of a bucket",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeBuckets,
+	}
+	now := time.Now()
+	from := metering.Flags().String("from", now.AddDate(0, -1, 0).Format(time.DateOnly), "start date (YYYY-MM-DD)")
+	to := metering.Flags().String("to", now.Format(time.DateOnly), "end date (YYYY-MM-DD)")
+	metering.RunE = func(cmd *cobra.Command, args []string) error {
+		fromTime, err := time.Parse(time.DateOnly, *from)
+		if err != nil {
+			return fmt.Errorf("invalid --from: %w", err)
+		}
+		toTime, err := time.Parse(time.DateOnly, *to)
+		if err != nil {
+			return fmt.Errorf("invalid --to: %w", err)
+		}
+		api, err := a.siteStatus()
+		if err != nil {
+			return err
+		}
+		res, err := api.ReadBucketMetering(cmd.Context(), args[0], fromTime, toTime)
+		if err != nil {
+			return err
+		}
+		return a.print(cmd, res, []column{
+			{"MONTH", "year_month"},
+			{"BASIC", "basic.charge"},
+			{"STORAGE_GIB", "storage_usage.usage"},
+			{"STORAGE", "storage_usage.charge"},
+			{"OBJECTS", "num_objects.usage"},
+			{"TRANSACTIONS", "num_transactions.usage"},
+			{"TRANSFER_GIB", "transfer_amount.usage"},
+		})
+	}
+
+	cmd.AddCommand(
+		encryption,
+		replication,
+		readCommand("usage BUCKET", "Read the usage of a bucket", bucketSizeColumns, func(ctx context.Context, api objectstorage.BucketExtraAPI) (any, error) {
+			return api.ReadUsage(ctx)
+		}),
+		readCommand("quota BUCKET", "Read the quota of a bucket", bucketSizeColumns, func(ctx context.Context, api objectstorage.BucketExtraAPI) (any, error) {
+			return api.ReadQuota(ctx)
+		}),
+		readCommand("penalty BUCKET", "Read the penalty of a bucket", bucketSizeColumns, func(ctx context.Context, api objectstorage.BucketExtraAPI) (any, error) {
+			return api.ReadPenalty(ctx)
+		}),
+		metering,
+	)
+	return cmd
+}
@@ -0,0 +1,184 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"strconv"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	"github.com/spf13/cobra"
+)
+
+func (a *app) sites(siteId string) (objectstorage.SiteAPI, error) {
+	backend, err := a.Backend()
+	if err != nil {
+		return nil, err
+	}
+	return backend.Sites(siteId)
+}
+
+func (a *app) buckets() (objectstorage.BucketAPI, error) {
+	backend, err := a.Backend()
+	if err != nil {
+		return nil, err
+	}
+	return backend.Buckets(a.siteId)
+}
+
+func (a *app) bucketExtra(bucket string) (objectstorage.BucketExtraAPI, error) {
+	backend, err := a.Backend()
+	if err != nil {
+		return nil, err
+	}
+	return backend.BucketExtra(a.siteId, bucket)
+}
+
+func (a *app) accounts() (objectstorage.AccountAPI, error) {
+	backend, err := a.Backend()
+	if err != nil {
+		return nil, err
+	}
+	return backend.Accounts(a.siteId)
+}
+
+func (a *app) permissions() (objectstorage.PermissionsAPI, error) {
+	backend, err := a.Backend()
+	if err != nil {
+		return nil, err
+	}
+	return backend.Permissions(a.siteId)
+}
+
+func (a *app) siteStatus() (objectstorage.SiteStatusAPI, error) {
+	backend, err := a.Backend()
+	if err != nil {
+		return nil, err
+	}
+	return backend.SiteStatus(a.siteId)
+}
+
+// completions 補完の候補を返す。APIの呼び出しに失敗した場合はデバッグログに記録してエラーとする
+func completions(values []string, err error) ([]string, cobra.ShellCompDirective) {
+	if err != nil {
+		cobra.CompDebugln(err.Error(), true)
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return values, cobra.ShellCompDirectiveNoFileComp
+}
+
+func (a *app) completeSites(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completions(func() ([]string, error) {
+		api, err := a.sites("")
+		if err != nil {
+			return nil, err
+		}
+		sites, err := api.List(cmd.Context())
+		if err != nil {
+			return nil, err
+		}
+		var res []string
+		for _, site := range sites {
+			res = append(res, site.ID.Value+"\t"+site.DisplayName.Value)
+		}
+		return res, nil
+	}())
+}
+
+// completeBuckets 第1引数としてバケット名を補完する
+func (a *app) completeBuckets(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return a.completeBucketNames(cmd, args, toComplete)
+}
+
+// completeBucketNames 引数の位置に関わらずバケット名を補完する。フラグの値の補完に用いる
+func (a *app) completeBucketNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completions(func() ([]string, error) {
+		api, err := a.buckets()
+		if err != nil {
+			return nil, err
+		}
+		buckets, err := api.List(cmd.Context())
+		if err != nil {
+			return nil, err
+		}
+		var res []string
+		for _, bucket := range buckets {
+			res = append(res, string(bucket.Name))
+		}
+		return res, nil
+	}())
+}
+
+func (a *app) completePermissions(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completions(func() ([]string, error) {
+		api, err := a.permissions()
+		if err != nil {
+			return nil, err
+		}
+		permissions, err := api.List(cmd.Context())
+		if err != nil {
+			return nil, err
+		}
+		var res []string
+		for _, permission := range permissions {
+			res = append(res, strconv.FormatInt(int64(permission.ID.Value), 10)+"\t"+string(permission.DisplayName.Value))
+		}
+		return res, nil
+	}())
+}
+
+// completePermissionKeys 第1引数のパーミッションIDと第2引数のアクセスキーIDを補完する
+func (a *app) completePermissionKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return a.completePermissions(cmd, args, toComplete)
+	case 1:
+		return completions(func() ([]string, error) {
+			api, err := a.permissions()
+			if err != nil {
+				return nil, err
+			}
+			keys, err := api.ListAccessKeys(cmd.Context(), args[0])
+			if err != nil {
+				return nil, err
+			}
+			var res []string
+			for _, key := range keys {
+				res = append(res, string(key.ID.Value))
+			}
+			return res, nil
+		}())
+	default:
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func (a *app) completeAccountKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completions(func() ([]string, error) {
+		api, err := a.accounts()
+		if err != nil {
+			return nil, err
+		}
+		keys, err := api.ListAccessKeys(cmd.Context())
+		if err != nil {
+			return nil, err
+		}
+		var res []string
+		for _, key := range keys {
+			res = append(res, string(key.ID.Value))
+		}
+		return res, nil
+	}())
+}
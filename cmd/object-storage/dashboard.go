@@ -0,0 +1,46 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/sacloud/object-storage-api-go/dashboard"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+func newDashboardCommand(a *app) *cobra.Command {
+	var interval time.Duration
+	cmd := &cobra.Command{
+		Use:   "dashboard",
+		Short: "Show an interactive dashboard of sites, buckets and their usage",
+		Long: "Show an interactive dashboard of sites, buckets and their usage.\n\n" +
+			"Starts from the bucket list of the site when --site is specified, otherwise from the site list.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			backend, err := a.Backend()
+			if err != nil {
+				return err
+			}
+			opts := dashboard.Options{Interval: interval}
+			if cmd.Flags().Changed("site") {
+				opts.SiteID = a.siteId
+			}
+
+			// 端末の場合はキー入力を1文字ずつ受け取るためrawモードにする
+			if f, ok := cmd.InOrStdin().(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+				state, err := term.MakeRaw(int(f.Fd()))
+				if err != nil {
+					return err
+				}
+				defer term.Restore(int(f.Fd()), state) //nolint:errcheck
+			}
+			return dashboard.Run(cmd.Context(), backend, cmd.InOrStdin(), cmd.OutOrStdout(), opts)
+		},
+	}
+	cmd.Flags().DurationVar(&interval, "interval", dashboard.DefaultInterval, "interval of refreshing the dashboard")
+	return cmd
+}
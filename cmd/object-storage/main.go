@@ -0,0 +1,99 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+// object-storage オブジェクトストレージのAPIを操作するコマンドラインツール
+//
+// 認証情報はsaclientのプロファイル、環境変数またはフラグから読み込む
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	"github.com/sacloud/saclient-go"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := newRootCommand(&app{}).ExecuteContext(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "object-storage: %v\n", err)
+		stop()
+		os.Exit(1)
+	}
+}
+
+// app コマンド間で共有する設定と状態
+type app struct {
+	client     saclient.Client
+	apiRootURL string
+	siteId     string
+	output     string
+	template   string
+
+	// newBackend Backendを作成する関数。テストでフェイクに差し替えるために用いる
+	newBackend func() (objectstorage.Backend, error)
+	backend    objectstorage.Backend
+}
+
+// Backend Backendを返す。補完の際にも用いるため、初回の呼び出し時に作成する
+func (a *app) Backend() (objectstorage.Backend, error) {
+	if a.backend != nil {
+		return a.backend, nil
+	}
+	newBackend := a.newBackend
+	if newBackend == nil {
+		newBackend = func() (objectstorage.Backend, error) {
+			if err := a.client.SetEnviron(os.Environ()); err != nil {
+				return nil, err
+			}
+			return objectstorage.NewBackend(&a.client, objectstorage.WithAPIRootURL(a.apiRootURL))
+		}
+	}
+	backend, err := newBackend()
+	if err != nil {
+		return nil, err
+	}
+	a.backend = backend
+	return backend, nil
+}
+
+func newRootCommand(a *app) *cobra.Command {
+	root := &cobra.Command{
+		Use:           "object-storage",
+		Short:         "Operate SAKURA Cloud Object Storage via the API",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return validateOutput(a.output)
+		},
+	}
+
+	flags := root.PersistentFlags()
+	flags.AddGoFlagSet(a.client.FlagSet(flag.ContinueOnError))
+	flags.StringVar(&a.apiRootURL, "api-root-url", objectstorage.DefaultAPIRootURL, "root URL of the object storage API")
+	flags.StringVar(&a.siteId, "site", "isk01", "ID of the site to operate on")
+	flags.StringVarP(&a.output, "output", "o", outputTable, "output format: table, json, yaml or template")
+	flags.StringVar(&a.template, "template", "", "Go template applied to the JSON representation when --output=template")
+	root.RegisterFlagCompletionFunc("site", a.completeSites)                                                             //nolint:errcheck,gosec
+	root.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(outputFormats, cobra.ShellCompDirectiveNoFileComp)) //nolint:errcheck,gosec
+
+	root.AddCommand(
+		newSitesCommand(a),
+		newStatusCommand(a),
+		newQuotaCommand(a),
+		newBucketsCommand(a),
+		newBucketCommand(a),
+		newAccountCommand(a),
+		newPermissionsCommand(a),
+		newDashboardCommand(a),
+		newPolicyCommand(a),
+	)
+	return root
+}
@@ -0,0 +1,183 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	"github.com/sacloud/object-storage-api-go/objectstoragetest"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v3"
+)
+
+func run(t *testing.T, fake *objectstoragetest.Fake, args ...string) (string, error) {
+	t.Helper()
+	cmd := newRootCommand(&app{newBackend: func() (objectstorage.Backend, error) { return fake, nil }})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(args)
+	err := cmd.ExecuteContext(context.Background())
+	return out.String(), err
+}
+
+func TestCLI_Output(t *testing.T) {
+	fake := objectstoragetest.NewFake()
+	_, err := run(t, fake, "buckets", "create", "bucket1")
+	require.NoError(t, err)
+
+	out, err := run(t, fake, "buckets", "list")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 2)
+	require.True(t, strings.HasPrefix(lines[0], "NAME"))
+	require.True(t, strings.HasPrefix(lines[1], "bucket1"))
+
+	out, err = run(t, fake, "buckets", "list", "-o", "json")
+	require.NoError(t, err)
+	var buckets []map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &buckets))
+	require.Len(t, buckets, 1)
+	require.Equal(t, "bucket1", buckets[0]["name"])
+
+	out, err = run(t, fake, "buckets", "list", "-o", "yaml")
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal([]byte(out), &buckets))
+	require.Equal(t, "bucket1", buckets[0]["name"])
+
+	out, err = run(t, fake, "buckets", "list", "-o", "template", "--template", "{{range .}}{{.name}};{{end}}")
+	require.NoError(t, err)
+	require.Equal(t, "bucket1;\n", out)
+
+	_, err = run(t, fake, "buckets", "list", "-o", "xml")
+	require.Error(t, err)
+}
+
+func TestCLI_Permissions(t *testing.T) {
+	fake := objectstoragetest.NewFake()
+	_, err := run(t, fake, "buckets", "create", "bucket1")
+	require.NoError(t, err)
+
+	out, err := run(t, fake, "permissions", "create", "perm1", "--bucket", "bucket1:r", "-o", "json")
+	require.NoError(t, err)
+	var permission struct {
+		ID             int64 `json:"id"`
+		BucketControls []struct {
+			BucketName string `json:"bucket_name"`
+			CanRead    bool   `json:"can_read"`
+			CanWrite   bool   `json:"can_write"`
+		} `json:"bucket_controls"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &permission))
+	require.Len(t, permission.BucketControls, 1)
+	require.Equal(t, "bucket1", permission.BucketControls[0].BucketName)
+	require.True(t, permission.BucketControls[0].CanRead)
+	require.False(t, permission.BucketControls[0].CanWrite)
+
+	_, err = run(t, fake, "permissions", "create", "perm2", "--bucket", "bucket1:x")
+	require.Error(t, err)
+
+	// シークレットは作成時のみ表示される
+	id := strconv.FormatInt(permission.ID, 10)
+	out, err = run(t, fake, "permissions", "keys", "create", id, "-o", "json")
+	require.NoError(t, err)
+	var key map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &key))
+	require.NotEmpty(t, key["secret"])
+
+	out, err = run(t, fake, "permissions", "keys", "list", id, "-o", "json")
+	require.NoError(t, err)
+	var keys []map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &keys))
+	require.Len(t, keys, 1)
+	require.Empty(t, keys[0]["secret"])
+}
+
+func TestCLI_Completion(t *testing.T) {
+	fake := objectstoragetest.NewFake()
+	mustRun(t, fake, "buckets", "create", "bucket1")
+	mustRun(t, fake, "buckets", "create", "bucket2")
+
+	out := mustRun(t, fake, "__complete", "buckets", "delete", "")
+	require.Contains(t, out, "bucket1")
+	require.Contains(t, out, "bucket2")
+
+	out = mustRun(t, fake, "__complete", "permissions", "create", "perm1", "--bucket", "")
+	require.Contains(t, out, "bucket1")
+
+	out = mustRun(t, fake, "__complete", "--site", "")
+	require.Contains(t, out, "isk01")
+}
+
+func mustRun(t *testing.T, fake *objectstoragetest.Fake, args ...string) string {
+	t.Helper()
+	out, err := run(t, fake, args...)
+	require.NoError(t, err)
+	return out
+}
+
+func TestCLI_Dashboard(t *testing.T) {
+	fake := objectstoragetest.NewFake()
+	mustRun(t, fake, "buckets", "create", "bucket1")
+
+	cmd := newRootCommand(&app{newBackend: func() (objectstorage.Backend, error) { return fake, nil }})
+	var out bytes.Buffer
+	cmd.SetIn(strings.NewReader("\r"))
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"dashboard", "--site", "isk01"})
+	require.NoError(t, cmd.ExecuteContext(context.Background()))
+	require.Contains(t, out.String(), "sites > isk01 > bucket1")
+}
+
+func TestCLI_Policy(t *testing.T) {
+	fake := objectstoragetest.NewFake()
+	mustRun(t, fake, "buckets", "create", "web-bucket")
+	mustRun(t, fake, "buckets", "create", "tmp-bucket")
+
+	dir := t.TempDir()
+	policyPath := filepath.Join(dir, "policy.yaml")
+	require.NoError(t, os.WriteFile(policyPath, []byte(`
+rules:
+  - id: bucket-team-prefix
+    resource: buckets
+    assert: startsWith(name, "web-")
+    severity: low
+    message: "bucket {{ name }} does not have a team prefix"
+`), 0o600))
+	junitPath, sarifPath := filepath.Join(dir, "junit.xml"), filepath.Join(dir, "result.sarif")
+
+	out, err := run(t, fake, "policy", "check", policyPath, "--site", "isk01", "--junit", junitPath, "--sarif", sarifPath)
+	require.Error(t, err)
+	require.Contains(t, out, "bucket tmp-bucket does not have a team prefix")
+	require.NotContains(t, out, "bucket web-bucket")
+	for _, path := range []string{junitPath, sarifPath} {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Contains(t, string(data), "bucket-team-prefix")
+	}
+
+	// 閾値未満の重大度の違反では失敗しない
+	mustRun(t, fake, "policy", "check", policyPath, "--fail-on", "medium")
+}
+
+func TestCLI_QuotaHeadroom(t *testing.T) {
+	fake := objectstoragetest.NewFake()
+	mustRun(t, fake, "buckets", "create", "bucket1")
+
+	out := mustRun(t, fake, "quota", "headroom", "--site", "isk01", "-o", "json")
+	var rows []map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &rows))
+	require.Len(t, rows, 5)
+	require.Equal(t, "isk01", rows[1]["site_id"])
+	require.Equal(t, "num_buckets", rows[1]["dimension"])
+	require.InDelta(t, 1, rows[1]["used"], 0)
+}
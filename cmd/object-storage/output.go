@@ -0,0 +1,145 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	outputTable    = "table"
+	outputJSON     = "json"
+	outputYAML     = "yaml"
+	outputTemplate = "template"
+)
+
+var outputFormats = []string{outputTable, outputJSON, outputYAML, outputTemplate}
+
+func validateOutput(output string) error {
+	if !slices.Contains(outputFormats, output) {
+		return fmt.Errorf("unknown output format %q: must be one of %s", output, strings.Join(outputFormats, ", "))
+	}
+	return nil
+}
+
+// column テーブル形式で出力する列。PathはJSON表現での"."区切りのキー
+type column struct {
+	Header string
+	Path   string
+}
+
+// print vを--outputに従って出力する
+//
+// vはJSONに変換してから出力するため、各形式のキーはAPIのJSONのキーと同じになる。
+// ogenの型のMarshalJSONはポインタのレシーバーを持つため、vにはポインタかスライスを渡すこと
+func (a *app) print(cmd *cobra.Command, v any, columns []column) error {
+	data, err := toJSONValue(v)
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	switch a.output {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(data)
+	case outputYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(data); err != nil {
+			return err
+		}
+		return enc.Close()
+	case outputTemplate:
+		if a.template == "" {
+			return fmt.Errorf("--template is required when --output=%s", outputTemplate)
+		}
+		tmpl, err := template.New("output").Option("missingkey=zero").Parse(a.template)
+		if err != nil {
+			return err
+		}
+		if err := tmpl.Execute(w, data); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w)
+		return err
+	default:
+		return printTable(w, data, columns)
+	}
+}
+
+// toJSONValue vをJSONに変換し、map[string]anyや[]anyなどの値として読み込む
+func toJSONValue(v any) (any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var data any
+	if err := dec.Decode(&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func printTable(w io.Writer, data any, columns []column) error {
+	rows, ok := data.([]any)
+	if !ok {
+		rows = []any{data}
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	headers := make([]string, len(columns))
+	for i, c := range columns {
+		headers[i] = c.Header
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for i, c := range columns {
+			cells[i] = cell(lookup(row, c.Path))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+func lookup(v any, path string) any {
+	for key := range strings.SplitSeq(path, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	return v
+}
+
+func cell(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []any:
+		values := make([]string, len(v))
+		for i, e := range v {
+			values[i] = cell(e)
+		}
+		return strings.Join(values, ",")
+	case map[string]any:
+		raw, _ := json.Marshal(v) //nolint:errchkjson
+		return string(raw)
+	default:
+		return fmt.Sprint(v)
+	}
+}
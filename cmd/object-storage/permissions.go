@@ -0,0 +1,225 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/spf13/cobra"
+)
+
+var permissionColumns = []column{
+	{"ID", "id"},
+	{"NAME", "display_name"},
+	{"BUCKETS", "bucket_controls"},
+	{"CREATED_AT", "created_at"},
+}
+
+// parseBucketControls "BUCKET[:r|w|rw]"の形式の指定をBucketControlsに変換する。権限を省略した場合はrw
+func parseBucketControls(specs []string) (v2.BucketControls, error) {
+	var controls v2.BucketControls
+	for _, spec := range specs {
+		name, mode, ok := strings.Cut(spec, ":")
+		if !ok {
+			mode = "rw"
+		}
+		if name == "" || mode == "" || strings.Trim(mode, "rw") != "" {
+			return nil, fmt.Errorf("invalid bucket control %q: must be BUCKET[:r|w|rw]", spec)
+		}
+		controls = append(controls, v2.BucketControlsItem{
+			BucketName: v2.NewOptBucketName(v2.BucketName(name)),
+			CanRead:    v2.NewOptCanRead(v2.CanRead(strings.Contains(mode, "r"))),
+			CanWrite:   v2.NewOptCanWrite(v2.CanWrite(strings.Contains(mode, "w"))),
+		})
+	}
+	return controls, nil
+}
+
+// bucketControlsFlag パーミッションの作成/更新で用いる--bucketフラグを追加する
+func bucketControlsFlag(a *app, cmd *cobra.Command) *[]string {
+	specs := cmd.Flags().StringArray("bucket", nil, "bucket to grant access in BUCKET[:r|w|rw] format (repeatable, default mode: rw)")
+	cmd.RegisterFlagCompletionFunc("bucket", a.completeBucketNames) //nolint:errcheck,gosec
+	return specs
+}
+
+func newPermissionsCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "permissions",
+		Short: "Manage permissions and their access keys in the site specified by --site",
+	}
+
+	create := &cobra.Command{
+		Use:   "create DISPLAY_NAME",
+		Short: "Create a permission",
+		Args:  cobra.ExactArgs(1),
+	}
+	createBuckets := bucketControlsFlag(a, create)
+	create.RunE = func(cmd *cobra.Command, args []string) error {
+		controls, err := parseBucketControls(*createBuckets)
+		if err != nil {
+			return err
+		}
+		api, err := a.permissions()
+		if err != nil {
+			return err
+		}
+		permission, err := api.Create(cmd.Context(), args[0], controls)
+		if err != nil {
+			return err
+		}
+		return a.print(cmd, permission, permissionColumns)
+	}
+
+	update := &cobra.Command{
+		Use:               "update PERMISSION_ID DISPLAY_NAME",
+		Short:             "Update a permission. The bucket controls are replaced with --bucket",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: a.completePermissions,
+	}
+	updateBuckets := bucketControlsFlag(a, update)
+	update.RunE = func(cmd *cobra.Command, args []string) error {
+		controls, err := parseBucketControls(*updateBuckets)
+		if err != nil {
+			return err
+		}
+		api, err := a.permissions()
+		if err != nil {
+			return err
+		}
+		permission, err := api.Update(cmd.Context(), args[0], args[1], controls)
+		if err != nil {
+			return err
+		}
+		return a.print(cmd, permission, permissionColumns)
+	}
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "list",
+			Short: "List permissions",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				api, err := a.permissions()
+				if err != nil {
+					return err
+				}
+				permissions, err := api.List(cmd.Context())
+				if err != nil {
+					return err
+				}
+				return a.print(cmd, permissions, permissionColumns)
+			},
+		},
+		create,
+		&cobra.Command{
+			Use:               "read PERMISSION_ID",
+			Short:             "Read a permission",
+			Args:              cobra.ExactArgs(1),
+			ValidArgsFunction: a.completePermissions,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				api, err := a.permissions()
+				if err != nil {
+					return err
+				}
+				permission, err := api.Read(cmd.Context(), args[0])
+				if err != nil {
+					return err
+				}
+				return a.print(cmd, permission, permissionColumns)
+			},
+		},
+		update,
+		&cobra.Command{
+			Use:               "delete PERMISSION_ID",
+			Short:             "Delete a permission",
+			Args:              cobra.ExactArgs(1),
+			ValidArgsFunction: a.completePermissions,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				api, err := a.permissions()
+				if err != nil {
+					return err
+				}
+				return api.Delete(cmd.Context(), args[0])
+			},
+		},
+		newPermissionKeysCommand(a),
+	)
+	return cmd
+}
+
+func newPermissionKeysCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "keys",
+		Short: "List, create, read and delete access keys of a permission",
+	}
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:               "list PERMISSION_ID",
+			Short:             "List access keys",
+			Args:              cobra.ExactArgs(1),
+			ValidArgsFunction: a.completePermissions,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				api, err := a.permissions()
+				if err != nil {
+					return err
+				}
+				keys, err := api.ListAccessKeys(cmd.Context(), args[0])
+				if err != nil {
+					return err
+				}
+				return a.print(cmd, keys, accessKeyColumns)
+			},
+		},
+		&cobra.Command{
+			Use:               "create PERMISSION_ID",
+			Short:             "Create an access key. The secret is only shown here",
+			Args:              cobra.ExactArgs(1),
+			ValidArgsFunction: a.completePermissions,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				api, err := a.permissions()
+				if err != nil {
+					return err
+				}
+				key, err := api.CreateAccessKey(cmd.Context(), args[0])
+				if err != nil {
+					return err
+				}
+				return a.print(cmd, key, accessKeyColumns)
+			},
+		},
+		&cobra.Command{
+			Use:               "read PERMISSION_ID KEY_ID",
+			Short:             "Read an access key",
+			Args:              cobra.ExactArgs(2),
+			ValidArgsFunction: a.completePermissionKeys,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				api, err := a.permissions()
+				if err != nil {
+					return err
+				}
+				key, err := api.ReadAccessKey(cmd.Context(), args[0], args[1])
+				if err != nil {
+					return err
+				}
+				return a.print(cmd, key, accessKeyColumns)
+			},
+		},
+		&cobra.Command{
+			Use:               "delete PERMISSION_ID KEY_ID",
+			Short:             "Delete an access key",
+			Args:              cobra.ExactArgs(2),
+			ValidArgsFunction: a.completePermissionKeys,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				api, err := a.permissions()
+				if err != nil {
+					return err
+				}
+				return api.DeleteAccessKey(cmd.Context(), args[0], args[1])
+			},
+		},
+	)
+	return cmd
+}
@@ -0,0 +1,113 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sacloud/object-storage-api-go/policy"
+	"github.com/spf13/cobra"
+)
+
+var violationColumns = []column{
+	{"SEVERITY", "severity"},
+	{"RULE", "rule_id"},
+	{"RESOURCE", "resource.id"},
+	{"MESSAGE", "message"},
+}
+
+func newPolicyCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Evaluate buckets, permissions and keys against policy rules",
+	}
+
+	var junitPath, sarifPath, failOn string
+	check := &cobra.Command{
+		Use:   "check POLICY_FILE",
+		Short: "Check resources against the rules of the policy file and print violations",
+		Long: "Check resources against the rules of the policy file and print violations.\n\n" +
+			"Checks all sites unless --site is specified. Fails when there is a violation of --fail-on or higher severity, " +
+			"or a rule cannot be evaluated.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			threshold, err := policy.ParseSeverity(failOn)
+			if err != nil {
+				return err
+			}
+			p, err := policy.LoadPolicy(args[0])
+			if err != nil {
+				return err
+			}
+			backend, err := a.Backend()
+			if err != nil {
+				return err
+			}
+			opts := &policy.CollectOptions{}
+			if cmd.Flags().Changed("site") {
+				opts.SiteIDs = []string{a.siteId}
+			}
+			inv, err := policy.Collect(cmd.Context(), backend, opts)
+			if inv == nil {
+				return err
+			}
+			// 取得に失敗した項目を参照するルールは評価の失敗として報告されるため続行する
+			if err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "warning: %v\n", err)
+			}
+
+			report := p.Evaluate(inv)
+			if err := writeReport(junitPath, report, policy.WriteJUnit); err != nil {
+				return err
+			}
+			if err := writeReport(sarifPath, report, policy.WriteSARIF); err != nil {
+				return err
+			}
+			violations := report.Violations()
+			if violations == nil {
+				violations = []policy.Violation{}
+			}
+			if err := a.print(cmd, violations, violationColumns); err != nil {
+				return err
+			}
+
+			var errs []string
+			for _, result := range report.Errors() {
+				errs = append(errs, fmt.Sprintf("%s %s: %v", result.Rule.ID, result.Resource.ID, result.Err))
+			}
+			if len(errs) > 0 {
+				return fmt.Errorf("failed to evaluate rules:\n%s", strings.Join(errs, "\n"))
+			}
+			if report.Failed(threshold) {
+				return fmt.Errorf("policy violations of %s or higher severity found", threshold)
+			}
+			return nil
+		},
+	}
+	check.Flags().StringVar(&junitPath, "junit", "", "write the result as JUnit XML to the file")
+	check.Flags().StringVar(&sarifPath, "sarif", "", "write the result as SARIF to the file")
+	check.Flags().StringVar(&failOn, "fail-on", string(policy.SeverityLow), "minimum severity of violations that fails the check: low, medium, high or critical")
+	check.RegisterFlagCompletionFunc("fail-on", cobra.FixedCompletions([]string{"low", "medium", "high", "critical"}, cobra.ShellCompDirectiveNoFileComp)) //nolint:errcheck,gosec
+	cmd.AddCommand(check)
+	return cmd
+}
+
+// writeReport pathが空でない場合にwriteで評価の結果をファイルに書き込む
+func writeReport(path string, report *policy.Report, write func(w io.Writer, report *policy.Report) error) error {
+	if path == "" {
+		return nil
+	}
+	f, err := os.Create(path) //nolint:gosec
+	if err != nil {
+		return err
+	}
+	if err := write(f, report); err != nil {
+		f.Close() //nolint:errcheck,gosec
+		return err
+	}
+	return f.Close()
+}
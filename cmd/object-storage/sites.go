@@ -0,0 +1,170 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	"github.com/spf13/cobra"
+)
+
+var siteColumns = []column{
+	{"ID", "id"},
+	{"NAME", "display_name"},
+	{"REGION", "region"},
+	{"ENDPOINT", "endpoint_base"},
+}
+
+func newSitesCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sites",
+		Short: "List and read sites",
+	}
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "list",
+			Short: "List sites",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				api, err := a.sites("")
+				if err != nil {
+					return err
+				}
+				sites, err := api.List(cmd.Context())
+				if err != nil {
+					return err
+				}
+				return a.print(cmd, sites, siteColumns)
+			},
+		},
+		&cobra.Command{
+			Use:               "read SITE_ID",
+			Short:             "Read a site",
+			Args:              cobra.ExactArgs(1),
+			ValidArgsFunction: a.completeSites,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				api, err := a.sites("")
+				if err != nil {
+					return err
+				}
+				site, err := api.Read(cmd.Context(), args[0])
+				if err != nil {
+					return err
+				}
+				return a.print(cmd, site, siteColumns)
+			},
+		},
+		&cobra.Command{
+			Use:   "plans",
+			Short: "List plans of the site specified by --site",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				api, err := a.sites(a.siteId)
+				if err != nil {
+					return err
+				}
+				plans, err := api.ListPlans(cmd.Context())
+				if err != nil {
+					return err
+				}
+				return a.print(cmd, plans, []column{
+					{"TYPE", "type"},
+					{"CLUSTER", "cluster_id"},
+					{"CAPACITY_GIB", "capacity_gib"},
+					{"MONTHLY_FEE", "fee.monthly"},
+				})
+			},
+		},
+	)
+	return cmd
+}
+
+func newStatusCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Read the status of the site specified by --site",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			api, err := a.siteStatus()
+			if err != nil {
+				return err
+			}
+			status, err := api.Read(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return a.print(cmd, status, []column{
+				{"STATUS", "status_code.status"},
+				{"ACCEPT_NEW", "accept_new"},
+				{"MESSAGE", "message"},
+				{"STARTED_AT", "started_at"},
+			})
+		},
+	}
+}
+
+func newQuotaCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "quota",
+		Short: "Read the quota of the site specified by --site",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			api, err := a.siteStatus()
+			if err != nil {
+				return err
+			}
+			quota, err := api.ReadQuota(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return a.print(cmd, quota, []column{
+				{"ROOT_KEYS", "num_root_keys"},
+				{"BUCKETS", "num_buckets"},
+				{"PERMISSIONS", "num_permissions"},
+				{"KEYS_PER_PERMISSION", "num_keys_per_permission"},
+				{"BUCKETS_PER_PERMISSION", "num_buckets_per_permission"},
+				{"OBJECTS_PER_BUCKET", "num_objects_per_bucket"},
+				{"GIB_PER_BUCKET", "amount_gib_per_bucket"},
+			})
+		},
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "headroom",
+		Short: "Show remaining capacity of each quota",
+		Long:  "Show remaining capacity of each quota.\n\nShows all sites unless --site is specified.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			backend, err := a.Backend()
+			if err != nil {
+				return err
+			}
+			var siteIds []string
+			if cmd.Flags().Changed("site") {
+				siteIds = []string{a.siteId}
+			}
+			headroom, err := objectstorage.Headroom(cmd.Context(), backend, siteIds...)
+			if err != nil {
+				return err
+			}
+			type row struct {
+				SiteID string `json:"site_id"`
+				objectstorage.QuotaHeadroom
+			}
+			rows := []row{}
+			for _, site := range headroom {
+				for _, quota := range site.Quotas {
+					rows = append(rows, row{SiteID: site.SiteID, QuotaHeadroom: quota})
+				}
+			}
+			return a.print(cmd, rows, []column{
+				{"SITE", "site_id"},
+				{"QUOTA", "dimension"},
+				{"LIMIT", "limit"},
+				{"USED", "used"},
+				{"REMAINING", "remaining"},
+				{"PERMISSION", "permission_id"},
+			})
+		},
+	})
+	return cmd
+}
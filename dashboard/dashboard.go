@@ -0,0 +1,351 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package dashboard サイト、バケットとその使用量を表示するターミナルのダッシュボード
+//
+// 画面の状態はModelが持ち、キー入力をHandleKeyで、APIからの再取得をRefreshで反映してViewで描画する。
+// 端末を用いずにModelを直接操作できるため、フェイクのBackendを用いてテストできる
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+)
+
+// DefaultInterval 再取得の間隔のデフォルト値
+const DefaultInterval = 30 * time.Second
+
+// Options ダッシュボードのオプション
+type Options struct {
+	// SiteID 最初に表示するサイトのID。空の場合はサイトの一覧から表示する
+	SiteID string
+	// Interval 再取得の間隔。0以下の場合はDefaultInterval
+	Interval time.Duration
+	// Now 現在時刻を返す関数。nilの場合はtime.Now
+	Now func() time.Time
+}
+
+// Screen 表示中の画面
+type Screen int
+
+const (
+	// ScreenSites サイトの一覧
+	ScreenSites Screen = iota
+	// ScreenBuckets サイトのバケットの一覧
+	ScreenBuckets
+	// ScreenBucket バケットの詳細
+	ScreenBucket
+)
+
+// Site サイトの一覧の行
+type Site struct {
+	Site   v2.ModelCluster
+	Status *v2.StatusData
+	// Err ステータスの取得に失敗した場合のエラー
+	Err error
+}
+
+// confirmation 確認ダイアログ。承認された場合にactionを実行し、その結果のメッセージを表示する
+type confirmation struct {
+	prompt string
+	action func(ctx context.Context) (string, error)
+}
+
+// Model ダッシュボードの状態
+type Model struct {
+	backend objectstorage.Backend
+	opts    Options
+
+	screen Screen
+	cursor map[Screen]int
+
+	sites       []Site
+	siteId      string
+	buckets     []objectstorage.BucketDetail
+	bucket      string
+	permissions []v2.PermissionsDataItem
+
+	confirm   *confirmation
+	message   string
+	err       error
+	updatedAt time.Time
+}
+
+// New Modelを生成する。データの取得はRefreshで行う
+func New(backend objectstorage.Backend, opts Options) *Model {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	m := &Model{
+		backend: backend,
+		opts:    opts,
+		cursor:  map[Screen]int{},
+	}
+	if opts.SiteID != "" {
+		m.screen = ScreenBuckets
+		m.siteId = opts.SiteID
+	}
+	return m
+}
+
+// Screen 表示中の画面を返す
+func (m *Model) Screen() Screen {
+	return m.screen
+}
+
+// Err 直前のRefreshまたは操作で発生したエラーを返す
+func (m *Model) Err() error {
+	return m.err
+}
+
+// Refresh 表示中の画面のデータをAPIから再取得する
+//
+// 失敗した場合もエラーを画面に表示するため、呼び出し元はエラーを無視して描画を続けてよい
+func (m *Model) Refresh(ctx context.Context) error {
+	var err error
+	switch m.screen {
+	case ScreenSites:
+		err = m.refreshSites(ctx)
+	case ScreenBuckets:
+		err = m.refreshBuckets(ctx)
+	case ScreenBucket:
+		err = m.refreshBuckets(ctx)
+		if err == nil {
+			err = m.refreshPermissions(ctx)
+		}
+	}
+	m.err = err
+	m.updatedAt = m.opts.Now()
+	m.clampCursor()
+	return err
+}
+
+func (m *Model) refreshSites(ctx context.Context) error {
+	api, err := m.backend.Sites("")
+	if err != nil {
+		return err
+	}
+	list, err := api.List(ctx)
+	if err != nil {
+		return err
+	}
+	sites := make([]Site, 0, len(list))
+	for _, site := range list {
+		row := Site{Site: site}
+		status, err := m.backend.SiteStatus(site.ID.Value)
+		if err == nil {
+			row.Status, err = status.Read(ctx)
+		}
+		row.Err = err
+		sites = append(sites, row)
+	}
+	m.sites = sites
+	return nil
+}
+
+func (m *Model) refreshBuckets(ctx context.Context) error {
+	buckets, err := objectstorage.ListDetailed(ctx, m.backend, &objectstorage.ListDetailedOptions{
+		SiteIDs: []string{m.siteId},
+	})
+	if err != nil {
+		return err
+	}
+	m.buckets = buckets
+	return nil
+}
+
+// refreshPermissions 表示中のバケットを参照するパーミッションを取得する
+func (m *Model) refreshPermissions(ctx context.Context) error {
+	api, err := m.backend.Permissions(m.siteId)
+	if err != nil {
+		return err
+	}
+	list, err := api.List(ctx)
+	if err != nil {
+		return err
+	}
+	var permissions []v2.PermissionsDataItem
+	for _, permission := range list {
+		for _, control := range permission.BucketControls {
+			if string(control.BucketName.Value) == m.bucket {
+				permissions = append(permissions, permission)
+				break
+			}
+		}
+	}
+	m.permissions = permissions
+	return nil
+}
+
+// HandleKey キー入力を反映する。終了する場合はtrueを返す
+func (m *Model) HandleKey(ctx context.Context, key Key) bool {
+	if m.confirm != nil {
+		m.handleConfirm(ctx, key)
+		return false
+	}
+
+	switch key {
+	case KeyQuit:
+		return true
+	case KeyUp:
+		m.moveCursor(-1)
+	case KeyDown:
+		m.moveCursor(1)
+	case KeyRefresh:
+		m.message = ""
+		m.Refresh(ctx) //nolint:errcheck
+	case KeyEnter:
+		m.enter(ctx)
+	case KeyBack:
+		m.back(ctx)
+	case KeyCreateKey:
+		m.confirmCreateKey()
+	case KeyDisableReplication:
+		m.confirmDisableReplication()
+	}
+	return false
+}
+
+func (m *Model) handleConfirm(ctx context.Context, key Key) {
+	switch key {
+	case KeyYes:
+		confirm := m.confirm
+		m.confirm = nil
+		message, err := confirm.action(ctx)
+		m.message = message
+		// 操作の結果を反映するため再取得する。操作のエラーを優先して表示する
+		if refreshErr := m.Refresh(ctx); err == nil {
+			err = refreshErr
+		}
+		m.err = err
+	case KeyNo, KeyBack, KeyQuit:
+		m.confirm = nil
+		m.message = "cancelled"
+	}
+}
+
+func (m *Model) enter(ctx context.Context) {
+	switch m.screen {
+	case ScreenSites:
+		if len(m.sites) == 0 {
+			return
+		}
+		m.siteId = m.sites[m.cursor[ScreenSites]].Site.ID.Value
+		m.screen = ScreenBuckets
+		m.cursor[ScreenBuckets] = 0
+	case ScreenBuckets:
+		if len(m.buckets) == 0 {
+			return
+		}
+		m.bucket = string(m.buckets[m.cursor[ScreenBuckets]].Bucket.Name)
+		m.screen = ScreenBucket
+		m.cursor[ScreenBucket] = 0
+	default:
+		return
+	}
+	m.message = ""
+	m.Refresh(ctx) //nolint:errcheck
+}
+
+func (m *Model) back(ctx context.Context) {
+	switch m.screen {
+	case ScreenBuckets:
+		m.screen = ScreenSites
+	case ScreenBucket:
+		m.screen = ScreenBuckets
+	default:
+		return
+	}
+	m.message = ""
+	m.Refresh(ctx) //nolint:errcheck
+}
+
+func (m *Model) confirmCreateKey() {
+	if m.screen != ScreenBucket || len(m.permissions) == 0 {
+		return
+	}
+	permission := m.permissions[m.cursor[ScreenBucket]]
+	id := strconv.FormatInt(int64(permission.ID.Value), 10)
+	siteId := m.siteId
+	m.confirm = &confirmation{
+		prompt: fmt.Sprintf("Create an access key for permission %s (%s)?", permission.DisplayName.Value, id),
+		action: func(ctx context.Context) (string, error) {
+			api, err := m.backend.Permissions(siteId)
+			if err != nil {
+				return "", err
+			}
+			key, err := api.CreateAccessKey(ctx, id)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("created access key %s with secret %s (the secret is shown only once)", key.ID.Value, key.Secret.Value), nil
+		},
+	}
+}
+
+func (m *Model) confirmDisableReplication() {
+	detail := m.bucketDetail()
+	if m.screen != ScreenBucket || detail == nil || detail.Replication == nil {
+		return
+	}
+	siteId, bucket := m.siteId, m.bucket
+	m.confirm = &confirmation{
+		prompt: fmt.Sprintf("Disable replication of bucket %s to %s?", bucket, replicationDest(detail.Replication)),
+		action: func(ctx context.Context) (string, error) {
+			api, err := m.backend.BucketExtra(siteId, bucket)
+			if err != nil {
+				return "", err
+			}
+			if err := api.DisableReplication(ctx); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("disabled replication of bucket %s", bucket), nil
+		},
+	}
+}
+
+// bucketDetail 表示中のバケットの詳細を返す。一覧に存在しない場合はnil
+func (m *Model) bucketDetail() *objectstorage.BucketDetail {
+	for i := range m.buckets {
+		if string(m.buckets[i].Bucket.Name) == m.bucket {
+			return &m.buckets[i]
+		}
+	}
+	return nil
+}
+
+func (m *Model) rows() int {
+	switch m.screen {
+	case ScreenSites:
+		return len(m.sites)
+	case ScreenBuckets:
+		return len(m.buckets)
+	default:
+		return len(m.permissions)
+	}
+}
+
+func (m *Model) moveCursor(delta int) {
+	m.cursor[m.screen] += delta
+	m.clampCursor()
+}
+
+func (m *Model) clampCursor() {
+	rows := m.rows()
+	cursor := m.cursor[m.screen]
+	if cursor >= rows {
+		cursor = rows - 1
+	}
+	if cursor < 0 {
+		cursor = 0
+	}
+	m.cursor[m.screen] = cursor
+}
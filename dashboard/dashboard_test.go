@@ -0,0 +1,169 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package dashboard_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/sacloud/object-storage-api-go/dashboard"
+	"github.com/sacloud/object-storage-api-go/objectstoragetest"
+	"github.com/stretchr/testify/require"
+)
+
+// setup bucket1(isk01)からbucket2(tky01)へのレプリケーションと、bucket1を参照するパーミッションを持つフェイクを返す
+func setup(t *testing.T) *objectstoragetest.Fake {
+	t.Helper()
+	ctx := context.Background()
+	fake := objectstoragetest.NewFake()
+	for siteId, name := range map[string]string{"isk01": "bucket1", "tky01": "bucket2"} {
+		api, err := fake.Buckets(siteId)
+		require.NoError(t, err)
+		_, err = api.Create(ctx, &objectstorage.BucketCreateParams{Bucket: name, SiteId: siteId})
+		require.NoError(t, err)
+	}
+	extra, err := fake.BucketExtra("isk01", "bucket1")
+	require.NoError(t, err)
+	_, err = extra.EnableReplication(ctx, "bucket2")
+	require.NoError(t, err)
+
+	permissions, err := fake.Permissions("isk01")
+	require.NoError(t, err)
+	_, err = permissions.Create(ctx, "perm1", v2.BucketControls{{
+		BucketName: v2.NewOptBucketName("bucket1"),
+		CanRead:    v2.NewOptCanRead(true),
+		CanWrite:   v2.NewOptCanWrite(false),
+	}})
+	require.NoError(t, err)
+	fake.ResetCalls()
+	return fake
+}
+
+func TestModel_Navigate(t *testing.T) {
+	ctx := context.Background()
+	m := dashboard.New(setup(t), dashboard.Options{})
+	require.NoError(t, m.Refresh(ctx))
+	require.Equal(t, dashboard.ScreenSites, m.Screen())
+	view := m.View()
+	require.Contains(t, view, "isk01")
+	require.Contains(t, view, "tky01")
+
+	m.HandleKey(ctx, dashboard.KeyEnter)
+	require.Equal(t, dashboard.ScreenBuckets, m.Screen())
+	require.NoError(t, m.Err())
+	view = m.View()
+	require.Contains(t, view, "sites > isk01")
+	require.Contains(t, view, "bucket1")
+	require.Contains(t, view, "bucket2@tky01")
+	require.NotContains(t, view, "bucket2 ")
+
+	m.HandleKey(ctx, dashboard.KeyEnter)
+	require.Equal(t, dashboard.ScreenBucket, m.Screen())
+	view = m.View()
+	require.Contains(t, view, "sites > isk01 > bucket1")
+	require.Contains(t, view, "Replication  bucket2@tky01 (created)")
+	require.Contains(t, view, "perm1")
+
+	m.HandleKey(ctx, dashboard.KeyBack)
+	m.HandleKey(ctx, dashboard.KeyBack)
+	require.Equal(t, dashboard.ScreenSites, m.Screen())
+	m.HandleKey(ctx, dashboard.KeyDown)
+	m.HandleKey(ctx, dashboard.KeyEnter)
+	require.Contains(t, m.View(), "sites > tky01")
+
+	require.True(t, m.HandleKey(ctx, dashboard.KeyQuit))
+}
+
+func TestModel_Confirm(t *testing.T) {
+	ctx := context.Background()
+	fake := setup(t)
+	m := dashboard.New(fake, dashboard.Options{SiteID: "isk01"})
+	require.NoError(t, m.Refresh(ctx))
+	m.HandleKey(ctx, dashboard.KeyEnter)
+
+	// キャンセルした場合は実行しない
+	m.HandleKey(ctx, dashboard.KeyDisableReplication)
+	require.Contains(t, m.View(), "Disable replication of bucket bucket1 to bucket2@tky01? [y/n]")
+	require.False(t, m.HandleKey(ctx, dashboard.KeyQuit))
+	require.Contains(t, m.View(), "cancelled")
+	require.Empty(t, fake.CallsTo("BucketExtra.DisableReplication"))
+
+	m.HandleKey(ctx, dashboard.KeyDisableReplication)
+	m.HandleKey(ctx, dashboard.KeyYes)
+	require.NoError(t, m.Err())
+	require.Len(t, fake.CallsTo("BucketExtra.DisableReplication"), 1)
+	view := m.View()
+	require.Contains(t, view, "disabled replication of bucket bucket1")
+	require.Contains(t, view, "Replication  disabled")
+
+	// レプリケーションが無い場合は確認ダイアログを表示しない
+	m.HandleKey(ctx, dashboard.KeyDisableReplication)
+	require.NotContains(t, m.View(), "[y/n]")
+
+	m.HandleKey(ctx, dashboard.KeyCreateKey)
+	require.Contains(t, m.View(), "Create an access key for permission perm1")
+	m.HandleKey(ctx, dashboard.KeyYes)
+	require.NoError(t, m.Err())
+	require.Len(t, fake.CallsTo("Permissions.CreateAccessKey"), 1)
+	require.Contains(t, m.View(), "with secret ")
+}
+
+func TestModel_Error(t *testing.T) {
+	ctx := context.Background()
+	fake := setup(t)
+	fake.FailOn("SiteStatus.Read", objectstoragetest.APIError("SiteStatus.Read", 503))
+	m := dashboard.New(fake, dashboard.Options{})
+	require.NoError(t, m.Refresh(ctx))
+	require.Contains(t, m.View(), "error: ")
+
+	fake.ClearHooks()
+	fake.FailOn("Permissions.CreateAccessKey", objectstoragetest.APIError("Permissions.CreateAccessKey", 409))
+	m = dashboard.New(fake, dashboard.Options{SiteID: "isk01"})
+	require.NoError(t, m.Refresh(ctx))
+	m.HandleKey(ctx, dashboard.KeyEnter)
+	m.HandleKey(ctx, dashboard.KeyCreateKey)
+	m.HandleKey(ctx, dashboard.KeyYes)
+	require.Equal(t, 409, objectstorage.StatusCode(m.Err()))
+}
+
+func TestParseKeys(t *testing.T) {
+	require.Equal(t,
+		[]dashboard.Key{dashboard.KeyUp, dashboard.KeyDown, dashboard.KeyEnter, dashboard.KeyBack, dashboard.KeyQuit},
+		dashboard.ParseKeys([]byte("\x1b[A\x1b[Bx\r\x1bq")),
+	)
+	// 対応しないCSIシーケンスはEscとして扱わずに全体を無視する
+	require.Equal(t,
+		[]dashboard.Key{dashboard.KeyBack, dashboard.KeyQuit},
+		dashboard.ParseKeys([]byte("\x1b[C\x1b[1;5C\x1b[D\x1b[15~q\x1b[")),
+	)
+}
+
+func TestRun(t *testing.T) {
+	fake := setup(t)
+	var out bytes.Buffer
+	err := dashboard.Run(context.Background(), fake, strings.NewReader("\r\r"), &out, dashboard.Options{})
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "sites > isk01 > bucket1")
+	require.Contains(t, out.String(), "\r\n")
+}
+
+func TestRun_Interval(t *testing.T) {
+	fake := setup(t)
+	in, w := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- dashboard.Run(context.Background(), fake, in, io.Discard, dashboard.Options{Interval: 10 * time.Millisecond})
+	}()
+	require.Eventually(t, func() bool {
+		return len(fake.CallsTo("Site.List")) >= 3
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, w.Close())
+	require.NoError(t, <-done)
+}
@@ -0,0 +1,182 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+)
+
+// Key ダッシュボードの操作
+type Key string
+
+const (
+	KeyUp                 Key = "up"
+	KeyDown               Key = "down"
+	KeyEnter              Key = "enter"
+	KeyBack               Key = "back"
+	KeyRefresh            Key = "refresh"
+	KeyQuit               Key = "quit"
+	KeyCreateKey          Key = "create-key"
+	KeyDisableReplication Key = "disable-replication"
+	KeyYes                Key = "yes"
+	KeyNo                 Key = "no"
+)
+
+// keySequences 端末からの入力とKeyの対応。CSIシーケンスは全体が一致するものを用いる。
+// それ以外は前方一致で照合するため長いものから並べる
+var keySequences = []struct {
+	seq string
+	key Key
+}{
+	{"\x1b[A", KeyUp},
+	{"\x1b[B", KeyDown},
+	{"\x1b[D", KeyBack},
+	{"\x1b", KeyBack},
+	{"\x7f", KeyBack},
+	{"\r", KeyEnter},
+	{"\n", KeyEnter},
+	{"\x03", KeyQuit},
+	{"k", KeyUp},
+	{"j", KeyDown},
+	{"h", KeyBack},
+	{"l", KeyEnter},
+	{"r", KeyRefresh},
+	{"q", KeyQuit},
+	{"c", KeyCreateKey},
+	{"d", KeyDisableReplication},
+	{"y", KeyYes},
+	{"n", KeyNo},
+}
+
+// ParseKeys 端末からの入力をKeyの列に変換する。対応しない入力は無視する
+func ParseKeys(input []byte) []Key {
+	var keys []Key
+	s := string(input)
+	for s != "" {
+		// CSIシーケンスは全体を読み取り、対応しないもの(→など)が単独のEscとして扱われないようにする
+		if n := csiLen(s); n > 0 {
+			for _, ks := range keySequences {
+				if ks.seq == s[:n] {
+					keys = append(keys, ks.key)
+					break
+				}
+			}
+			s = s[n:]
+			continue
+		}
+		matched := false
+		for _, ks := range keySequences {
+			if strings.HasPrefix(s, ks.seq) {
+				keys = append(keys, ks.key)
+				s = s[len(ks.seq):]
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			s = s[1:]
+		}
+	}
+	return keys
+}
+
+// csiLen sの先頭のCSIシーケンス(ESC [ パラメータ 中間バイト 終端バイト)の長さを返す。CSIシーケンスでない場合は0
+//
+// 終端バイトまでが含まれない途中で切れたシーケンスは全体をCSIシーケンスとみなす
+func csiLen(s string) int {
+	if !strings.HasPrefix(s, "\x1b[") {
+		return 0
+	}
+	for i := 2; i < len(s); i++ {
+		switch c := s[i]; {
+		case c >= 0x40 && c <= 0x7e:
+			return i + 1
+		case c < 0x20 || c > 0x3f:
+			// パラメータでも中間バイトでもない場合は不正なシーケンスとしてここまでを読み捨てる
+			return i
+		}
+	}
+	return len(s)
+}
+
+// Run ダッシュボードを実行する
+//
+// inから読み込んだキー入力とOptions.Intervalごとの再取得を反映し、その都度outに画面全体を描画する。
+// 終了のキーが入力されるか、inが終端に達するか、ctxがキャンセルされると終了する。
+// 端末をrawモードにするのは呼び出し元の責務
+func Run(ctx context.Context, backend objectstorage.Backend, in io.Reader, out io.Writer, opts Options) error {
+	m := New(backend, opts)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	keys := make(chan []Key)
+	readErr := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 256)
+		for {
+			n, err := in.Read(buf)
+			if n > 0 {
+				select {
+				case keys <- ParseKeys(buf[:n]):
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	// 代替画面に切り替えてカーソルを隠す
+	if _, err := io.WriteString(out, "\x1b[?1049h\x1b[?25l"); err != nil {
+		return err
+	}
+	defer io.WriteString(out, "\x1b[?25h\x1b[?1049l") //nolint:errcheck
+
+	m.Refresh(ctx) //nolint:errcheck
+	if err := render(out, m); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(m.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-readErr:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		case <-ticker.C:
+			if m.confirm == nil {
+				m.Refresh(ctx) //nolint:errcheck
+			}
+		case ks := <-keys:
+			for _, key := range ks {
+				if m.HandleKey(ctx, key) {
+					return nil
+				}
+			}
+		}
+		if err := render(out, m); err != nil {
+			return err
+		}
+	}
+}
+
+// render 画面を消去して描画する。rawモードの端末のため改行は"\r\n"とする
+func render(out io.Writer, m *Model) error {
+	_, err := io.WriteString(out, "\x1b[H\x1b[2J"+strings.ReplaceAll(m.View(), "\n", "\r\n"))
+	return err
+}
@@ -0,0 +1,244 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package dashboard
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+)
+
+// barWidth 使用量のバーの幅
+const barWidth = 20
+
+// View 現在の状態を描画した文字列を返す。改行は"\n"
+func (m *Model) View() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Object Storage Dashboard  %s", m.breadcrumb())
+	if !m.updatedAt.IsZero() {
+		fmt.Fprintf(&b, "  (updated %s)", m.updatedAt.Format("15:04:05"))
+	}
+	b.WriteString("\n\n")
+
+	switch m.screen {
+	case ScreenSites:
+		m.viewSites(&b)
+	case ScreenBuckets:
+		m.viewBuckets(&b)
+	case ScreenBucket:
+		m.viewBucket(&b)
+	}
+
+	b.WriteString("\n")
+	if m.err != nil {
+		fmt.Fprintf(&b, "error: %v\n", m.err)
+	}
+	if m.message != "" {
+		fmt.Fprintf(&b, "%s\n", m.message)
+	}
+	if m.confirm != nil {
+		fmt.Fprintf(&b, "%s [y/n]\n", m.confirm.prompt)
+	} else {
+		b.WriteString(m.help() + "\n")
+	}
+	return b.String()
+}
+
+func (m *Model) breadcrumb() string {
+	crumbs := []string{"sites"}
+	if m.screen >= ScreenBuckets {
+		crumbs = append(crumbs, m.siteId)
+	}
+	if m.screen >= ScreenBucket {
+		crumbs = append(crumbs, m.bucket)
+	}
+	return strings.Join(crumbs, " > ")
+}
+
+func (m *Model) help() string {
+	keys := []string{"↑/↓ move"}
+	switch m.screen {
+	case ScreenSites:
+		keys = append(keys, "enter buckets")
+	case ScreenBuckets:
+		keys = append(keys, "enter details", "esc sites")
+	case ScreenBucket:
+		keys = append(keys, "c create key", "d disable replication", "esc buckets")
+	}
+	return strings.Join(append(keys, "r refresh", "q quit"), "  ")
+}
+
+func (m *Model) marker(i int) string {
+	if m.cursor[m.screen] == i {
+		return ">"
+	}
+	return " "
+}
+
+func (m *Model) viewSites(b *strings.Builder) {
+	w := tabwriter.NewWriter(b, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, " \tID\tNAME\tREGION\tSTATUS\tACCEPT_NEW")
+	for i, site := range m.sites {
+		status, acceptNew := "-", "-"
+		switch {
+		case site.Err != nil:
+			status = "error: " + site.Err.Error()
+		case site.Status != nil:
+			status = site.Status.StatusCode.Value.Status.Value
+			acceptNew = strconv.FormatBool(site.Status.AcceptNew.Value)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", m.marker(i), site.Site.ID.Value, site.Site.DisplayName.Value, site.Site.Region.Value, status, acceptNew)
+	}
+	w.Flush() //nolint:errcheck,gosec
+}
+
+func (m *Model) viewBuckets(b *strings.Builder) {
+	w := tabwriter.NewWriter(b, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, " \tNAME\tSTORAGE\tOBJECTS\tPENALTY\tENCRYPTION\tREPLICATION")
+	for i, detail := range m.buckets {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", m.marker(i), detail.Bucket.Name,
+			storageBar(&detail), objectsBar(&detail), penalty(&detail), encryption(&detail), replication(&detail))
+	}
+	w.Flush() //nolint:errcheck,gosec
+}
+
+func (m *Model) viewBucket(b *strings.Builder) {
+	detail := m.bucketDetail()
+	if detail == nil {
+		fmt.Fprintf(b, "bucket %s not found\n", m.bucket)
+		return
+	}
+	w := tabwriter.NewWriter(b, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Storage\t%s\n", storageBar(detail))
+	fmt.Fprintf(w, "Objects\t%s\n", objectsBar(detail))
+	fmt.Fprintf(w, "Penalty\t%s\n", penalty(detail))
+	fmt.Fprintf(w, "Encryption\t%s\n", encryption(detail))
+	fmt.Fprintf(w, "Replication\t%s\n", replication(detail))
+	w.Flush() //nolint:errcheck,gosec
+
+	b.WriteString("\nPermissions\n")
+	w = tabwriter.NewWriter(b, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, " \tID\tNAME\tACCESS")
+	for i, permission := range m.permissions {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", m.marker(i), permission.ID.Value, permission.DisplayName.Value, m.access(permission.BucketControls))
+	}
+	w.Flush() //nolint:errcheck,gosec
+}
+
+// access パーミッションが表示中のバケットに持つ権限を返す
+func (m *Model) access(controls v2.BucketControls) string {
+	for _, control := range controls {
+		if string(control.BucketName.Value) != m.bucket {
+			continue
+		}
+		var access string
+		if control.CanRead.Value {
+			access += "r"
+		}
+		if control.CanWrite.Value {
+			access += "w"
+		}
+		if access == "" {
+			access = "-"
+		}
+		return access
+	}
+	return "-"
+}
+
+// fieldError 項目の取得に失敗した場合の表示を返す
+func fieldError(detail *objectstorage.BucketDetail, field objectstorage.BucketDetailField) (string, bool) {
+	if err, ok := detail.Errors[field]; ok {
+		return "error: " + err.Error(), true
+	}
+	return "", false
+}
+
+func storageBar(detail *objectstorage.BucketDetail) string {
+	if s, ok := fieldError(detail, objectstorage.BucketDetailUsage); ok {
+		return s
+	}
+	if detail.Usage == nil {
+		return "-"
+	}
+	used := float64(detail.Usage.AmountGibPerBucket.Value)
+	if detail.Quota == nil || !detail.Quota.AmountGibPerBucket.Set {
+		return fmt.Sprintf("%.2f GiB", used)
+	}
+	limit := float64(detail.Quota.AmountGibPerBucket.Value)
+	return fmt.Sprintf("%s %.2f/%.2f GiB", bar(used, limit), used, limit)
+}
+
+func objectsBar(detail *objectstorage.BucketDetail) string {
+	if s, ok := fieldError(detail, objectstorage.BucketDetailUsage); ok {
+		return s
+	}
+	if detail.Usage == nil {
+		return "-"
+	}
+	used := detail.Usage.NumObjectsPerBucket.Value
+	if detail.Quota == nil || !detail.Quota.NumObjectsPerBucket.Set {
+		return strconv.Itoa(used)
+	}
+	limit := detail.Quota.NumObjectsPerBucket.Value
+	return fmt.Sprintf("%s %d/%d", bar(float64(used), float64(limit)), used, limit)
+}
+
+func penalty(detail *objectstorage.BucketDetail) string {
+	if s, ok := fieldError(detail, objectstorage.BucketDetailPenalty); ok {
+		return s
+	}
+	if detail.Penalty == nil {
+		return "-"
+	}
+	var applied []string
+	if detail.Penalty.AmountGibPerBucket.Value.IsApplied.Value {
+		applied = append(applied, "storage")
+	}
+	if detail.Penalty.NumObjectsPerBucket.Value.IsApplied.Value {
+		applied = append(applied, "objects")
+	}
+	if len(applied) == 0 {
+		return "none"
+	}
+	return "APPLIED (" + strings.Join(applied, ", ") + ")"
+}
+
+func encryption(detail *objectstorage.BucketDetail) string {
+	if s, ok := fieldError(detail, objectstorage.BucketDetailEncryption); ok {
+		return s
+	}
+	if detail.Encryption == nil || !detail.Encryption.KmsKeyID.Set {
+		return "disabled"
+	}
+	return "kms:" + string(detail.Encryption.KmsKeyID.Value)
+}
+
+func replication(detail *objectstorage.BucketDetail) string {
+	if s, ok := fieldError(detail, objectstorage.BucketDetailReplication); ok {
+		return s
+	}
+	if detail.Replication == nil {
+		return "disabled"
+	}
+	return fmt.Sprintf("%s (%s)", replicationDest(detail.Replication), detail.Replication.ConfigStatus)
+}
+
+func replicationDest(replication *v2.ModelReplication) string {
+	return replication.DestBucket.Name.Value + "@" + replication.DestBucket.ClusterID.Value
+}
+
+// bar 上限に対する使用量の割合をバーで表す。上限を超えた場合は全て埋める
+func bar(used, limit float64) string {
+	ratio := 0.0
+	if limit > 0 {
+		ratio = min(max(used/limit, 0), 1)
+	}
+	filled := int(ratio*barWidth + 0.5)
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(".", barWidth-filled) + "]"
+}
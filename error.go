@@ -4,14 +4,17 @@
 package objectstorage
 
 import (
+	"errors"
 	"strings"
 
+	"github.com/ogen-go/ogen/validate"
 	"github.com/sacloud/saclient-go"
 )
 
 type Error struct {
-	msg string
-	err error
+	msg  string
+	err  error
+	code int
 }
 
 func (e *Error) Unwrap() error { return e.err }
@@ -35,5 +38,25 @@ func (e *Error) Error() string {
 
 func NewError(msg string, err error) *Error { return &Error{msg: msg, err: err} }
 func NewAPIError(method string, code int, err error) *Error {
-	return NewError(method, saclient.NewError(code, "", err))
+	e := NewError(method, saclient.NewError(code, "", err))
+	e.code = code
+	return e
+}
+
+// StatusCode errに含まれるAPIのレスポンスのステータスコードを返す。含まれない場合は0
+//
+// APIの定義にないステータスコードのレスポンスの場合も、そのステータスコードを返す
+func StatusCode(err error) int {
+	for err != nil {
+		switch e := err.(type) {
+		case *Error:
+			if e.code != 0 {
+				return e.code
+			}
+		case *validate.UnexpectedStatusCodeError:
+			return e.StatusCode
+		}
+		err = errors.Unwrap(err)
+	}
+	return 0
 }
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/ogen-go/ogen/validate"
 	"github.com/sacloud/saclient-go"
 	"github.com/stretchr/testify/require"
 )
@@ -68,3 +69,13 @@ func TestNewAPIError(t *testing.T) {
 	assert.Equal("msg", err2.msg)
 	assert.False(saclient.IsNotFoundError(err2))
 }
+
+func TestStatusCode(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Equal(409, StatusCode(NewAPIError("Buckets.Create", 409, errors.New("conflict"))))
+	assert.Equal(409, StatusCode(NewError("wrapped", NewAPIError("Buckets.Create", 409, nil))))
+	assert.Equal(503, StatusCode(NewAPIError("Site.List", 0, &validate.UnexpectedStatusCodeError{StatusCode: 503})))
+	assert.Equal(0, StatusCode(NewAPIError("Site.List", 0, errors.New("unknown error"))))
+	assert.Equal(0, StatusCode(nil))
+}
@@ -0,0 +1,306 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package exporter バケットの使用量やサイトの状態をPrometheusのメトリクスとして公開する
+package exporter
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+)
+
+const (
+	// DefaultNamespace メトリクス名のプレフィックスのデフォルト値
+	DefaultNamespace = "sakura_object_storage"
+	// DefaultConcurrency 同時に実行するAPIリクエスト数のデフォルト値
+	DefaultConcurrency = 4
+)
+
+// Options NewCollectorのオプション
+type Options struct {
+	// Sites 対象とするサイトID。空の場合は全てのサイト。重複は取り除く
+	Sites []string
+	// Concurrency 同時に実行するAPIリクエスト数。0以下の場合はDefaultConcurrency
+	Concurrency int
+	// Namespace メトリクス名のプレフィックス。空の場合はDefaultNamespace
+	Namespace string
+}
+
+// Collector サイトとバケットの状態を定期的に取得し、最後に取得した値をメトリクスとして返すprometheus.Collector
+//
+// Collectは取得済みの値を返すのみでAPIの呼び出しは行わない。値の取得はRefreshもしくはRunで行う
+type Collector struct {
+	backend objectstorage.Backend
+	opts    Options
+
+	siteAcceptNew      *prometheus.Desc
+	siteStatusCode     *prometheus.Desc
+	siteQuota          *prometheus.Desc
+	bucketObjects      *prometheus.Desc
+	bucketGiB          *prometheus.Desc
+	bucketQuotaObjects *prometheus.Desc
+	bucketQuotaGiB     *prometheus.Desc
+	bucketPenalty      *prometheus.Desc
+	scrapeSuccess      *prometheus.Desc
+	scrapeDuration     *prometheus.Desc
+	scrapeTimestamp    *prometheus.Desc
+
+	mu      sync.RWMutex
+	metrics []prometheus.Metric
+}
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+// NewCollector Collectorを作成する
+func NewCollector(backend objectstorage.Backend, opts *Options) *Collector {
+	c := &Collector{backend: backend}
+	if opts != nil {
+		c.opts = *opts
+	}
+	// 同じサイトのメトリクスを重複して返さないよう、重複を取り除く
+	c.opts.Sites = slices.Compact(slices.Sorted(slices.Values(c.opts.Sites)))
+	if c.opts.Concurrency <= 0 {
+		c.opts.Concurrency = DefaultConcurrency
+	}
+	if c.opts.Namespace == "" {
+		c.opts.Namespace = DefaultNamespace
+	}
+
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(c.opts.Namespace, "", name), help, labels, nil)
+	}
+	c.siteAcceptNew = desc("site_accept_new", "Whether the site accepts new buckets (1) or not (0).", "site")
+	c.siteStatusCode = desc("site_status_code", "Status code of the site.", "site", "status")
+	c.siteQuota = desc("site_quota", "Quota of the site per resource.", "site", "resource")
+	c.bucketObjects = desc("bucket_objects", "Number of objects in the bucket.", "site", "bucket")
+	c.bucketGiB = desc("bucket_gib", "Amount of data stored in the bucket in GiB.", "site", "bucket")
+	c.bucketQuotaObjects = desc("bucket_quota_objects", "Maximum number of objects in the bucket.", "site", "bucket")
+	c.bucketQuotaGiB = desc("bucket_quota_gib", "Maximum amount of data in the bucket in GiB.", "site", "bucket")
+	c.bucketPenalty = desc("bucket_penalty_applied", "Whether a penalty is applied to the bucket (1) or not (0).", "site", "bucket", "resource")
+	c.scrapeSuccess = desc("scrape_success", "Whether the last scrape of the site succeeded (1) or not (0).", "site")
+	c.scrapeDuration = desc("scrape_duration_seconds", "Duration of the last scrape.")
+	c.scrapeTimestamp = desc("scrape_timestamp_seconds", "Unix time of the last scrape.")
+	return c
+}
+
+// Describe prometheus.Collectorの実装
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		c.siteAcceptNew, c.siteStatusCode, c.siteQuota,
+		c.bucketObjects, c.bucketGiB, c.bucketQuotaObjects, c.bucketQuotaGiB, c.bucketPenalty,
+		c.scrapeSuccess, c.scrapeDuration, c.scrapeTimestamp,
+	} {
+		ch <- d
+	}
+}
+
+// Collect prometheus.Collectorの実装。最後にRefreshした時点の値を返す
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, m := range c.metrics {
+		ch <- m
+	}
+}
+
+// Run intervalごとにRefreshを行う。ctxがキャンセルされるまで戻らない
+//
+// RefreshのエラーはonErrorに渡される。onErrorがnilの場合は無視する
+func (c *Collector) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := c.Refresh(ctx); err != nil && onError != nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh 全ての対象サイト/バケットの値を取得する
+//
+// 一部の取得に失敗した場合も取得できた値は反映され、失敗したサイトのscrape_successは0となる
+func (c *Collector) Refresh(ctx context.Context) error {
+	start := time.Now()
+	sites := c.opts.Sites
+	if len(sites) == 0 {
+		var err error
+		if sites, err = objectstorage.ListSiteIDs(ctx, c.backend); err != nil {
+			return err
+		}
+	}
+
+	s := &scrape{c: c, sem: make(chan struct{}, c.opts.Concurrency), failed: map[string]bool{}}
+	var wg sync.WaitGroup
+	for _, site := range sites {
+		wg.Go(func() { s.site(ctx, &wg, site) })
+	}
+	wg.Wait()
+
+	for _, site := range sites {
+		success := 1.0
+		if s.failed[site] {
+			success = 0
+		}
+		s.add(prometheus.MustNewConstMetric(c.scrapeSuccess, prometheus.GaugeValue, success, site))
+	}
+	s.add(prometheus.MustNewConstMetric(c.scrapeDuration, prometheus.GaugeValue, time.Since(start).Seconds()))
+	s.add(prometheus.MustNewConstMetric(c.scrapeTimestamp, prometheus.GaugeValue, float64(start.Unix())))
+
+	c.mu.Lock()
+	c.metrics = s.metrics
+	c.mu.Unlock()
+	return errors.Join(s.errs...)
+}
+
+// scrape 1回分のRefreshの状態
+type scrape struct {
+	c   *Collector
+	sem chan struct{}
+
+	mu      sync.Mutex
+	metrics []prometheus.Metric
+	errs    []error
+	failed  map[string]bool
+}
+
+func (s *scrape) add(m ...prometheus.Metric) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metrics = append(s.metrics, m...)
+}
+
+func (s *scrape) fail(site string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errs = append(s.errs, err)
+	s.failed[site] = true
+}
+
+// do 同時実行数の制限内でfnを実行する
+func (s *scrape) do(ctx context.Context, site string, fn func() error) {
+	select {
+	case s.sem <- struct{}{}:
+	case <-ctx.Done():
+		s.fail(site, ctx.Err())
+		return
+	}
+	defer func() { <-s.sem }()
+	if err := fn(); err != nil {
+		s.fail(site, err)
+	}
+}
+
+func (s *scrape) site(ctx context.Context, wg *sync.WaitGroup, site string) {
+	c := s.c
+	var buckets []v2.BucketListDataItem
+	s.do(ctx, site, func() error {
+		status, err := c.backend.SiteStatus(site)
+		if err != nil {
+			return err
+		}
+		data, err := status.Read(ctx)
+		if err != nil {
+			return err
+		}
+		s.add(
+			prometheus.MustNewConstMetric(c.siteAcceptNew, prometheus.GaugeValue, boolValue(data.AcceptNew.Value), site),
+			prometheus.MustNewConstMetric(c.siteStatusCode, prometheus.GaugeValue, float64(data.StatusCode.Value.ID.Value), site, data.StatusCode.Value.Status.Value),
+		)
+		return nil
+	})
+	s.do(ctx, site, func() error {
+		status, err := c.backend.SiteStatus(site)
+		if err != nil {
+			return err
+		}
+		quota, err := status.ReadQuota(ctx)
+		if err != nil {
+			return err
+		}
+		for resource, v := range map[string]float64{
+			"root_keys":              float64(quota.NumRootKeys.Value),
+			"buckets":                float64(quota.NumBuckets.Value),
+			"permissions":            float64(quota.NumPermissions.Value),
+			"keys_per_permission":    float64(quota.NumKeysPerPermission.Value),
+			"buckets_per_permission": float64(quota.NumBucketsPerPermission.Value),
+			"objects_per_bucket":     float64(quota.NumObjectsPerBucket.Value),
+			"gib_per_bucket":         float64(quota.AmountGibPerBucket.Value),
+		} {
+			s.add(prometheus.MustNewConstMetric(c.siteQuota, prometheus.GaugeValue, v, site, resource))
+		}
+		return nil
+	})
+	s.do(ctx, site, func() error {
+		api, err := c.backend.Buckets(site)
+		if err != nil {
+			return err
+		}
+		buckets, err = api.List(ctx)
+		return err
+	})
+
+	for _, bucket := range buckets {
+		wg.Go(func() { s.bucket(ctx, site, string(bucket.Name)) })
+	}
+}
+
+func (s *scrape) bucket(ctx context.Context, site, bucket string) {
+	c := s.c
+	api, err := c.backend.BucketExtra(site, bucket)
+	if err != nil {
+		s.fail(site, err)
+		return
+	}
+	s.do(ctx, site, func() error {
+		usage, err := api.ReadUsage(ctx)
+		if err != nil {
+			return err
+		}
+		s.add(
+			prometheus.MustNewConstMetric(c.bucketObjects, prometheus.GaugeValue, float64(usage.NumObjectsPerBucket.Value), site, bucket),
+			prometheus.MustNewConstMetric(c.bucketGiB, prometheus.GaugeValue, float64(usage.AmountGibPerBucket.Value), site, bucket),
+		)
+		return nil
+	})
+	s.do(ctx, site, func() error {
+		quota, err := api.ReadQuota(ctx)
+		if err != nil {
+			return err
+		}
+		s.add(
+			prometheus.MustNewConstMetric(c.bucketQuotaObjects, prometheus.GaugeValue, float64(quota.NumObjectsPerBucket.Value), site, bucket),
+			prometheus.MustNewConstMetric(c.bucketQuotaGiB, prometheus.GaugeValue, float64(quota.AmountGibPerBucket.Value), site, bucket),
+		)
+		return nil
+	})
+	s.do(ctx, site, func() error {
+		penalty, err := api.ReadPenalty(ctx)
+		if err != nil {
+			return err
+		}
+		s.add(
+			prometheus.MustNewConstMetric(c.bucketPenalty, prometheus.GaugeValue, boolValue(penalty.NumObjectsPerBucket.Value.IsApplied.Value), site, bucket, "objects"),
+			prometheus.MustNewConstMetric(c.bucketPenalty, prometheus.GaugeValue, boolValue(penalty.AmountGibPerBucket.Value.IsApplied.Value), site, bucket, "gib"),
+		)
+		return nil
+	})
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,198 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package exporter
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/stretchr/testify/require"
+)
+
+type stubBackend struct {
+	objectstorage.Backend
+	buckets map[string][]string
+	// failBucket このバケットのReadPenaltyはエラーとなる
+	failBucket string
+	calls      atomic.Int32
+}
+
+func (b *stubBackend) Sites(string) (objectstorage.SiteAPI, error) {
+	return &stubSiteAPI{b: b}, nil
+}
+
+func (b *stubBackend) SiteStatus(siteId string) (objectstorage.SiteStatusAPI, error) {
+	return &stubSiteStatusAPI{b: b}, nil
+}
+
+func (b *stubBackend) Buckets(siteId string) (objectstorage.BucketAPI, error) {
+	return &stubBucketAPI{b: b, siteId: siteId}, nil
+}
+
+func (b *stubBackend) BucketExtra(siteId, bucket string) (objectstorage.BucketExtraAPI, error) {
+	return &stubBucketExtraAPI{b: b, bucket: bucket}, nil
+}
+
+type stubSiteAPI struct {
+	objectstorage.SiteAPI
+	b *stubBackend
+}
+
+func (s *stubSiteAPI) List(context.Context) ([]v2.ModelCluster, error) {
+	s.b.calls.Add(1)
+	var sites []v2.ModelCluster
+	for id := range s.b.buckets {
+		sites = append(sites, v2.ModelCluster{ID: v2.NewOptString(id)})
+	}
+	return sites, nil
+}
+
+type stubSiteStatusAPI struct {
+	objectstorage.SiteStatusAPI
+	b *stubBackend
+}
+
+func (s *stubSiteStatusAPI) Read(context.Context) (*v2.StatusData, error) {
+	s.b.calls.Add(1)
+	return &v2.StatusData{
+		AcceptNew:  v2.NewOptBool(true),
+		StatusCode: v2.NewOptStatusDataStatusCode(v2.StatusDataStatusCode{ID: v2.NewOptInt(1), Status: v2.NewOptString("ok")}),
+	}, nil
+}
+
+func (s *stubSiteStatusAPI) ReadQuota(context.Context) (*v2.QuotaData, error) {
+	s.b.calls.Add(1)
+	return &v2.QuotaData{NumBuckets: v2.NewOptInt(100)}, nil
+}
+
+type stubBucketAPI struct {
+	objectstorage.BucketAPI
+	b      *stubBackend
+	siteId string
+}
+
+func (s *stubBucketAPI) List(context.Context) ([]v2.BucketListDataItem, error) {
+	s.b.calls.Add(1)
+	var res []v2.BucketListDataItem
+	for _, name := range s.b.buckets[s.siteId] {
+		res = append(res, v2.BucketListDataItem{Name: v2.BucketName(name)})
+	}
+	return res, nil
+}
+
+type stubBucketExtraAPI struct {
+	objectstorage.BucketExtraAPI
+	b      *stubBackend
+	bucket string
+}
+
+func (s *stubBucketExtraAPI) ReadUsage(context.Context) (*v2.BucketUsageData, error) {
+	s.b.calls.Add(1)
+	return &v2.BucketUsageData{NumObjectsPerBucket: v2.NewOptInt(42), AmountGibPerBucket: v2.NewOptFloat32(1.5)}, nil
+}
+
+func (s *stubBucketExtraAPI) ReadQuota(context.Context) (*v2.BucketQuotaData, error) {
+	s.b.calls.Add(1)
+	return &v2.BucketQuotaData{NumObjectsPerBucket: v2.NewOptInt(1000), AmountGibPerBucket: v2.NewOptFloat32(10)}, nil
+}
+
+func (s *stubBucketExtraAPI) ReadPenalty(context.Context) (*v2.BucketPenaltyData, error) {
+	s.b.calls.Add(1)
+	if s.bucket == s.b.failBucket {
+		return nil, objectstorage.NewAPIError("BucketExtra.ReadPenalty", http.StatusInternalServerError, nil)
+	}
+	return &v2.BucketPenaltyData{
+		NumObjectsPerBucket: v2.NewOptBucketPenaltyDataNumObjectsPerBucket(v2.BucketPenaltyDataNumObjectsPerBucket{IsApplied: v2.NewOptBool(false)}),
+		AmountGibPerBucket:  v2.NewOptBucketPenaltyDataAmountGibPerBucket(v2.BucketPenaltyDataAmountGibPerBucket{IsApplied: v2.NewOptBool(true)}),
+	}, nil
+}
+
+func TestCollector(t *testing.T) {
+	backend := &stubBackend{buckets: map[string][]string{"isk01": {"bucket1", "bucket2"}, "tky01": {"bucket3"}}}
+	collector := NewCollector(backend, &Options{Sites: []string{"isk01"}, Concurrency: 2})
+	ctx := context.Background()
+
+	// Refresh前は何も返さない
+	require.Equal(t, 0, testutil.CollectAndCount(collector))
+
+	require.NoError(t, collector.Refresh(ctx))
+	calls := backend.calls.Load()
+	// 2(status/quota) + 1(list) + 2 buckets * 3
+	require.EqualValues(t, 9, calls)
+
+	expected := `
+# HELP sakura_object_storage_bucket_gib Amount of data stored in the bucket in GiB.
+# TYPE sakura_object_storage_bucket_gib gauge
+sakura_object_storage_bucket_gib{bucket="bucket1",site="isk01"} 1.5
+sakura_object_storage_bucket_gib{bucket="bucket2",site="isk01"} 1.5
+# HELP sakura_object_storage_bucket_objects Number of objects in the bucket.
+# TYPE sakura_object_storage_bucket_objects gauge
+sakura_object_storage_bucket_objects{bucket="bucket1",site="isk01"} 42
+sakura_object_storage_bucket_objects{bucket="bucket2",site="isk01"} 42
+# HELP sakura_object_storage_bucket_penalty_applied Whether a penalty is applied to the bucket (1) or not (0).
+# TYPE sakura_object_storage_bucket_penalty_applied gauge
+sakura_object_storage_bucket_penalty_applied{bucket="bucket1",resource="gib",site="isk01"} 1
+sakura_object_storage_bucket_penalty_applied{bucket="bucket1",resource="objects",site="isk01"} 0
+sakura_object_storage_bucket_penalty_applied{bucket="bucket2",resource="gib",site="isk01"} 1
+sakura_object_storage_bucket_penalty_applied{bucket="bucket2",resource="objects",site="isk01"} 0
+# HELP sakura_object_storage_site_accept_new Whether the site accepts new buckets (1) or not (0).
+# TYPE sakura_object_storage_site_accept_new gauge
+sakura_object_storage_site_accept_new{site="isk01"} 1
+# HELP sakura_object_storage_site_status_code Status code of the site.
+# TYPE sakura_object_storage_site_status_code gauge
+sakura_object_storage_site_status_code{site="isk01",status="ok"} 1
+# HELP sakura_object_storage_scrape_success Whether the last scrape of the site succeeded (1) or not (0).
+# TYPE sakura_object_storage_scrape_success gauge
+sakura_object_storage_scrape_success{site="isk01"} 1
+`
+	names := []string{
+		"sakura_object_storage_bucket_gib",
+		"sakura_object_storage_bucket_objects",
+		"sakura_object_storage_bucket_penalty_applied",
+		"sakura_object_storage_site_accept_new",
+		"sakura_object_storage_site_status_code",
+		"sakura_object_storage_scrape_success",
+	}
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected), names...))
+
+	// Collectはキャッシュした値を返すのみ
+	testutil.CollectAndCount(collector)
+	require.Equal(t, calls, backend.calls.Load())
+
+	t.Run("duplicated sites", func(t *testing.T) {
+		backend.calls.Store(0)
+		collector := NewCollector(backend, &Options{Sites: []string{"isk01", "isk01"}})
+		require.NoError(t, collector.Refresh(ctx))
+		require.EqualValues(t, 9, backend.calls.Load())
+		// 重複したメトリクスはCollectAndCompareでエラーとなる
+		require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected), names...))
+	})
+
+	t.Run("all sites with partial failure", func(t *testing.T) {
+		backend.failBucket = "bucket3"
+		collector := NewCollector(backend, nil)
+		err := collector.Refresh(ctx)
+		require.Error(t, err)
+
+		expected := `
+# HELP sakura_object_storage_scrape_success Whether the last scrape of the site succeeded (1) or not (0).
+# TYPE sakura_object_storage_scrape_success gauge
+sakura_object_storage_scrape_success{site="isk01"} 1
+sakura_object_storage_scrape_success{site="tky01"} 0
+# HELP sakura_object_storage_bucket_objects Number of objects in the bucket.
+# TYPE sakura_object_storage_bucket_objects gauge
+sakura_object_storage_bucket_objects{bucket="bucket1",site="isk01"} 42
+sakura_object_storage_bucket_objects{bucket="bucket2",site="isk01"} 42
+sakura_object_storage_bucket_objects{bucket="bucket3",site="tky01"} 42
+`
+		require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected),
+			"sakura_object_storage_scrape_success", "sakura_object_storage_bucket_objects"))
+	})
+}
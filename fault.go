@@ -0,0 +1,342 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstorage
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sacloud/saclient-go"
+)
+
+// FaultKind 注入する障害の種類
+type FaultKind string
+
+const (
+	// FaultLatency Latencyだけ待ってからリクエストを行う
+	FaultLatency FaultKind = "latency"
+	// FaultConnectionReset 接続がリセットされたとしてエラーを返す
+	FaultConnectionReset FaultKind = "connection_reset"
+	// FaultStatus StatusCodeのエラーレスポンスを返す。ボディはAPIのエラーのスキーマ(Error400など)に従う
+	FaultStatus FaultKind = "status"
+	// FaultTruncatedBody リクエストを行い、レスポンスのボディを途中で切り詰めて返す
+	FaultTruncatedBody FaultKind = "truncated_body"
+	// FaultTimeout Latencyだけ(0の場合はコンテキストが終了するまで)待ってからタイムアウトのエラーを返す
+	FaultTimeout FaultKind = "timeout"
+)
+
+// FaultRule 障害を注入する条件と内容
+//
+// Operations/Pathの両方が空の場合は全てのリクエストが対象となる。
+// 対象のリクエストのうち、Nth/Probability/Timesの条件を満たしたものに障害が注入される
+type FaultRule struct {
+	// Operations 対象とするオペレーション名
+	Operations []string
+	// Path 対象とするURLのパスの正規表現
+	Path string
+
+	// Kind 障害の種類
+	Kind FaultKind
+	// Latency FaultLatency/FaultTimeoutで待つ時間
+	Latency time.Duration
+	// StatusCode FaultStatusで返すステータスコード
+	StatusCode int
+	// Message FaultStatusで返すエラーメッセージ。空の場合はステータスコードに応じたメッセージ
+	Message string
+	// TruncateAt FaultTruncatedBodyで残すボディのバイト数。0の場合はボディの半分
+	TruncateAt int
+
+	// Nth 対象のリクエストのうちNth番目(1始まり)のみに注入する。0の場合は全て
+	Nth int
+	// Probability 注入する確率(0〜1)。0の場合は常に注入する
+	Probability float64
+	// Times 注入する最大回数。0の場合は無制限
+	Times int
+}
+
+// InjectedFault 注入した障害の記録
+type InjectedFault struct {
+	// Rule 注入したFaultRuleのインデックス
+	Rule      int
+	Kind      FaultKind
+	Operation string
+	Method    string
+	Path      string
+}
+
+type faultRule struct {
+	FaultRule
+	path     *regexp.Regexp
+	calls    int
+	injected int
+}
+
+// FaultInjector ルールに従ってAPIへのリクエストに障害を注入する
+//
+// 利用者側のリトライやエラー処理をテストするためのもので、乱数のシードを固定することで結果は決定的になる。
+// saclientのミドルウェアとして用いる場合は認証やリトライより前段で動作するため、障害はリトライされずに呼び出し元へ返る
+type FaultInjector struct {
+	mu       sync.Mutex
+	rules    []*faultRule
+	rand     *rand.Rand
+	injected []InjectedFault
+}
+
+// NewFaultInjector FaultInjectorを生成する。ルールは先頭から評価され、最初に条件を満たしたルールの障害のみが注入される
+func NewFaultInjector(seed uint64, rules ...FaultRule) (*FaultInjector, error) {
+	f := &FaultInjector{rand: rand.New(rand.NewPCG(seed, seed))} //nolint:gosec
+	for i, rule := range rules {
+		r := &faultRule{FaultRule: rule}
+		if rule.Path != "" {
+			p, err := regexp.Compile(rule.Path)
+			if err != nil {
+				return nil, NewError(fmt.Sprintf("invalid path pattern in fault rule #%d", i), err)
+			}
+			r.path = p
+		}
+		switch rule.Kind {
+		case FaultLatency, FaultConnectionReset, FaultTruncatedBody, FaultTimeout:
+		case FaultStatus:
+			if rule.StatusCode < 400 || rule.StatusCode > 599 {
+				return nil, NewError(fmt.Sprintf("fault rule #%d: status code must be 4xx or 5xx: %d", i, rule.StatusCode), nil)
+			}
+		default:
+			return nil, NewError(fmt.Sprintf("fault rule #%d: unknown kind %q", i, rule.Kind), nil)
+		}
+		if rule.Probability < 0 || rule.Probability > 1 {
+			return nil, NewError(fmt.Sprintf("fault rule #%d: probability must be between 0 and 1: %v", i, rule.Probability), nil)
+		}
+		f.rules = append(f.rules, r)
+	}
+	return f, nil
+}
+
+// Injected これまでに注入した障害を返す
+func (f *FaultInjector) Injected() []InjectedFault {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.injected)
+}
+
+// Middleware saclientのミドルウェアとして障害を注入する
+func (f *FaultInjector) Middleware() saclient.Middleware {
+	return func(req *http.Request, pull func() (saclient.Middleware, bool)) (*http.Response, error) {
+		return f.roundTrip(req, func(req *http.Request) (*http.Response, error) {
+			next, ok := pull()
+			if !ok {
+				return nil, NewError("no next middleware", nil)
+			}
+			return next(req, pull)
+		})
+	}
+}
+
+// RoundTripper http.RoundTripperとして障害を注入する。baseがnilの場合はhttp.DefaultTransportを用いる
+func (f *FaultInjector) RoundTripper(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return faultRoundTripper{f: f, base: base}
+}
+
+type faultRoundTripper struct {
+	f    *FaultInjector
+	base http.RoundTripper
+}
+
+func (t faultRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.f.roundTrip(req, t.base.RoundTrip)
+}
+
+func (f *FaultInjector) roundTrip(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	var operation string
+	if routed, ok := ResolveOperation(req.Method, req.URL); ok {
+		operation = routed.Operation.Name
+	}
+	rule, traceId := f.trigger(req, operation)
+	if rule == nil {
+		return next(req)
+	}
+
+	ctx := req.Context()
+	switch rule.Kind {
+	case FaultLatency:
+		if err := sleep(ctx, rule.Latency); err != nil {
+			return nil, err
+		}
+		return next(req)
+	case FaultConnectionReset:
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+	case FaultTimeout:
+		if rule.Latency > 0 {
+			if err := sleep(ctx, rule.Latency); err != nil {
+				return nil, err
+			}
+		} else {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}
+	case FaultStatus:
+		return faultResponse(req, rule.StatusCode, rule.Message, traceId), nil
+	case FaultTruncatedBody:
+		res, err := next(req)
+		if err != nil || res.Body == nil {
+			return res, err
+		}
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close() //nolint:errcheck,gosec
+		if err != nil {
+			return nil, err
+		}
+		n := rule.TruncateAt
+		if n <= 0 || n > len(body) {
+			n = len(body) / 2
+		}
+		res.Body = io.NopCloser(bytes.NewReader(body[:n]))
+		res.ContentLength = int64(n)
+		res.Header.Set("Content-Length", strconv.Itoa(n))
+		return res, nil
+	}
+	return next(req)
+}
+
+// trigger 条件を満たすルールを返す。FaultStatusの場合に返すトレースIDも乱数から生成する
+func (f *FaultInjector) trigger(req *http.Request, operation string) (*faultRule, string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var fired *faultRule
+	for i, rule := range f.rules {
+		if !rule.matches(req, operation) {
+			continue
+		}
+		rule.calls++
+		if fired != nil {
+			continue
+		}
+		if rule.Nth > 0 && rule.calls != rule.Nth {
+			continue
+		}
+		if rule.Times > 0 && rule.injected >= rule.Times {
+			continue
+		}
+		if rule.Probability > 0 && f.rand.Float64() >= rule.Probability {
+			continue
+		}
+		rule.injected++
+		fired = rule
+		f.injected = append(f.injected, InjectedFault{Rule: i, Kind: rule.Kind, Operation: operation, Method: req.Method, Path: req.URL.Path})
+	}
+	if fired == nil || fired.Kind != FaultStatus {
+		return fired, ""
+	}
+	traceId := make([]byte, 16)
+	for i := range traceId {
+		traceId[i] = byte(f.rand.UintN(256))
+	}
+	return fired, hex.EncodeToString(traceId)
+}
+
+func (r *faultRule) matches(req *http.Request, operation string) bool {
+	if len(r.Operations) > 0 && !slices.Contains(r.Operations, operation) {
+		return false
+	}
+	if r.path != nil && !r.path.MatchString(req.URL.Path) {
+		return false
+	}
+	return true
+}
+
+// faultMessages ステータスコードごとのエラーメッセージ。APIの定義の例に従う
+var faultMessages = map[int]string{
+	http.StatusBadRequest:   "Invalid parameters.",
+	http.StatusUnauthorized: "Authentication failed.",
+	http.StatusForbidden:    "Operation denied.",
+	http.StatusNotFound:     "Resource does not exist.",
+	http.StatusConflict:     "Resource already created.",
+}
+
+// faultResponse APIのエラーのスキーマ(Error400/401/403/404/409/ErrorDefault)に従ったレスポンスを返す
+func faultResponse(req *http.Request, code int, message, traceId string) *http.Response {
+	if message == "" {
+		message = faultMessages[code]
+	}
+	if message == "" {
+		message = "unknown error occurred."
+	}
+	type errorsItem struct {
+		Domain       string `json:"domain"`
+		Location     string `json:"location"`
+		LocationType string `json:"location_type"`
+		Message      string `json:"message"`
+		Reason       string `json:"reason"`
+	}
+	var payload struct {
+		Error struct {
+			Code    int          `json:"code"`
+			Message string       `json:"message"`
+			TraceID string       `json:"trace_id"`
+			Errors  []errorsItem `json:"errors,omitempty"`
+		} `json:"error"`
+	}
+	payload.Error.Code = code
+	payload.Error.Message = message
+	payload.Error.TraceID = traceId
+	// 認証に関するエラーのみ詳細なエラー内容を含む
+	if code == http.StatusUnauthorized || code == http.StatusForbidden {
+		domain := "cluster-api-objectstorage.sacloud"
+		if routed, ok := ResolveOperation(req.Method, req.URL); ok && routed.SiteID == "" {
+			domain = "federation-api-objectstorage.sacloud"
+		}
+		payload.Error.Errors = []errorsItem{{
+			Domain:       domain,
+			Location:     "Authorization",
+			LocationType: "header",
+			Message:      message,
+			Reason:       http.StatusText(code),
+		}}
+	}
+	body, _ := json.Marshal(payload) //nolint:errchkjson
+
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		StatusCode: code,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header: http.Header{
+			"Content-Type":   []string{"application/json"},
+			"Content-Length": []string{strconv.Itoa(len(body))},
+		},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
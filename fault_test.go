@@ -0,0 +1,147 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstorage
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+	"time"
+
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFaultInjector(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":[{"name":"bucket1"},{"name":"bucket2"}]}`)) //nolint:errcheck,gosec
+	})
+	ctx := context.Background()
+
+	newBucketAPI := func(t *testing.T, rules ...FaultRule) (BucketAPI, *FaultInjector) {
+		f, err := NewFaultInjector(42, rules...)
+		require.NoError(t, err)
+		client, apiRootURL := newTestClient(t, handler, f.Middleware())
+		siteClient, err := NewSiteClientWithAPIRootURL(client, apiRootURL, "isk01")
+		require.NoError(t, err)
+		return NewBucketOp(nil, siteClient), f
+	}
+
+	t.Run("nth call", func(t *testing.T) {
+		buckets, f := newBucketAPI(t, FaultRule{Operations: []string{v2.ListBucketsOperation}, Kind: FaultStatus, StatusCode: http.StatusUnauthorized, Nth: 2})
+		_, err := buckets.List(ctx)
+		require.NoError(t, err)
+		_, err = buckets.List(ctx)
+		require.Error(t, err)
+		require.ErrorContains(t, err, "Authentication failed.")
+		_, err = buckets.List(ctx)
+		require.NoError(t, err)
+
+		require.Equal(t, []InjectedFault{
+			{Rule: 0, Kind: FaultStatus, Operation: v2.ListBucketsOperation, Method: http.MethodGet, Path: "/isk01/v2/buckets"},
+		}, f.Injected())
+	})
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	t.Run("probability with fixed seed", func(t *testing.T) {
+		results := func() []bool {
+			f, err := NewFaultInjector(42, FaultRule{Path: "/buckets$", Kind: FaultStatus, StatusCode: http.StatusInternalServerError, Probability: 0.5})
+			require.NoError(t, err)
+			client := &http.Client{Transport: f.RoundTripper(nil)}
+			var injected []bool
+			for range 20 {
+				res, err := client.Get(server.URL + "/isk01/v2/buckets")
+				require.NoError(t, err)
+				res.Body.Close() //nolint:errcheck,gosec
+				injected = append(injected, res.StatusCode == http.StatusInternalServerError)
+			}
+			return injected
+		}
+		first := results()
+		require.Equal(t, first, results())
+		require.Contains(t, first, true)
+		require.Contains(t, first, false)
+	})
+
+	t.Run("times", func(t *testing.T) {
+		buckets, _ := newBucketAPI(t, FaultRule{Kind: FaultConnectionReset, Times: 2})
+		for range 2 {
+			_, err := buckets.List(ctx)
+			require.ErrorIs(t, err, syscall.ECONNRESET)
+		}
+		_, err := buckets.List(ctx)
+		require.NoError(t, err)
+	})
+
+	t.Run("truncated body", func(t *testing.T) {
+		buckets, _ := newBucketAPI(t, FaultRule{Kind: FaultTruncatedBody})
+		_, err := buckets.List(ctx)
+		require.Error(t, err)
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		buckets, _ := newBucketAPI(t, FaultRule{Kind: FaultLatency, Latency: 50 * time.Millisecond})
+		start := time.Now()
+		_, err := buckets.List(ctx)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		buckets, _ := newBucketAPI(t, FaultRule{Kind: FaultTimeout})
+		ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err := buckets.List(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		buckets, _ = newBucketAPI(t, FaultRule{Kind: FaultTimeout, Latency: time.Millisecond})
+		_, err = buckets.List(context.Background())
+		var netErr net.Error
+		require.ErrorAs(t, err, &netErr)
+		require.True(t, netErr.Timeout())
+	})
+
+	t.Run("error bodies match the schema", func(t *testing.T) {
+		for _, code := range []int{400, 401, 403, 404, 409, 503} {
+			f, err := NewFaultInjector(1, FaultRule{Kind: FaultStatus, StatusCode: code})
+			require.NoError(t, err)
+			client := &http.Client{Transport: f.RoundTripper(nil)}
+			res, err := client.Get(server.URL + "/fed/v1/clusters")
+			require.NoError(t, err)
+			body, err := io.ReadAll(res.Body)
+			res.Body.Close() //nolint:errcheck,gosec
+			require.NoError(t, err)
+			require.Equal(t, code, res.StatusCode)
+
+			var payload v2.ErrorDefault
+			require.NoError(t, json.Unmarshal(body, &payload))
+			require.EqualValues(t, code, payload.Error.Value.Code.Value)
+			require.NotEmpty(t, payload.Error.Value.Message.Value)
+			require.Len(t, payload.Error.Value.TraceID.Value, 32)
+			if code == 401 || code == 403 {
+				require.Equal(t, "federation-api-objectstorage.sacloud", string(payload.Error.Value.Errors[0].Domain.Value))
+			}
+		}
+	})
+
+	t.Run("invalid rules", func(t *testing.T) {
+		for _, rule := range []FaultRule{
+			{Kind: "unknown"},
+			{Kind: FaultStatus, StatusCode: http.StatusOK},
+			{Kind: FaultLatency, Probability: 1.5},
+			{Kind: FaultLatency, Path: "("},
+		} {
+			_, err := NewFaultInjector(0, rule)
+			require.Error(t, err)
+		}
+	})
+}
@@ -0,0 +1,354 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package gateway APIのトークンを保持し、チームごとの権限を適用した簡易なREST APIを提供するゲートウェイ
+//
+// 呼び出し元は独自のベアラートークンで認証し、Policyでトークンに対応付けたチームに許可された
+// サイト、バケット名のプレフィックス、操作の範囲でのみAPIを呼び出せる。
+// ヘルスチェック以外の全ての呼び出しは結果に関わらず監査ログに記録する
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+)
+
+// DefaultCallTimeout APIの呼び出しのタイムアウトのデフォルト値
+const DefaultCallTimeout = 30 * time.Second
+
+// AuditRecord 監査ログの記録
+type AuditRecord struct {
+	Time time.Time
+	// Team 認証されたチームの名前。認証に失敗した場合は空
+	Team         string
+	Operation    Operation
+	Method       string
+	Path         string
+	SiteID       string
+	Bucket       string
+	PermissionID string
+	// Status 呼び出し元に返したHTTPステータス
+	Status     int
+	Duration   time.Duration
+	RemoteAddr string
+	// Error 失敗した場合のエラーの内容
+	Error string
+}
+
+// Options Newのオプション
+type Options struct {
+	// Audit 監査ログを記録する関数。nilの場合はslog.Defaultに記録する
+	Audit func(ctx context.Context, record AuditRecord)
+	// CallTimeout APIの呼び出しのタイムアウト。0以下の場合はDefaultCallTimeout
+	CallTimeout time.Duration
+	// Now 現在時刻を返す関数。nilの場合はtime.Now
+	Now func() time.Time
+}
+
+// Gateway ゲートウェイのhttp.Handler
+type Gateway struct {
+	backend objectstorage.Backend
+	policy  *Policy
+	opts    Options
+	mux     *http.ServeMux
+}
+
+var _ http.Handler = (*Gateway)(nil)
+
+// New Gatewayを作成する
+func New(backend objectstorage.Backend, policy *Policy, opts *Options) *Gateway {
+	g := &Gateway{backend: backend, policy: policy, mux: http.NewServeMux()}
+	if opts != nil {
+		g.opts = *opts
+	}
+	if g.opts.Audit == nil {
+		g.opts.Audit = logAudit
+	}
+	if g.opts.CallTimeout <= 0 {
+		g.opts.CallTimeout = DefaultCallTimeout
+	}
+	if g.opts.Now == nil {
+		g.opts.Now = time.Now
+	}
+
+	g.mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	g.handle("GET /v1/sites/{site}/buckets", OpListBuckets, g.listBuckets)
+	g.handle("GET /v1/sites/{site}/buckets/{bucket}/usage", OpReadUsage, g.readUsage)
+	g.handle("GET /v1/sites/{site}/permissions", OpListPermissions, g.listPermissions)
+	g.handle("POST /v1/sites/{site}/permissions/{permission}/keys", OpIssueKey, g.issueKey)
+	return g
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mux.ServeHTTP(w, r)
+}
+
+// logAudit 監査ログをslog.Defaultに記録する
+func logAudit(ctx context.Context, record AuditRecord) {
+	slog.InfoContext(ctx, "audit",
+		"team", record.Team,
+		"operation", record.Operation,
+		"method", record.Method,
+		"path", record.Path,
+		"site", record.SiteID,
+		"bucket", record.Bucket,
+		"permission", record.PermissionID,
+		"status", record.Status,
+		"duration", record.Duration,
+		"remote_addr", record.RemoteAddr,
+		"error", record.Error,
+	)
+}
+
+// httpError 呼び出し元に返すステータスを持つエラー
+type httpError struct {
+	status  int
+	message string
+}
+
+func (e *httpError) Error() string {
+	return e.message
+}
+
+// statusOf エラーに対応するHTTPステータスを返す。APIのクライアントエラーはそのまま返し、それ以外は502とする
+func statusOf(err error) int {
+	var e *httpError
+	if errors.As(err, &e) {
+		return e.status
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+	if code := objectstorage.StatusCode(err); code >= 400 && code < 500 && code != http.StatusUnauthorized && code != http.StatusForbidden {
+		return code
+	}
+	return http.StatusBadGateway
+}
+
+// request 認証と認可を済ませた呼び出し
+type request struct {
+	*http.Request
+	team *Team
+}
+
+type handlerFunc func(ctx context.Context, r *request) (status int, body any, err error)
+
+// handle 認証、認可、監査を行うハンドラを登録する
+func (g *Gateway) handle(pattern string, op Operation, h handlerFunc) {
+	g.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		start := g.opts.Now()
+		record := AuditRecord{
+			Time:         start,
+			Operation:    op,
+			Method:       r.Method,
+			Path:         r.URL.Path,
+			SiteID:       r.PathValue("site"),
+			Bucket:       r.PathValue("bucket"),
+			PermissionID: r.PathValue("permission"),
+			RemoteAddr:   r.RemoteAddr,
+		}
+
+		status, body, err := g.serve(r, op, &record, h)
+		if err != nil {
+			status = statusOf(err)
+			body = map[string]string{"error": err.Error()}
+			record.Error = err.Error()
+		}
+		writeJSON(w, status, body)
+
+		record.Status = status
+		record.Duration = g.opts.Now().Sub(start)
+		g.opts.Audit(r.Context(), record)
+	})
+}
+
+func (g *Gateway) serve(r *http.Request, op Operation, record *AuditRecord, h handlerFunc) (int, any, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return 0, nil, &httpError{http.StatusUnauthorized, "bearer token is required"}
+	}
+	team := g.policy.authenticate(token)
+	if team == nil {
+		return 0, nil, &httpError{http.StatusUnauthorized, "invalid bearer token"}
+	}
+	record.Team = team.Name
+
+	switch {
+	case !team.allowsOperation(op):
+		return 0, nil, &httpError{http.StatusForbidden, "operation " + string(op) + " is not allowed"}
+	case !team.allowsSite(record.SiteID):
+		return 0, nil, &httpError{http.StatusForbidden, "site " + record.SiteID + " is not allowed"}
+	case record.Bucket != "" && !team.allowsBucket(record.Bucket):
+		return 0, nil, &httpError{http.StatusForbidden, "bucket " + record.Bucket + " is not allowed"}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), g.opts.CallTimeout)
+	defer cancel()
+	return h(ctx, &request{Request: r, team: team})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body) //nolint:errcheck,gosec
+}
+
+// Bucket バケットの一覧の項目
+type Bucket struct {
+	Name string `json:"name"`
+}
+
+// Usage バケットの使用量と上限。上限が設定されていない項目は省略する
+type Usage struct {
+	Objects      int      `json:"objects"`
+	GiB          float64  `json:"gib"`
+	QuotaObjects *int     `json:"quota_objects,omitempty"`
+	QuotaGiB     *float64 `json:"quota_gib,omitempty"`
+}
+
+// Permission パーミッション
+type Permission struct {
+	ID      string             `json:"id"`
+	Name    string             `json:"name"`
+	Buckets []PermissionBucket `json:"buckets"`
+}
+
+// PermissionBucket パーミッションが許可するバケットへのアクセス
+type PermissionBucket struct {
+	Name  string `json:"name"`
+	Read  bool   `json:"read"`
+	Write bool   `json:"write"`
+}
+
+// Key 発行したアクセスキー。シークレットは発行時のみ返す
+type Key struct {
+	ID        string    `json:"id"`
+	Secret    string    `json:"secret"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (g *Gateway) listBuckets(ctx context.Context, r *request) (int, any, error) {
+	api, err := g.backend.Buckets(r.PathValue("site"))
+	if err != nil {
+		return 0, nil, err
+	}
+	list, err := api.List(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	buckets := []Bucket{}
+	for _, bucket := range list {
+		if r.team.allowsBucket(string(bucket.Name)) {
+			buckets = append(buckets, Bucket{Name: string(bucket.Name)})
+		}
+	}
+	return http.StatusOK, buckets, nil
+}
+
+func (g *Gateway) readUsage(ctx context.Context, r *request) (int, any, error) {
+	api, err := g.backend.BucketExtra(r.PathValue("site"), r.PathValue("bucket"))
+	if err != nil {
+		return 0, nil, err
+	}
+	usage, err := api.ReadUsage(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	quota, err := api.ReadQuota(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	res := Usage{
+		Objects: usage.NumObjectsPerBucket.Value,
+		GiB:     float64(usage.AmountGibPerBucket.Value),
+	}
+	if v, ok := quota.NumObjectsPerBucket.Get(); ok {
+		res.QuotaObjects = &v
+	}
+	if v, ok := quota.AmountGibPerBucket.Get(); ok {
+		gib := float64(v)
+		res.QuotaGiB = &gib
+	}
+	return http.StatusOK, res, nil
+}
+
+// scoped パーミッションが許可する全てのバケットがチームに許可されている場合にtrueを返す
+func scoped(team *Team, controls v2.BucketControls) bool {
+	if len(controls) == 0 {
+		return false
+	}
+	for _, control := range controls {
+		if !team.allowsBucket(string(control.BucketName.Value)) {
+			return false
+		}
+	}
+	return true
+}
+
+func toPermission(id v2.PermissionID, name v2.DisplayName, controls v2.BucketControls) Permission {
+	res := Permission{ID: strconv.FormatInt(int64(id), 10), Name: string(name), Buckets: []PermissionBucket{}}
+	for _, control := range controls {
+		res.Buckets = append(res.Buckets, PermissionBucket{
+			Name:  string(control.BucketName.Value),
+			Read:  bool(control.CanRead.Value),
+			Write: bool(control.CanWrite.Value),
+		})
+	}
+	return res
+}
+
+// listPermissions チームに許可されたバケットのみを対象とするパーミッションの一覧を返す
+func (g *Gateway) listPermissions(ctx context.Context, r *request) (int, any, error) {
+	api, err := g.backend.Permissions(r.PathValue("site"))
+	if err != nil {
+		return 0, nil, err
+	}
+	list, err := api.List(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	permissions := []Permission{}
+	for _, permission := range list {
+		if scoped(r.team, permission.BucketControls) {
+			permissions = append(permissions, toPermission(permission.ID.Value, permission.DisplayName.Value, permission.BucketControls))
+		}
+	}
+	return http.StatusOK, permissions, nil
+}
+
+// issueKey パーミッションのアクセスキーを発行する。
+// チームに許可されていないバケットへのアクセスを含むパーミッションの場合は存在しないものとして扱う
+func (g *Gateway) issueKey(ctx context.Context, r *request) (int, any, error) {
+	api, err := g.backend.Permissions(r.PathValue("site"))
+	if err != nil {
+		return 0, nil, err
+	}
+	id := r.PathValue("permission")
+	permission, err := api.Read(ctx, id)
+	if err != nil {
+		return 0, nil, err
+	}
+	if !scoped(r.team, permission.BucketControls) {
+		return 0, nil, &httpError{http.StatusNotFound, "permission " + id + " not found"}
+	}
+	key, err := api.CreateAccessKey(ctx, id)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, Key{
+		ID:        string(key.ID.Value),
+		Secret:    string(key.Secret.Value),
+		CreatedAt: time.Time(key.CreatedAt.Value),
+	}, nil
+}
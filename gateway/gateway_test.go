@@ -0,0 +1,197 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package gateway_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/sacloud/object-storage-api-go/gateway"
+	"github.com/sacloud/object-storage-api-go/objectstoragetest"
+	"github.com/stretchr/testify/require"
+)
+
+var testPolicy = `
+teams:
+  - name: team-a
+    token_sha256: [` + gateway.HashToken("token-a") + `]
+    sites: [isk01]
+    bucket_prefixes: [team-a-]
+    operations: [buckets.list, buckets.usage, permissions.list, keys.issue]
+  - name: readonly
+    token_sha256: [` + gateway.HashToken("token-r") + `]
+    sites: [isk01]
+    bucket_prefixes: [""]
+    operations: [buckets.list]
+`
+
+type testGateway struct {
+	server *httptest.Server
+	fake   *objectstoragetest.Fake
+
+	mu      sync.Mutex
+	records []gateway.AuditRecord
+}
+
+func setup(t *testing.T) *testGateway {
+	t.Helper()
+	ctx := context.Background()
+	fake := objectstoragetest.NewFake()
+	buckets, err := fake.Buckets("isk01")
+	require.NoError(t, err)
+	for _, name := range []string{"team-a-logs", "team-b-logs"} {
+		_, err := buckets.Create(ctx, &objectstorage.BucketCreateParams{SiteId: "isk01", Bucket: name})
+		require.NoError(t, err)
+	}
+	permissions, err := fake.Permissions("isk01")
+	require.NoError(t, err)
+	for _, name := range []string{"team-a-logs", "team-b-logs"} {
+		_, err := permissions.Create(ctx, name, v2.BucketControls{{
+			BucketName: v2.NewOptBucketName(v2.BucketName(name)),
+			CanRead:    v2.NewOptCanRead(true),
+			CanWrite:   v2.NewOptCanWrite(true),
+		}})
+		require.NoError(t, err)
+	}
+
+	policy, err := gateway.ParsePolicy([]byte(testPolicy))
+	require.NoError(t, err)
+	g := &testGateway{fake: fake}
+	g.server = httptest.NewServer(gateway.New(fake, policy, &gateway.Options{
+		Audit: func(ctx context.Context, record gateway.AuditRecord) {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			g.records = append(g.records, record)
+		},
+	}))
+	t.Cleanup(g.server.Close)
+	return g
+}
+
+func (g *testGateway) do(t *testing.T, method, path, token string, v any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, g.server.URL+path, nil)
+	require.NoError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close() //nolint:errcheck
+	if v != nil {
+		require.NoError(t, json.NewDecoder(res.Body).Decode(v))
+	}
+	return res.StatusCode
+}
+
+func (g *testGateway) lastRecord() gateway.AuditRecord {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.records[len(g.records)-1]
+}
+
+func TestGateway_Buckets(t *testing.T) {
+	g := setup(t)
+
+	var buckets []gateway.Bucket
+	require.Equal(t, http.StatusOK, g.do(t, "GET", "/v1/sites/isk01/buckets", "token-a", &buckets))
+	require.Equal(t, []gateway.Bucket{{Name: "team-a-logs"}}, buckets)
+	record := g.lastRecord()
+	require.Equal(t, "team-a", record.Team)
+	require.Equal(t, gateway.OpListBuckets, record.Operation)
+	require.Equal(t, http.StatusOK, record.Status)
+
+	require.Equal(t, http.StatusOK, g.do(t, "GET", "/v1/sites/isk01/buckets", "token-r", &buckets))
+	require.Len(t, buckets, 2)
+
+	var usage gateway.Usage
+	require.Equal(t, http.StatusOK, g.do(t, "GET", "/v1/sites/isk01/buckets/team-a-logs/usage", "token-a", &usage))
+	require.NotNil(t, usage.QuotaGiB)
+	require.Equal(t, http.StatusNotFound, g.do(t, "GET", "/v1/sites/isk01/buckets/team-a-missing/usage", "token-a", nil))
+}
+
+func TestGateway_Authorization(t *testing.T) {
+	g := setup(t)
+
+	for _, tc := range []struct {
+		method, path, token string
+		status              int
+	}{
+		{"GET", "/v1/sites/isk01/buckets", "", http.StatusUnauthorized},
+		{"GET", "/v1/sites/isk01/buckets", "unknown", http.StatusUnauthorized},
+		{"GET", "/v1/sites/tky01/buckets", "token-a", http.StatusForbidden},
+		{"GET", "/v1/sites/isk01/buckets/team-b-logs/usage", "token-a", http.StatusForbidden},
+		{"GET", "/v1/sites/isk01/buckets/team-a-logs/usage", "token-r", http.StatusForbidden},
+		{"GET", "/v1/sites/isk01/permissions", "token-r", http.StatusForbidden},
+	} {
+		var body map[string]string
+		require.Equal(t, tc.status, g.do(t, tc.method, tc.path, tc.token, &body), tc.path)
+		require.NotEmpty(t, body["error"])
+
+		record := g.lastRecord()
+		require.Equal(t, tc.status, record.Status)
+		require.Equal(t, tc.path, record.Path)
+		require.NotEmpty(t, record.Error)
+	}
+
+	// 認可に失敗した場合はAPIを呼び出さない
+	require.Empty(t, g.fake.CallsTo("BucketExtra.ReadUsage"))
+}
+
+func TestGateway_IssueKey(t *testing.T) {
+	g := setup(t)
+
+	var permissions []gateway.Permission
+	require.Equal(t, http.StatusOK, g.do(t, "GET", "/v1/sites/isk01/permissions", "token-a", &permissions))
+	require.Len(t, permissions, 1)
+	require.Equal(t, "team-a-logs", permissions[0].Name)
+
+	var key gateway.Key
+	require.Equal(t, http.StatusCreated, g.do(t, "POST", "/v1/sites/isk01/permissions/"+permissions[0].ID+"/keys", "token-a", &key))
+	require.NotEmpty(t, key.ID)
+	require.NotEmpty(t, key.Secret)
+	require.Empty(t, g.lastRecord().Error)
+
+	// 他のチームのバケットを対象とするパーミッションは存在しないものとして扱う
+	api, err := g.fake.Permissions("isk01")
+	require.NoError(t, err)
+	list, err := api.List(context.Background())
+	require.NoError(t, err)
+	var other string
+	for _, permission := range list {
+		if permission.DisplayName.Value == "team-b-logs" {
+			other = strconv.FormatInt(int64(permission.ID.Value), 10)
+		}
+	}
+	require.Equal(t, http.StatusNotFound, g.do(t, "POST", "/v1/sites/isk01/permissions/"+other+"/keys", "token-a", nil))
+	require.Len(t, g.fake.CallsTo("Permissions.CreateAccessKey"), 1)
+}
+
+func TestGateway_Health(t *testing.T) {
+	g := setup(t)
+	var body map[string]string
+	require.Equal(t, http.StatusOK, g.do(t, "GET", "/healthz", "", &body))
+	require.Equal(t, "ok", body["status"])
+}
+
+func TestParsePolicy(t *testing.T) {
+	for name, policy := range map[string]string{
+		"unknown field":     "teams:\n  - name: a\n    tokens: [x]\n",
+		"missing token":     "teams:\n  - name: a\n",
+		"invalid hash":      "teams:\n  - name: a\n    token_sha256: [abc]\n",
+		"unknown operation": "teams:\n  - name: a\n    token_sha256: [" + gateway.HashToken("a") + "]\n    operations: [buckets.delete]\n",
+		"duplicated token": "teams:\n  - name: a\n    token_sha256: [" + gateway.HashToken("a") + "]\n" +
+			"  - name: b\n    token_sha256: [" + gateway.HashToken("a") + "]\n",
+	} {
+		_, err := gateway.ParsePolicy([]byte(policy))
+		require.Error(t, err, name)
+	}
+}
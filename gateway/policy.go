@@ -0,0 +1,145 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package gateway
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Operation ゲートウェイで許可する操作
+type Operation string
+
+const (
+	// OpListBuckets バケットの一覧の取得
+	OpListBuckets Operation = "buckets.list"
+	// OpReadUsage バケットの使用量と上限の取得
+	OpReadUsage Operation = "buckets.usage"
+	// OpListPermissions パーミッションの一覧の取得
+	OpListPermissions Operation = "permissions.list"
+	// OpIssueKey パーミッションのアクセスキーの発行
+	OpIssueKey Operation = "keys.issue"
+)
+
+// Operations 全ての操作
+var Operations = []Operation{OpListBuckets, OpReadUsage, OpListPermissions, OpIssueKey}
+
+// Policy 呼び出し元のトークンとチームの対応、およびチームごとに許可する範囲
+type Policy struct {
+	Teams []Team `yaml:"teams"`
+}
+
+// Team 呼び出し元のチーム
+type Team struct {
+	Name string `yaml:"name"`
+	// TokenSHA256 チームのベアラートークンのSHA-256の16進表記。トークン自体はポリシーファイルに記載しない
+	TokenSHA256 []string `yaml:"token_sha256"`
+	// Sites 許可するサイトのID
+	Sites []string `yaml:"sites"`
+	// BucketPrefixes 許可するバケット名のプレフィックス。空文字列は全てのバケットに一致する
+	BucketPrefixes []string `yaml:"bucket_prefixes"`
+	// Operations 許可する操作
+	Operations []Operation `yaml:"operations"`
+}
+
+// LoadPolicy ファイルからポリシーを読み込む
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePolicy(data)
+}
+
+// ParsePolicy YAMLのポリシーを読み込んで検証する
+func ParsePolicy(data []byte) (*Policy, error) {
+	var policy Policy
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&policy); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Validate ポリシーを検証する
+func (p *Policy) Validate() error {
+	var errs []error
+	names := map[string]bool{}
+	hashes := map[string]string{}
+	for i, team := range p.Teams {
+		if team.Name == "" {
+			errs = append(errs, fmt.Errorf("teams[%d]: name is required", i))
+		} else if names[team.Name] {
+			errs = append(errs, fmt.Errorf("team %s: duplicated name", team.Name))
+		}
+		names[team.Name] = true
+
+		if len(team.TokenSHA256) == 0 {
+			errs = append(errs, fmt.Errorf("team %s: token_sha256 is required", team.Name))
+		}
+		for _, hash := range team.TokenSHA256 {
+			if b, err := hex.DecodeString(hash); err != nil || len(b) != sha256.Size {
+				errs = append(errs, fmt.Errorf("team %s: token_sha256 %q is not a hex encoded SHA-256 hash", team.Name, hash))
+			} else if other, ok := hashes[strings.ToLower(hash)]; ok {
+				errs = append(errs, fmt.Errorf("team %s: token is also used by team %s", team.Name, other))
+			}
+			hashes[strings.ToLower(hash)] = team.Name
+		}
+		for _, op := range team.Operations {
+			if !slices.Contains(Operations, op) {
+				errs = append(errs, fmt.Errorf("team %s: unknown operation %q", team.Name, op))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// HashToken ポリシーのtoken_sha256に記載するトークンのハッシュを返す
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// authenticate トークンに対応するチームを返す。見つからない場合はnil
+func (p *Policy) authenticate(token string) *Team {
+	sum := sha256.Sum256([]byte(token))
+	var found *Team
+	for i := range p.Teams {
+		for _, hash := range p.Teams[i].TokenSHA256 {
+			expected, err := hex.DecodeString(hash)
+			// 一致したかどうかで処理時間が変わらないよう全ての候補と比較する
+			if err == nil && subtle.ConstantTimeCompare(expected, sum[:]) == 1 {
+				found = &p.Teams[i]
+			}
+		}
+	}
+	return found
+}
+
+func (t *Team) allowsSite(siteId string) bool {
+	return slices.Contains(t.Sites, siteId)
+}
+
+func (t *Team) allowsBucket(bucket string) bool {
+	return slices.ContainsFunc(t.BucketPrefixes, func(prefix string) bool {
+		return strings.HasPrefix(bucket, prefix)
+	})
+}
+
+func (t *Team) allowsOperation(op Operation) bool {
+	return slices.Contains(t.Operations, op)
+}
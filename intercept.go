@@ -0,0 +1,386 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstorage
+
+import (
+	"context"
+	"time"
+
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+)
+
+// Call ラッパーのメソッド呼び出しの情報
+type Call struct {
+	// Method "Buckets.Create"のようなメソッド名。エラーメッセージに含まれるものと同じ
+	Method string
+	// SiteID 呼び出し先のサイトID。サイトに依存しない呼び出しの場合は空
+	SiteID string
+	// Bucket 対象のバケット名。バケットを対象としない呼び出しの場合は空
+	Bucket string
+	// Mutating リソースを変更する呼び出しの場合はtrue
+	Mutating bool
+	// Params リソースを変更する呼び出しの、SiteIDとBucket以外の引数。引数名をキーとする
+	Params map[string]any
+	// Result リソースを変更する呼び出しが成功した場合の戻り値。invokeの完了後に設定される。戻り値がない場合はnil
+	Result any
+}
+
+// Interceptor ラッパーのメソッド呼び出しに割り込む関数
+//
+// invokeを呼び出すことで元のメソッドが実行される。invokeを呼び出さずにエラーを返すこともできる
+type Interceptor func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error
+
+// ChainInterceptors 複数のInterceptorを1つにまとめる。先に指定したものほど外側で実行される
+func ChainInterceptors(interceptors ...Interceptor) Interceptor {
+	return func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error {
+		next := invoke
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, inner := interceptors[i], next
+			next = func(ctx context.Context) error { return interceptor(ctx, call, inner) }
+		}
+		return next(ctx)
+	}
+}
+
+type interceptor struct {
+	intercept Interceptor
+	siteId    string
+}
+
+func (i *interceptor) call(ctx context.Context, method, bucket string, invoke func(ctx context.Context) error) error {
+	return i.intercept(ctx, &Call{Method: method, SiteID: i.siteId, Bucket: bucket}, invoke)
+}
+
+// mutate リソースを変更する呼び出しに割り込む。成功した場合はinvokeの戻り値をCall.Resultに設定する
+func (i *interceptor) mutate(ctx context.Context, call *Call, invoke func(ctx context.Context) (any, error)) error {
+	call.Mutating = true
+	if call.SiteID == "" {
+		call.SiteID = i.siteId
+	}
+	return i.intercept(ctx, call, func(ctx context.Context) error {
+		res, err := invoke(ctx)
+		if err == nil {
+			call.Result = res
+		}
+		return err
+	})
+}
+
+// InterceptSiteAPI SiteAPIの呼び出しにInterceptorを適用する
+func InterceptSiteAPI(api SiteAPI, interceptors ...Interceptor) SiteAPI {
+	return &interceptedSiteAPI{api: api, interceptor: &interceptor{intercept: ChainInterceptors(interceptors...)}}
+}
+
+type interceptedSiteAPI struct {
+	api SiteAPI
+	*interceptor
+}
+
+func (a *interceptedSiteAPI) List(ctx context.Context) (res []v2.ModelCluster, err error) {
+	err = a.call(ctx, "Site.List", "", func(ctx context.Context) (err error) {
+		res, err = a.api.List(ctx)
+		return err
+	})
+	return res, err
+}
+
+func (a *interceptedSiteAPI) Read(ctx context.Context, siteId string) (res *v2.ModelCluster, err error) {
+	err = a.intercept(ctx, &Call{Method: "Site.Read", SiteID: siteId}, func(ctx context.Context) (err error) {
+		res, err = a.api.Read(ctx, siteId)
+		return err
+	})
+	return res, err
+}
+
+func (a *interceptedSiteAPI) ListPlans(ctx context.Context) (res []v2.PlanItem, err error) {
+	err = a.call(ctx, "Site.ListPlans", "", func(ctx context.Context) (err error) {
+		res, err = a.api.ListPlans(ctx)
+		return err
+	})
+	return res, err
+}
+
+// InterceptBucketAPI BucketAPIの呼び出しにInterceptorを適用する
+func InterceptBucketAPI(api BucketAPI, siteId string, interceptors ...Interceptor) BucketAPI {
+	return &interceptedBucketAPI{api: api, interceptor: &interceptor{intercept: ChainInterceptors(interceptors...), siteId: siteId}}
+}
+
+type interceptedBucketAPI struct {
+	api BucketAPI
+	*interceptor
+}
+
+func (a *interceptedBucketAPI) List(ctx context.Context) (res []v2.BucketListDataItem, err error) {
+	err = a.call(ctx, "Buckets.List", "", func(ctx context.Context) (err error) {
+		res, err = a.api.List(ctx)
+		return err
+	})
+	return res, err
+}
+
+func (a *interceptedBucketAPI) Create(ctx context.Context, params *BucketCreateParams) (res *v2.ModelBucket, err error) {
+	call := &Call{Method: "Buckets.Create", SiteID: params.SiteId, Bucket: params.Bucket, Params: map[string]any{"plan": params.Plan}}
+	err = a.mutate(ctx, call, func(ctx context.Context) (_ any, err error) {
+		res, err = a.api.Create(ctx, params)
+		return res, err
+	})
+	return res, err
+}
+
+func (a *interceptedBucketAPI) Delete(ctx context.Context, bucketName string) error {
+	return a.mutate(ctx, &Call{Method: "Buckets.Delete", Bucket: bucketName, Params: map[string]any{}}, func(ctx context.Context) (any, error) {
+		return nil, a.api.Delete(ctx, bucketName)
+	})
+}
+
+// InterceptBucketExtraAPI BucketExtraAPIの呼び出しにInterceptorを適用する
+func InterceptBucketExtraAPI(api BucketExtraAPI, siteId, bucket string, interceptors ...Interceptor) BucketExtraAPI {
+	return &interceptedBucketExtraAPI{api: api, bucket: bucket, interceptor: &interceptor{intercept: ChainInterceptors(interceptors...), siteId: siteId}}
+}
+
+type interceptedBucketExtraAPI struct {
+	api    BucketExtraAPI
+	bucket string
+	*interceptor
+}
+
+func (a *interceptedBucketExtraAPI) ReadEncryption(ctx context.Context) (res *v2.HandlerEncryptionConfigRes, err error) {
+	err = a.call(ctx, "BucketExtra.ReadEncryption", a.bucket, func(ctx context.Context) (err error) {
+		res, err = a.api.ReadEncryption(ctx)
+		return err
+	})
+	return res, err
+}
+
+func (a *interceptedBucketExtraAPI) EnableEncryption(ctx context.Context, KMSKeyID string) error {
+	return a.mutate(ctx, &Call{Method: "BucketExtra.EnableEncryption", Bucket: a.bucket, Params: map[string]any{"kms_key_id": KMSKeyID}}, func(ctx context.Context) (any, error) {
+		return nil, a.api.EnableEncryption(ctx, KMSKeyID)
+	})
+}
+
+func (a *interceptedBucketExtraAPI) DisableEncryption(ctx context.Context) error {
+	return a.mutate(ctx, &Call{Method: "BucketExtra.DisableEncryption", Bucket: a.bucket, Params: map[string]any{}}, func(ctx context.Context) (any, error) {
+		return nil, a.api.DisableEncryption(ctx)
+	})
+}
+
+func (a *interceptedBucketExtraAPI) ReadReplication(ctx context.Context) (res *v2.ModelReplication, err error) {
+	err = a.call(ctx, "BucketExtra.ReadReplication", a.bucket, func(ctx context.Context) (err error) {
+		res, err = a.api.ReadReplication(ctx)
+		return err
+	})
+	return res, err
+}
+
+func (a *interceptedBucketExtraAPI) EnableReplication(ctx context.Context, targetBucket string) (res *v2.ModelReplication, err error) {
+	err = a.mutate(ctx, &Call{Method: "BucketExtra.EnableReplication", Bucket: a.bucket, Params: map[string]any{"target_bucket": targetBucket}}, func(ctx context.Context) (_ any, err error) {
+		res, err = a.api.EnableReplication(ctx, targetBucket)
+		return res, err
+	})
+	return res, err
+}
+
+func (a *interceptedBucketExtraAPI) DisableReplication(ctx context.Context) error {
+	return a.mutate(ctx, &Call{Method: "BucketExtra.DisableReplication", Bucket: a.bucket, Params: map[string]any{}}, func(ctx context.Context) (any, error) {
+		return nil, a.api.DisableReplication(ctx)
+	})
+}
+
+func (a *interceptedBucketExtraAPI) ReadPenalty(ctx context.Context) (res *v2.BucketPenaltyData, err error) {
+	err = a.call(ctx, "BucketExtra.ReadPenalty", a.bucket, func(ctx context.Context) (err error) {
+		res, err = a.api.ReadPenalty(ctx)
+		return err
+	})
+	return res, err
+}
+
+func (a *interceptedBucketExtraAPI) ReadUsage(ctx context.Context) (res *v2.BucketUsageData, err error) {
+	err = a.call(ctx, "BucketExtra.ReadUsage", a.bucket, func(ctx context.Context) (err error) {
+		res, err = a.api.ReadUsage(ctx)
+		return err
+	})
+	return res, err
+}
+
+func (a *interceptedBucketExtraAPI) ReadQuota(ctx context.Context) (res *v2.BucketQuotaData, err error) {
+	err = a.call(ctx, "BucketExtra.ReadQuota", a.bucket, func(ctx context.Context) (err error) {
+		res, err = a.api.ReadQuota(ctx)
+		return err
+	})
+	return res, err
+}
+
+// InterceptAccountAPI AccountAPIの呼び出しにInterceptorを適用する
+func InterceptAccountAPI(api AccountAPI, siteId string, interceptors ...Interceptor) AccountAPI {
+	return &interceptedAccountAPI{api: api, interceptor: &interceptor{intercept: ChainInterceptors(interceptors...), siteId: siteId}}
+}
+
+type interceptedAccountAPI struct {
+	api AccountAPI
+	*interceptor
+}
+
+func (a *interceptedAccountAPI) Create(ctx context.Context) (res *v2.AccountData, err error) {
+	err = a.mutate(ctx, &Call{Method: "Accounts.Create", Params: map[string]any{}}, func(ctx context.Context) (_ any, err error) {
+		res, err = a.api.Create(ctx)
+		return res, err
+	})
+	return res, err
+}
+
+func (a *interceptedAccountAPI) Read(ctx context.Context) (res *v2.AccountData, err error) {
+	err = a.call(ctx, "Accounts.Read", "", func(ctx context.Context) (err error) {
+		res, err = a.api.Read(ctx)
+		return err
+	})
+	return res, err
+}
+
+func (a *interceptedAccountAPI) Delete(ctx context.Context) error {
+	return a.mutate(ctx, &Call{Method: "Accounts.Delete", Params: map[string]any{}}, func(ctx context.Context) (any, error) {
+		return nil, a.api.Delete(ctx)
+	})
+}
+
+func (a *interceptedAccountAPI) ListAccessKeys(ctx context.Context) (res []v2.AccountKeysDataItem, err error) {
+	err = a.call(ctx, "Accounts.ListAccessKeys", "", func(ctx context.Context) (err error) {
+		res, err = a.api.ListAccessKeys(ctx)
+		return err
+	})
+	return res, err
+}
+
+func (a *interceptedAccountAPI) CreateAccessKey(ctx context.Context) (res *v2.AccountKeyData, err error) {
+	err = a.mutate(ctx, &Call{Method: "Accounts.CreateAccessKey", Params: map[string]any{}}, func(ctx context.Context) (_ any, err error) {
+		res, err = a.api.CreateAccessKey(ctx)
+		return res, err
+	})
+	return res, err
+}
+
+func (a *interceptedAccountAPI) ReadAccessKey(ctx context.Context, keyId string) (res *v2.AccountKeyData, err error) {
+	err = a.call(ctx, "Accounts.ReadAccessKey", "", func(ctx context.Context) (err error) {
+		res, err = a.api.ReadAccessKey(ctx, keyId)
+		return err
+	})
+	return res, err
+}
+
+func (a *interceptedAccountAPI) DeleteAccessKey(ctx context.Context, keyId string) error {
+	return a.mutate(ctx, &Call{Method: "Accounts.DeleteAccessKey", Params: map[string]any{"key_id": keyId}}, func(ctx context.Context) (any, error) {
+		return nil, a.api.DeleteAccessKey(ctx, keyId)
+	})
+}
+
+// InterceptPermissionsAPI PermissionsAPIの呼び出しにInterceptorを適用する
+func InterceptPermissionsAPI(api PermissionsAPI, siteId string, interceptors ...Interceptor) PermissionsAPI {
+	return &interceptedPermissionsAPI{api: api, interceptor: &interceptor{intercept: ChainInterceptors(interceptors...), siteId: siteId}}
+}
+
+type interceptedPermissionsAPI struct {
+	api PermissionsAPI
+	*interceptor
+}
+
+func (a *interceptedPermissionsAPI) List(ctx context.Context) (res []v2.PermissionsDataItem, err error) {
+	err = a.call(ctx, "Permissions.List", "", func(ctx context.Context) (err error) {
+		res, err = a.api.List(ctx)
+		return err
+	})
+	return res, err
+}
+
+func (a *interceptedPermissionsAPI) Create(ctx context.Context, displayName string, controls v2.BucketControls) (res *v2.PermissionData, err error) {
+	err = a.mutate(ctx, &Call{Method: "Permissions.Create", Params: map[string]any{"display_name": displayName, "bucket_controls": controls}}, func(ctx context.Context) (_ any, err error) {
+		res, err = a.api.Create(ctx, displayName, controls)
+		return res, err
+	})
+	return res, err
+}
+
+func (a *interceptedPermissionsAPI) Read(ctx context.Context, permissionId string) (res *v2.PermissionData, err error) {
+	err = a.call(ctx, "Permissions.Read", "", func(ctx context.Context) (err error) {
+		res, err = a.api.Read(ctx, permissionId)
+		return err
+	})
+	return res, err
+}
+
+func (a *interceptedPermissionsAPI) Update(ctx context.Context, permissionId string, displayName string, controls v2.BucketControls) (res *v2.PermissionData, err error) {
+	err = a.mutate(ctx, &Call{Method: "Permissions.Update", Params: map[string]any{"permission_id": permissionId, "display_name": displayName, "bucket_controls": controls}}, func(ctx context.Context) (_ any, err error) {
+		res, err = a.api.Update(ctx, permissionId, displayName, controls)
+		return res, err
+	})
+	return res, err
+}
+
+func (a *interceptedPermissionsAPI) Delete(ctx context.Context, permissionId string) error {
+	return a.mutate(ctx, &Call{Method: "Permissions.Delete", Params: map[string]any{"permission_id": permissionId}}, func(ctx context.Context) (any, error) {
+		return nil, a.api.Delete(ctx, permissionId)
+	})
+}
+
+func (a *interceptedPermissionsAPI) ListAccessKeys(ctx context.Context, permissionId string) (res []v2.PermissionKeysDataItem, err error) {
+	err = a.call(ctx, "Permissions.ListAccessKeys", "", func(ctx context.Context) (err error) {
+		res, err = a.api.ListAccessKeys(ctx, permissionId)
+		return err
+	})
+	return res, err
+}
+
+func (a *interceptedPermissionsAPI) CreateAccessKey(ctx context.Context, permissionId string) (res *v2.PermissionKeyData, err error) {
+	err = a.mutate(ctx, &Call{Method: "Permissions.CreateAccessKey", Params: map[string]any{"permission_id": permissionId}}, func(ctx context.Context) (_ any, err error) {
+		res, err = a.api.CreateAccessKey(ctx, permissionId)
+		return res, err
+	})
+	return res, err
+}
+
+func (a *interceptedPermissionsAPI) ReadAccessKey(ctx context.Context, permissionId string, accessKeyId string) (res *v2.PermissionKeyData, err error) {
+	err = a.call(ctx, "Permissions.ReadAccessKey", "", func(ctx context.Context) (err error) {
+		res, err = a.api.ReadAccessKey(ctx, permissionId, accessKeyId)
+		return err
+	})
+	return res, err
+}
+
+func (a *interceptedPermissionsAPI) DeleteAccessKey(ctx context.Context, permissionId string, accessKeyId string) error {
+	return a.mutate(ctx, &Call{Method: "Permissions.DeleteAccessKey", Params: map[string]any{"permission_id": permissionId, "access_key_id": accessKeyId}}, func(ctx context.Context) (any, error) {
+		return nil, a.api.DeleteAccessKey(ctx, permissionId, accessKeyId)
+	})
+}
+
+// InterceptSiteStatusAPI SiteStatusAPIの呼び出しにInterceptorを適用する
+func InterceptSiteStatusAPI(api SiteStatusAPI, siteId string, interceptors ...Interceptor) SiteStatusAPI {
+	return &interceptedSiteStatusAPI{api: api, interceptor: &interceptor{intercept: ChainInterceptors(interceptors...), siteId: siteId}}
+}
+
+type interceptedSiteStatusAPI struct {
+	api SiteStatusAPI
+	*interceptor
+}
+
+func (a *interceptedSiteStatusAPI) Read(ctx context.Context) (res *v2.StatusData, err error) {
+	err = a.call(ctx, "SiteStatus.Read", "", func(ctx context.Context) (err error) {
+		res, err = a.api.Read(ctx)
+		return err
+	})
+	return res, err
+}
+
+func (a *interceptedSiteStatusAPI) ReadQuota(ctx context.Context) (res *v2.QuotaData, err error) {
+	err = a.call(ctx, "SiteStatus.ReadQuota", "", func(ctx context.Context) (err error) {
+		res, err = a.api.ReadQuota(ctx)
+		return err
+	})
+	return res, err
+}
+
+func (a *interceptedSiteStatusAPI) ReadBucketMetering(ctx context.Context, bucketName string, from, to time.Time) (res []v2.BucketBillingItem, err error) {
+	err = a.call(ctx, "SiteStatus.ReadBucketMetering", bucketName, func(ctx context.Context) (err error) {
+		res, err = a.api.ReadBucketMetering(ctx, bucketName, from, to)
+		return err
+	})
+	return res, err
+}
@@ -0,0 +1,185 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/sacloud/saclient-go"
+	"golang.org/x/sync/errgroup"
+)
+
+// BucketDetailField ListDetailedで取得するバケットの詳細の項目
+type BucketDetailField string
+
+const (
+	BucketDetailEncryption  BucketDetailField = "encryption"
+	BucketDetailReplication BucketDetailField = "replication"
+	BucketDetailUsage       BucketDetailField = "usage"
+	BucketDetailQuota       BucketDetailField = "quota"
+	BucketDetailPenalty     BucketDetailField = "penalty"
+)
+
+// BucketDetailFields ListDetailedで取得する全ての項目
+var BucketDetailFields = []BucketDetailField{
+	BucketDetailEncryption,
+	BucketDetailReplication,
+	BucketDetailUsage,
+	BucketDetailQuota,
+	BucketDetailPenalty,
+}
+
+// BucketDetail バケットの一覧の項目と詳細
+type BucketDetail struct {
+	SiteID string
+	Bucket v2.BucketListDataItem
+
+	Encryption *v2.HandlerEncryptionConfigRes
+	// Replication レプリケーションの設定。設定されていない場合はnil
+	Replication *v2.ModelReplication
+	Usage       *v2.BucketUsageData
+	Quota       *v2.BucketQuotaData
+	Penalty     *v2.BucketPenaltyData
+
+	// Errors 取得に失敗した項目とそのエラー
+	Errors map[BucketDetailField]error
+}
+
+// ListDetailedOptions ListDetailedのオプション
+type ListDetailedOptions struct {
+	// SiteIDs 対象のサイトのID。空の場合はSiteAPI.Listで取得した全てのサイト
+	SiteIDs []string
+	// Fields 取得する項目。空の場合はBucketDetailFieldsの全ての項目
+	Fields []BucketDetailField
+	// Concurrency 同時に行う呼び出しの数。0以下の場合は8
+	Concurrency int
+	// CallTimeout 呼び出しごとのタイムアウト。0以下の場合は30秒
+	CallTimeout time.Duration
+}
+
+// ListDetailed 複数のサイトのバケットの一覧とそれぞれの詳細を取得する
+//
+// 詳細の取得は並行して行い、失敗した項目はBucketDetail.Errorsに記録する。
+// バケットの一覧の取得に失敗したサイトがある場合は、取得できたサイトの結果とともにエラーを返す
+func ListDetailed(ctx context.Context, backend Backend, opts *ListDetailedOptions) ([]BucketDetail, error) {
+	if opts == nil {
+		opts = &ListDetailedOptions{}
+	}
+	fields := opts.Fields
+	if len(fields) == 0 {
+		fields = BucketDetailFields
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 8
+	}
+	timeout := opts.CallTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	siteIds := opts.SiteIDs
+	if len(siteIds) == 0 {
+		var err error
+		if siteIds, err = ListSiteIDs(ctx, backend); err != nil {
+			return nil, err
+		}
+	}
+
+	var g errgroup.Group
+	g.SetLimit(concurrency)
+	call := func(f func(ctx context.Context) error) func() error {
+		return func() error {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			return f(ctx)
+		}
+	}
+
+	// サイトごとのバケットの一覧
+	buckets := make([][]v2.BucketListDataItem, len(siteIds))
+	siteErrs := make([]error, len(siteIds))
+	for i, siteId := range siteIds {
+		g.Go(call(func(ctx context.Context) error {
+			api, err := backend.Buckets(siteId)
+			if err == nil {
+				buckets[i], err = api.List(ctx)
+			}
+			if err != nil {
+				siteErrs[i] = NewError(fmt.Sprintf("failed to list buckets in site %s", siteId), err)
+			}
+			return nil
+		}))
+	}
+	g.Wait() //nolint:errcheck
+
+	var details []*BucketDetail
+	for i, siteId := range siteIds {
+		for _, bucket := range buckets[i] {
+			details = append(details, &BucketDetail{SiteID: siteId, Bucket: bucket})
+		}
+	}
+
+	var mu sync.Mutex
+	record := func(detail *BucketDetail, field BucketDetailField, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if detail.Errors == nil {
+			detail.Errors = map[BucketDetailField]error{}
+		}
+		detail.Errors[field] = err
+	}
+	for _, detail := range details {
+		api, err := backend.BucketExtra(detail.SiteID, string(detail.Bucket.Name))
+		if err != nil {
+			for _, field := range fields {
+				record(detail, field, err)
+			}
+			continue
+		}
+		for _, field := range fields {
+			g.Go(call(func(ctx context.Context) error {
+				if err := readBucketDetail(ctx, api, detail, field); err != nil {
+					record(detail, field, err)
+				}
+				return nil
+			}))
+		}
+	}
+	g.Wait() //nolint:errcheck
+
+	res := make([]BucketDetail, 0, len(details))
+	for _, detail := range details {
+		res = append(res, *detail)
+	}
+	return res, errors.Join(siteErrs...)
+}
+
+// readBucketDetail fieldの項目を取得してdetailに設定する。項目ごとに異なるフィールドに書き込むため排他は不要
+func readBucketDetail(ctx context.Context, api BucketExtraAPI, detail *BucketDetail, field BucketDetailField) error {
+	var err error
+	switch field {
+	case BucketDetailEncryption:
+		detail.Encryption, err = api.ReadEncryption(ctx)
+	case BucketDetailReplication:
+		detail.Replication, err = api.ReadReplication(ctx)
+		if saclient.IsNotFoundError(err) {
+			detail.Replication, err = nil, nil
+		}
+	case BucketDetailUsage:
+		detail.Usage, err = api.ReadUsage(ctx)
+	case BucketDetailQuota:
+		detail.Quota, err = api.ReadQuota(ctx)
+	case BucketDetailPenalty:
+		detail.Penalty, err = api.ReadPenalty(ctx)
+	default:
+		err = NewError(fmt.Sprintf("unknown bucket detail field: %s", field), nil)
+	}
+	return err
+}
@@ -0,0 +1,134 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstorage_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/sacloud/object-storage-api-go/objectstoragetest"
+	"github.com/stretchr/testify/require"
+)
+
+// slowPenaltyBackend ReadPenaltyがコンテキストの終了まで戻らないBackend
+type slowPenaltyBackend struct {
+	objectstorage.Backend
+}
+
+func (b slowPenaltyBackend) BucketExtra(siteId, bucket string) (objectstorage.BucketExtraAPI, error) {
+	api, err := b.Backend.BucketExtra(siteId, bucket)
+	if err != nil {
+		return nil, err
+	}
+	return slowPenaltyAPI{BucketExtraAPI: api}, nil
+}
+
+type slowPenaltyAPI struct {
+	objectstorage.BucketExtraAPI
+}
+
+func (a slowPenaltyAPI) ReadPenalty(ctx context.Context) (*v2.BucketPenaltyData, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestListDetailed(t *testing.T) {
+	ctx := context.Background()
+	fake := objectstoragetest.NewFake()
+	for site, names := range map[string][]string{"isk01": {"bucket1", "bucket2"}, "tky01": {"bucket3"}} {
+		api, err := fake.Buckets(site)
+		require.NoError(t, err)
+		for _, name := range names {
+			_, err := api.Create(ctx, &objectstorage.BucketCreateParams{Bucket: name})
+			require.NoError(t, err)
+		}
+	}
+	extra, err := fake.BucketExtra("isk01", "bucket1")
+	require.NoError(t, err)
+	_, err = extra.EnableReplication(ctx, "bucket3")
+	require.NoError(t, err)
+	require.NoError(t, fake.SetBucketUsage("isk01", "bucket2", v2.BucketUsageData{NumObjectsPerBucket: v2.NewOptInt(10)}))
+
+	t.Run("all sites", func(t *testing.T) {
+		fake.ResetCalls()
+		var mu sync.Mutex
+		var inflight, peak int
+		fake.OnCall(func(call *objectstorage.Call) error {
+			mu.Lock()
+			inflight++
+			peak = max(peak, inflight)
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			inflight--
+			mu.Unlock()
+			if call.Method == "BucketExtra.ReadQuota" && call.Bucket == "bucket2" {
+				return objectstoragetest.APIError(call.Method, http.StatusInternalServerError)
+			}
+			return nil
+		})
+		defer fake.ClearHooks()
+
+		details, err := objectstorage.ListDetailed(ctx, fake, &objectstorage.ListDetailedOptions{Concurrency: 3})
+		require.NoError(t, err)
+		require.Len(t, details, 3)
+		require.LessOrEqual(t, peak, 3)
+		require.Len(t, fake.CallsTo("Site.List"), 1)
+		require.Len(t, fake.CallsTo("Buckets.List"), 2)
+
+		byName := map[string]objectstorage.BucketDetail{}
+		for _, d := range details {
+			byName[string(d.Bucket.Name)] = d
+		}
+		require.Equal(t, "isk01", byName["bucket1"].SiteID)
+		require.Equal(t, "tky01", byName["bucket3"].SiteID)
+		require.Equal(t, "bucket3", byName["bucket1"].Replication.DestBucket.Name.Value)
+		require.Nil(t, byName["bucket2"].Replication, "replication must be nil when not configured")
+		require.Equal(t, 10, byName["bucket2"].Usage.NumObjectsPerBucket.Value)
+		require.NotNil(t, byName["bucket3"].Penalty)
+
+		// 失敗した項目のみエラーとなる
+		require.Nil(t, byName["bucket2"].Quota)
+		require.Len(t, byName["bucket2"].Errors, 1)
+		require.ErrorContains(t, byName["bucket2"].Errors[objectstorage.BucketDetailQuota], "BucketExtra.ReadQuota")
+		require.Empty(t, byName["bucket1"].Errors)
+	})
+
+	t.Run("site failure and fields", func(t *testing.T) {
+		fake.OnCall(func(call *objectstorage.Call) error {
+			if call.Method == "Buckets.List" && call.SiteID == "tky01" {
+				return objectstoragetest.APIError(call.Method, http.StatusServiceUnavailable)
+			}
+			return nil
+		})
+		defer fake.ClearHooks()
+
+		details, err := objectstorage.ListDetailed(ctx, fake, &objectstorage.ListDetailedOptions{
+			SiteIDs: []string{"isk01", "tky01"},
+			Fields:  []objectstorage.BucketDetailField{objectstorage.BucketDetailUsage},
+		})
+		require.ErrorContains(t, err, "failed to list buckets in site tky01")
+		require.Len(t, details, 2)
+		for _, d := range details {
+			require.NotNil(t, d.Usage)
+			require.Nil(t, d.Quota)
+		}
+	})
+
+	t.Run("call timeout", func(t *testing.T) {
+		details, err := objectstorage.ListDetailed(ctx, slowPenaltyBackend{Backend: fake}, &objectstorage.ListDetailedOptions{
+			SiteIDs:     []string{"tky01"},
+			CallTimeout: 10 * time.Millisecond,
+		})
+		require.NoError(t, err)
+		require.Len(t, details, 1)
+		require.ErrorIs(t, details[0].Errors[objectstorage.BucketDetailPenalty], context.DeadlineExceeded)
+		require.NotNil(t, details[0].Usage)
+	})
+}
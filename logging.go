@@ -0,0 +1,229 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstorage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sacloud/saclient-go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Redacted マスクした値の代わりに出力される文字列
+const Redacted = "REDACTED"
+
+// DefaultMaxLogBodyLen ログに出力するボディの最大長のデフォルト値
+const DefaultMaxLogBodyLen = 8 << 10
+
+var (
+	// alwaysRedactedHeaders 常にマスクされるヘッダ
+	alwaysRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+	// alwaysRedactedFields 常にマスクされるJSONのフィールド。
+	// "secret"はAccountKeyData/PermissionKeyDataのシークレット(SecretAccessKey/PermissionSecret)
+	alwaysRedactedFields = []string{"secret", "secret_access_key", "access_token_secret", "password"}
+)
+
+// LoggingOptions NewLoggingMiddlewareのオプション
+type LoggingOptions struct {
+	// RedactHeaders 追加でマスクするヘッダ名
+	RedactHeaders []string
+	// RedactFields 追加でマスクするJSONのフィールド名
+	RedactFields []string
+	// MaxBodyLen ログに出力するボディの最大長。0の場合はDefaultMaxLogBodyLen
+	MaxBodyLen int
+}
+
+type requestLogger struct {
+	logger        *slog.Logger
+	redactHeaders map[string]bool
+	redactFields  map[string]bool
+	maxBodyLen    int
+}
+
+// NewLoggingMiddleware APIリクエストをslogで記録するsaclientのミドルウェアを返す
+//
+// Infoレベルでオペレーション名、サイト、メソッド、パス、ステータス、所要時間、トレースIDを、
+// Debugレベルでリクエスト/レスポンスのヘッダとボディを記録する。
+// Authorizationヘッダやシークレットを含むフィールドはオプションに関わらず常にマスクされる
+func NewLoggingMiddleware(handler slog.Handler, opts *LoggingOptions) saclient.Middleware {
+	if opts == nil {
+		opts = &LoggingOptions{}
+	}
+	l := &requestLogger{
+		logger:        slog.New(handler),
+		redactHeaders: map[string]bool{},
+		redactFields:  map[string]bool{},
+		maxBodyLen:    opts.MaxBodyLen,
+	}
+	if l.maxBodyLen <= 0 {
+		l.maxBodyLen = DefaultMaxLogBodyLen
+	}
+	for _, h := range slices.Concat(alwaysRedactedHeaders, opts.RedactHeaders) {
+		l.redactHeaders[http.CanonicalHeaderKey(h)] = true
+	}
+	for _, f := range slices.Concat(alwaysRedactedFields, opts.RedactFields) {
+		l.redactFields[strings.ToLower(f)] = true
+	}
+	return l.middleware
+}
+
+func (l *requestLogger) middleware(req *http.Request, pull func() (saclient.Middleware, bool)) (*http.Response, error) {
+	ctx := req.Context()
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+	}
+	if routed, ok := ResolveOperation(req.Method, req.URL); ok {
+		attrs = append(attrs, slog.String("operation", routed.Operation.Name))
+		if routed.SiteID != "" {
+			attrs = append(attrs, slog.String("site", routed.SiteID))
+		}
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		attrs = append(attrs, slog.String("otel_trace_id", sc.TraceID().String()))
+	}
+
+	debug := l.logger.Enabled(ctx, slog.LevelDebug)
+	if debug {
+		body, err := l.readRequestBody(req)
+		if err != nil {
+			return nil, err
+		}
+		l.logger.LogAttrs(ctx, slog.LevelDebug, "object-storage API request", append(attrs,
+			slog.Any("headers", l.headers(req.Header)),
+			slog.String("body", l.body(body)),
+		)...)
+	}
+
+	next, ok := pull()
+	if !ok {
+		return nil, NewError("no next middleware", nil)
+	}
+	start := time.Now()
+	res, err := next(req, pull)
+	attrs = append(attrs, slog.Duration("latency", time.Since(start)))
+	if err != nil {
+		l.logger.LogAttrs(ctx, slog.LevelError, "object-storage API request failed", append(attrs, slog.Any("error", err))...)
+		return res, err
+	}
+
+	attrs = append(attrs, slog.Int("status", res.StatusCode))
+	if res.StatusCode >= 400 {
+		if traceId := peekAPITraceID(res); traceId != "" {
+			attrs = append(attrs, slog.String("trace_id", traceId))
+		}
+	}
+	l.logger.LogAttrs(ctx, slog.LevelInfo, "object-storage API response", attrs...)
+
+	if debug {
+		body := l.peekResponseBody(res)
+		l.logger.LogAttrs(ctx, slog.LevelDebug, "object-storage API response body", append(attrs,
+			slog.Any("headers", l.headers(res.Header)),
+			slog.String("body", l.body(body)),
+		)...)
+	}
+	return res, nil
+}
+
+func (l *requestLogger) readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body.Close() //nolint:errcheck,gosec
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	return body, nil
+}
+
+func (l *requestLogger) peekResponseBody(res *http.Response) []byte {
+	if res.Body == nil {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, int64(l.maxBodyLen)+1))
+	res.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), res.Body), res.Body}
+	if err != nil {
+		return nil
+	}
+	return body
+}
+
+func (l *requestLogger) headers(h http.Header) map[string]string {
+	res := map[string]string{}
+	for k, v := range h {
+		if l.redactHeaders[http.CanonicalHeaderKey(k)] {
+			res[k] = Redacted
+			continue
+		}
+		res[k] = strings.Join(v, ", ")
+	}
+	return res
+}
+
+// body ログに出力するボディ。JSONの場合はシークレットをマスクし、JSON以外や長すぎる場合は長さのみを出力する
+func (l *requestLogger) body(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if len(body) > l.maxBodyLen {
+		return fmt.Sprintf("(body longer than %d bytes)", l.maxBodyLen)
+	}
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return fmt.Sprintf("(non-JSON body, %d bytes)", len(body))
+	}
+	redacted, err := json.Marshal(l.redact(v))
+	if err != nil {
+		return fmt.Sprintf("(non-JSON body, %d bytes)", len(body))
+	}
+	return string(redacted)
+}
+
+func (l *requestLogger) redact(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if l.redactFields[strings.ToLower(k)] {
+				v[k] = Redacted
+				continue
+			}
+			v[k] = l.redact(child)
+		}
+		return v
+	case []any:
+		for i, child := range v {
+			v[i] = l.redact(child)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// RedactJSON JSON内のシークレットを含むフィールドをマスクする。JSONとして解釈できない場合はエラーを返す
+func RedactJSON(data []byte, extraFields ...string) ([]byte, error) {
+	l := &requestLogger{redactFields: map[string]bool{}}
+	for _, f := range slices.Concat(alwaysRedactedFields, extraFields) {
+		l.redactFields[strings.ToLower(f)] = true
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(l.redact(v))
+}
@@ -0,0 +1,108 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstorage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/sacloud/saclient-go"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "jqRaUo5l+EiEYqP8wos9exbmFfq4/vG8CLPYI2XN"
+
+func TestLoggingMiddleware(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/isk01/v2/permissions/12/keys":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"data":{"id":"AKID","secret":"` + testSecret + `","created_at":"2026-01-01T00:00:00Z"}}`)) //nolint:errcheck,gosec
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":404,"message":"not found","trace_id":"trace-404"}}`)) //nolint:errcheck,gosec
+		}
+	})
+	setAuthorization := func(req *http.Request, pull func() (saclient.Middleware, bool)) (*http.Response, error) {
+		req.Header.Set("Authorization", "Basic dG9rZW46c2VjcmV0")
+		next, _ := pull()
+		return next(req, pull)
+	}
+
+	newSiteClient := func(t *testing.T, level slog.Level) (*SiteClient, *bytes.Buffer) {
+		var buf bytes.Buffer
+		logging := NewLoggingMiddleware(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: level}), nil)
+		client, apiRootURL := newTestClient(t, handler, setAuthorization, logging)
+		siteClient, err := NewSiteClientWithAPIRootURL(client, apiRootURL, "isk01")
+		require.NoError(t, err)
+		return siteClient, &buf
+	}
+
+	ctx := context.Background()
+
+	t.Run("info", func(t *testing.T) {
+		siteClient, buf := newSiteClient(t, slog.LevelInfo)
+		_, err := NewPermissionOp(siteClient).Read(ctx, "99")
+		require.Error(t, err)
+
+		records := decodeLogRecords(t, buf)
+		require.Len(t, records, 1)
+		r := records[0]
+		require.Equal(t, "INFO", r["level"])
+		require.Equal(t, v2.GetPermissionOperation, r["operation"])
+		require.Equal(t, "isk01", r["site"])
+		require.Equal(t, http.MethodGet, r["method"])
+		require.Equal(t, "/isk01/v2/permissions/99", r["path"])
+		require.EqualValues(t, http.StatusNotFound, r["status"])
+		require.Equal(t, "trace-404", r["trace_id"])
+		require.Contains(t, r, "latency")
+		require.NotContains(t, r, "body")
+	})
+
+	t.Run("debug", func(t *testing.T) {
+		siteClient, buf := newSiteClient(t, slog.LevelDebug)
+		key, err := NewPermissionOp(siteClient).CreateAccessKey(ctx, "12")
+		require.NoError(t, err)
+		require.Equal(t, testSecret, string(key.Secret.Value), "the response must be passed through untouched")
+
+		require.NotContains(t, buf.String(), testSecret)
+		require.NotContains(t, buf.String(), "dG9rZW46c2VjcmV0")
+
+		records := decodeLogRecords(t, buf)
+		require.Len(t, records, 3)
+		request, response, body := records[0], records[1], records[2]
+		require.Equal(t, "DEBUG", request["level"])
+		require.Equal(t, Redacted, request["headers"].(map[string]any)["Authorization"])
+		require.Equal(t, "INFO", response["level"])
+		require.Equal(t, "DEBUG", body["level"])
+		require.JSONEq(t, `{"data":{"id":"AKID","secret":"REDACTED","created_at":"2026-01-01T00:00:00Z"}}`, body["body"].(string))
+	})
+}
+
+func decodeLogRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for line := range strings.Lines(buf.String()) {
+		var r map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &r))
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestRedactJSON(t *testing.T) {
+	got, err := RedactJSON([]byte(`{"data":[{"id":"a","secret":"s1"},{"nested":{"Secret_Access_Key":"s2","token":"t"}}]}`), "token")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"data":[{"id":"a","secret":"REDACTED"},{"nested":{"Secret_Access_Key":"REDACTED","token":"REDACTED"}}]}`, string(got))
+
+	_, err = RedactJSON([]byte("not json"))
+	require.Error(t, err)
+}
@@ -0,0 +1,100 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package mcp
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// schemaOf 型からJSON Schemaを生成する
+//
+// 構造体のプロパティ名はjsonタグに従い、descriptionタグを説明とする。
+// ogenのOpt型(ValueとSetのみを持つ構造体)のフィールドとomitemptyのフィールドは省略可能とし、それ以外は必須とする
+func schemaOf(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	if value, ok := optValue(t); ok {
+		return schemaOf(value)
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]any{}
+		required := []string{}
+		addProperties(t, properties, &required)
+		return map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}
+	default:
+		return map[string]any{}
+	}
+}
+
+// addProperties 構造体のフィールドをプロパティとして追加する。埋め込まれた構造体のフィールドは展開する
+func addProperties(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			addProperties(field.Type, properties, required)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := schemaOf(field.Type)
+		if description := field.Tag.Get("description"); description != "" {
+			schema["description"] = description
+		}
+		properties[name] = schema
+		if _, opt := optValue(field.Type); !opt && !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// optValue ogenのOpt型の場合に値の型を返す
+func optValue(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() != reflect.Struct || t.NumField() != 2 || !strings.HasPrefix(t.Name(), "Opt") {
+		return nil, false
+	}
+	value, ok := t.FieldByName("Value")
+	if !ok {
+		return nil, false
+	}
+	if set, ok := t.FieldByName("Set"); !ok || set.Type.Kind() != reflect.Bool {
+		return nil, false
+	}
+	return value.Type, true
+}
@@ -0,0 +1,60 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+)
+
+// SecretSink 作成したアクセスキーのシークレットをツールの出力とは別の経路で受け渡す関数
+//
+// 受け渡し先を示す文字列(ファイルのパスなど)を返す。この文字列はツールの出力に含まれるため、シークレットを含めないこと
+type SecretSink func(ctx context.Context, siteId, permissionId string, key *v2.PermissionKeyData) (string, error)
+
+// secretFile DirSecretSinkが書き込むファイルの内容
+type secretFile struct {
+	SiteID          string `json:"site_id"`
+	PermissionID    string `json:"permission_id"`
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+}
+
+// DirSecretSink dirにアクセスキーごとのJSONファイルとしてシークレットを書き込むSecretSinkを返す
+//
+// ファイル名は<サイトID>-<パーミッションID>-<アクセスキーID>.jsonで、パーミッションは0600となる
+func DirSecretSink(dir string) SecretSink {
+	return func(ctx context.Context, siteId, permissionId string, key *v2.PermissionKeyData) (string, error) {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return "", err
+		}
+		data, err := json.MarshalIndent(secretFile{
+			SiteID:          siteId,
+			PermissionID:    permissionId,
+			AccessKeyID:     string(key.ID.Value),
+			SecretAccessKey: string(key.Secret.Value),
+		}, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		path := filepath.Join(dir, filepath.Base(fmt.Sprintf("%s-%s-%s.json", siteId, permissionId, key.ID.Value)))
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600) //nolint:gosec
+		if err != nil {
+			return "", err
+		}
+		if _, err := f.Write(append(data, '\n')); err != nil {
+			f.Close() //nolint:errcheck,gosec
+			return "", err
+		}
+		if err := f.Close(); err != nil {
+			return "", err
+		}
+		return path, nil
+	}
+}
@@ -0,0 +1,327 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package mcp オブジェクトストレージの操作をModel Context Protocol(MCP)のツールとして公開するサーバー
+//
+// 標準入出力で改行区切りのJSON-RPC 2.0のメッセージをやり取りする。
+// 状態を変更するツールはOptions.EnableWriteを指定した場合のみ有効となり、
+// ツールの出力からはアクセスキーのシークレットを取り除く。作成したアクセスキーのシークレットはOptions.SecretSinkに渡す
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+)
+
+// ProtocolVersions サーバーが対応するMCPのプロトコルバージョン。先頭が最新
+var ProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPCのエラーコード
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Options NewServerのオプション
+type Options struct {
+	// EnableWrite 状態を変更するツールを有効にする
+	EnableWrite bool
+	// SecretSink 作成したアクセスキーのシークレットの受け渡し先。nilの場合はアクセスキーを作成するツールは無効となる
+	SecretSink SecretSink
+}
+
+// Tool MCPのツール
+type Tool struct {
+	Name        string
+	Description string
+	// Write 状態を変更するツールの場合はtrue
+	Write bool
+	// InputSchema 引数のJSON Schema。パラメータの型から生成する
+	InputSchema map[string]any
+
+	// secretSink Options.SecretSinkが必要なツールの場合はtrue
+	secretSink bool
+	call       func(ctx context.Context, s *Server, args json.RawMessage) (any, error)
+}
+
+// newTool パラメータの型PからInputSchemaを生成してツールを定義する
+func newTool[P any](name, description string, write bool, call func(ctx context.Context, backend objectstorage.Backend, p *P) (any, error)) *Tool {
+	return newServerTool(name, description, write, func(ctx context.Context, s *Server, p *P) (any, error) {
+		return call(ctx, s.backend, p)
+	})
+}
+
+// newServerTool Serverのオプションを参照するツールを定義する
+func newServerTool[P any](name, description string, write bool, call func(ctx context.Context, s *Server, p *P) (any, error)) *Tool {
+	schema := schemaOf(reflect.TypeFor[P]())
+	return &Tool{
+		Name:        name,
+		Description: description,
+		Write:       write,
+		InputSchema: schema,
+		call: func(ctx context.Context, s *Server, args json.RawMessage) (any, error) {
+			var p P
+			if err := decodeArguments(args, schema, &p); err != nil {
+				return nil, err
+			}
+			return call(ctx, s, &p)
+		},
+	}
+}
+
+// decodeArguments 引数をpに読み込む。未知のプロパティと必須のプロパティの欠落はエラーとする
+func decodeArguments(args json.RawMessage, schema map[string]any, p any) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(args, &fields); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	for _, name := range schema["required"].([]string) {
+		if _, ok := fields[name]; !ok {
+			return fmt.Errorf("invalid arguments: %q is required", name)
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(args))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(p); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+// Content ツールの結果の内容
+type Content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// CallToolResult ツールの呼び出しの結果
+type CallToolResult struct {
+	Content []Content `json:"content"`
+	// IsError ツールの実行に失敗した場合はtrue。Contentにエラーの内容を持つ
+	IsError bool `json:"isError"`
+}
+
+// Server MCPサーバー
+type Server struct {
+	backend objectstorage.Backend
+	opts    Options
+}
+
+// NewServer Serverを作成する
+func NewServer(backend objectstorage.Backend, opts *Options) *Server {
+	s := &Server{backend: backend}
+	if opts != nil {
+		s.opts = *opts
+	}
+	return s
+}
+
+// Tools 有効なツールを返す
+func (s *Server) Tools() []*Tool {
+	var res []*Tool
+	for _, tool := range tools {
+		if s.enabled(tool) == nil {
+			res = append(res, tool)
+		}
+	}
+	return res
+}
+
+// requireSecretSink Options.SecretSinkが必要なツールとする
+func requireSecretSink(tool *Tool) *Tool {
+	tool.secretSink = true
+	return tool
+}
+
+// enabled ツールが有効かを返す。無効な場合はその理由のエラー
+func (s *Server) enabled(tool *Tool) error {
+	if tool.Write && !s.opts.EnableWrite {
+		return fmt.Errorf("tool %s is disabled: write tools must be enabled explicitly", tool.Name)
+	}
+	if tool.secretSink && s.opts.SecretSink == nil {
+		return fmt.Errorf("tool %s is disabled: a secret sink must be configured to receive secrets", tool.Name)
+	}
+	return nil
+}
+
+// CallTool ツールを呼び出す
+//
+// 存在しないツールや無効なツールの場合はエラーを返す。
+// 引数の誤りやAPIの呼び出しの失敗はIsErrorを設定した結果として返す
+func (s *Server) CallTool(ctx context.Context, name string, args json.RawMessage) (*CallToolResult, error) {
+	i := slices.IndexFunc(tools, func(tool *Tool) bool { return tool.Name == name })
+	if i < 0 {
+		return nil, fmt.Errorf("unknown tool: %s", name)
+	}
+	tool := tools[i]
+	if err := s.enabled(tool); err != nil {
+		return nil, err
+	}
+	if len(args) == 0 || string(args) == "null" {
+		args = json.RawMessage("{}")
+	}
+
+	res, err := tool.call(ctx, s, args)
+	if err != nil {
+		return &CallToolResult{Content: []Content{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+	}
+	text, err := redact(res)
+	if err != nil {
+		return nil, err
+	}
+	return &CallToolResult{Content: []Content{{Type: "text", Text: text}}}, nil
+}
+
+// redact 結果をJSONに変換し、シークレットを取り除く
+func redact(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return "", err
+	}
+	data, err = json.Marshal(removeSecrets(value))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func removeSecrets(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		delete(v, "secret")
+		for key, value := range v {
+			v[key] = removeSecrets(value)
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = removeSecrets(value)
+		}
+	}
+	return v
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Serve inから改行区切りのJSON-RPCのメッセージを読み込み、応答をoutに書き込む。inが終端に達すると終了する
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	r := bufio.NewReader(in)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line, err := r.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			if res := s.handle(ctx, line); res != nil {
+				data, err := json.Marshal(res)
+				if err != nil {
+					return err
+				}
+				if _, err := out.Write(append(data, '\n')); err != nil {
+					return err
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// handle メッセージを処理して応答を返す。通知の場合はnilを返す
+func (s *Server) handle(ctx context.Context, message []byte) *response {
+	var req request
+	if err := json.Unmarshal(message, &req); err != nil {
+		return &response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: codeParseError, Message: err.Error()}}
+	}
+	if len(req.ID) == 0 {
+		return nil
+	}
+	res := &response{JSONRPC: "2.0", ID: req.ID}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		res.Error = &rpcError{Code: codeInvalidRequest, Message: "invalid request"}
+		return res
+	}
+
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		json.Unmarshal(req.Params, &params) //nolint:errcheck
+		version := ProtocolVersions[0]
+		if slices.Contains(ProtocolVersions, params.ProtocolVersion) {
+			version = params.ProtocolVersion
+		}
+		res.Result = map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "object-storage-api-go", "version": objectstorage.Version},
+		}
+	case "ping":
+		res.Result = map[string]any{}
+	case "tools/list":
+		var list []map[string]any
+		for _, tool := range s.Tools() {
+			list = append(list, map[string]any{
+				"name":        tool.Name,
+				"description": tool.Description,
+				"inputSchema": tool.InputSchema,
+				"annotations": map[string]any{"readOnlyHint": !tool.Write, "destructiveHint": false},
+			})
+		}
+		res.Result = map[string]any{"tools": list}
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			res.Error = &rpcError{Code: codeInvalidParams, Message: err.Error()}
+			return res
+		}
+		result, err := s.CallTool(ctx, params.Name, params.Arguments)
+		if err != nil {
+			res.Error = &rpcError{Code: codeInvalidParams, Message: err.Error()}
+			return res
+		}
+		res.Result = result
+	default:
+		res.Error = &rpcError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+	return res
+}
@@ -0,0 +1,209 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/sacloud/object-storage-api-go/objectstoragetest"
+	"github.com/stretchr/testify/require"
+)
+
+// serve メッセージを順に処理して応答をidごとに返す
+func serve(t *testing.T, s *Server, messages ...string) map[string]map[string]any {
+	t.Helper()
+	var out bytes.Buffer
+	require.NoError(t, s.Serve(context.Background(), strings.NewReader(strings.Join(messages, "\n")), &out))
+	res := map[string]map[string]any{}
+	for line := range strings.Lines(out.String()) {
+		var msg map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &msg))
+		res[string(must(json.Marshal(msg["id"])))] = msg
+	}
+	return res
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func call(id int, name, args string) string {
+	return `{"jsonrpc":"2.0","id":` + strconv.Itoa(id) + `,"method":"tools/call","params":{"name":"` + name + `","arguments":` + args + `}}`
+}
+
+func toolNames(res map[string]any) []string {
+	var names []string
+	for _, tool := range res["result"].(map[string]any)["tools"].([]any) {
+		names = append(names, tool.(map[string]any)["name"].(string))
+	}
+	return names
+}
+
+func toolText(t *testing.T, res map[string]any) (string, bool) {
+	t.Helper()
+	require.Nil(t, res["error"])
+	result := res["result"].(map[string]any)
+	return result["content"].([]any)[0].(map[string]any)["text"].(string), result["isError"].(bool)
+}
+
+func TestServer_Protocol(t *testing.T) {
+	s := NewServer(objectstoragetest.NewFake(), nil)
+	res := serve(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":4,"method":"unknown"}`,
+		`not json`,
+	)
+	require.Len(t, res, 5)
+	require.Equal(t, "2025-03-26", res["1"]["result"].(map[string]any)["protocolVersion"])
+	require.Equal(t, map[string]any{}, res["2"]["result"])
+	require.Equal(t, float64(codeMethodNotFound), res["4"]["error"].(map[string]any)["code"])
+	require.Equal(t, float64(codeParseError), res["null"]["error"].(map[string]any)["code"])
+
+	// 書き込みのツールは既定では無効
+	names := toolNames(res["3"])
+	require.Contains(t, names, "list_buckets")
+	require.NotContains(t, names, "create_bucket")
+	res = serve(t, s, call(1, "create_bucket", `{"site_id":"isk01","bucket":"bucket1"}`))
+	require.Equal(t, float64(codeInvalidParams), res["1"]["error"].(map[string]any)["code"])
+}
+
+func TestServer_Schema(t *testing.T) {
+	s := NewServer(objectstoragetest.NewFake(), &Options{EnableWrite: true})
+	schemas := map[string]map[string]any{}
+	for _, tool := range s.Tools() {
+		schemas[tool.Name] = tool.InputSchema
+	}
+
+	require.Equal(t, []string{"bucket", "site_id"}, schemas["create_bucket"]["required"])
+	require.Contains(t, schemas["create_bucket"]["properties"], "plan")
+	require.Equal(t, []string{"site_id", "bucket", "from", "to"}, schemas["get_bucket_metering"]["required"])
+	require.Equal(t, "date-time", schemas["get_bucket_metering"]["properties"].(map[string]any)["from"].(map[string]any)["format"])
+
+	controls := schemas["grant_permission"]["properties"].(map[string]any)["bucket_controls"].(map[string]any)
+	require.Equal(t, "array", controls["type"])
+	item := controls["items"].(map[string]any)
+	require.Equal(t, map[string]any{"type": "boolean"}, item["properties"].(map[string]any)["can_read"])
+	require.Empty(t, item["required"])
+}
+
+func TestServer_Tools(t *testing.T) {
+	fake := objectstoragetest.NewFake()
+	s := NewServer(fake, &Options{EnableWrite: true})
+	res := serve(t, s,
+		call(1, "create_bucket", `{"site_id":"isk01","bucket":"bucket1"}`),
+		call(2, "grant_permission", `{"site_id":"isk01","display_name":"perm1","bucket_controls":[{"bucket_name":"bucket1","can_read":true,"can_write":false}]}`),
+		call(3, "list_buckets", `{"site_id":"isk01"}`),
+		call(4, "get_bucket_usage", `{"site_id":"isk01","bucket":"bucket1"}`),
+		call(5, "list_buckets", `{}`),
+		call(6, "list_buckets", `{"site_id":"isk01","unknown":1}`),
+		call(7, "get_bucket_usage", `{"site_id":"isk01","bucket":"missing"}`),
+	)
+	for _, id := range []string{"1", "2", "3", "4"} {
+		_, isError := toolText(t, res[id])
+		require.False(t, isError, id)
+	}
+	text, _ := toolText(t, res["3"])
+	require.Contains(t, text, `"name":"bucket1"`)
+
+	text, isError := toolText(t, res["5"])
+	require.True(t, isError)
+	require.Contains(t, text, `"site_id" is required`)
+	_, isError = toolText(t, res["6"])
+	require.True(t, isError)
+	_, isError = toolText(t, res["7"])
+	require.True(t, isError)
+
+	permissions, err := fake.Permissions("isk01")
+	require.NoError(t, err)
+	list, err := permissions.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.True(t, bool(list[0].BucketControls[0].CanRead.Value))
+	require.False(t, bool(list[0].BucketControls[0].CanWrite.Value))
+}
+
+func TestServer_Secrets(t *testing.T) {
+	fake := objectstoragetest.NewFake()
+	ctx := context.Background()
+	buckets, err := fake.Buckets("isk01")
+	require.NoError(t, err)
+	_, err = buckets.Create(ctx, &objectstorage.BucketCreateParams{SiteId: "isk01", Bucket: "bucket1"})
+	require.NoError(t, err)
+	permissions, err := fake.Permissions("isk01")
+	require.NoError(t, err)
+	permission, err := permissions.Create(ctx, "perm1", nil)
+	require.NoError(t, err)
+	id := strconv.FormatInt(int64(permission.ID.Value), 10)
+
+	args := json.RawMessage(`{"site_id":"isk01","permission_id":"` + id + `"}`)
+
+	// シークレットの受け渡し先がない場合はアクセスキーを作成するツールは無効
+	s := NewServer(fake, &Options{EnableWrite: true})
+	require.False(t, slices.ContainsFunc(s.Tools(), func(tool *Tool) bool { return tool.Name == "create_permission_key" }))
+	_, err = s.CallTool(ctx, "create_permission_key", args)
+	require.Error(t, err)
+
+	dir := t.TempDir()
+	s = NewServer(fake, &Options{EnableWrite: true, SecretSink: DirSecretSink(dir)})
+	result, err := s.CallTool(ctx, "create_permission_key", args)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.NotContains(t, result.Content[0].Text, `"secret"`)
+	var created createdKeyResult
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &created))
+	require.NotEmpty(t, created.ID)
+
+	// シークレットは受け渡し先のファイルにのみ書き込まれる
+	data, err := os.ReadFile(created.SecretLocation) //nolint:gosec
+	require.NoError(t, err)
+	var file secretFile
+	require.NoError(t, json.Unmarshal(data, &file))
+	require.Equal(t, created.ID, file.AccessKeyID)
+	require.NotEmpty(t, file.SecretAccessKey)
+	require.NotContains(t, result.Content[0].Text, file.SecretAccessKey)
+	info, err := os.Stat(created.SecretLocation)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	result, err = s.CallTool(ctx, "list_permission_keys", args)
+	require.NoError(t, err)
+	require.NotContains(t, result.Content[0].Text, file.SecretAccessKey)
+	require.Contains(t, result.Content[0].Text, created.ID)
+
+	// 受け渡しに失敗した場合は作成したキーを削除する
+	s = NewServer(fake, &Options{EnableWrite: true, SecretSink: func(context.Context, string, string, *v2.PermissionKeyData) (string, error) {
+		return "", errors.New("unavailable")
+	}})
+	result, err = s.CallTool(ctx, "create_permission_key", args)
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	keys, err := permissions.ListAccessKeys(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+
+	// 一覧や入れ子の値に含まれるシークレットも取り除く
+	text, err := redact([]v2.PermissionKeyData{{
+		ID:     v2.NewOptPermissionKeyID("key1"),
+		Secret: v2.NewOptPermissionSecret("secret1"),
+	}})
+	require.NoError(t, err)
+	require.NotContains(t, text, "secret1")
+	require.Contains(t, text, "key1")
+}
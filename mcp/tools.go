@@ -0,0 +1,151 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+)
+
+type siteParams struct {
+	SiteID string `json:"site_id" description:"ID of the site such as isk01. See list_sites"`
+}
+
+type bucketParams struct {
+	siteParams
+	Bucket string `json:"bucket" description:"name of the bucket"`
+}
+
+type meteringParams struct {
+	bucketParams
+	From time.Time `json:"from" description:"start of the period in RFC 3339"`
+	To   time.Time `json:"to" description:"end of the period in RFC 3339"`
+}
+
+type permissionParams struct {
+	siteParams
+	PermissionID string `json:"permission_id" description:"ID of the permission. See list_permissions"`
+}
+
+type grantParams struct {
+	siteParams
+	DisplayName    string            `json:"display_name" description:"display name of the permission"`
+	BucketControls v2.BucketControls `json:"bucket_controls" description:"buckets to grant access to and whether to allow reading and writing"`
+}
+
+// createdKeyResult create_permission_keyの結果。シークレットはOptions.SecretSinkに渡し、出力には含めない
+type createdKeyResult struct {
+	ID             string `json:"id"`
+	SecretLocation string `json:"secret_location"`
+}
+
+// tools サーバーが提供する全てのツール
+var tools = []*Tool{
+	newTool("list_sites", "List the object storage sites.", false,
+		func(ctx context.Context, backend objectstorage.Backend, p *struct{}) (any, error) {
+			api, err := backend.Sites("")
+			if err != nil {
+				return nil, err
+			}
+			return api.List(ctx)
+		}),
+	newTool("list_buckets", "List the buckets in a site.", false,
+		func(ctx context.Context, backend objectstorage.Backend, p *siteParams) (any, error) {
+			api, err := backend.Buckets(p.SiteID)
+			if err != nil {
+				return nil, err
+			}
+			return api.List(ctx)
+		}),
+	newTool("get_bucket_usage", "Get the number of objects and the amount of data stored in a bucket.", false,
+		func(ctx context.Context, backend objectstorage.Backend, p *bucketParams) (any, error) {
+			api, err := backend.BucketExtra(p.SiteID, p.Bucket)
+			if err != nil {
+				return nil, err
+			}
+			return api.ReadUsage(ctx)
+		}),
+	newTool("get_bucket_quota", "Get the quota of a bucket.", false,
+		func(ctx context.Context, backend objectstorage.Backend, p *bucketParams) (any, error) {
+			api, err := backend.BucketExtra(p.SiteID, p.Bucket)
+			if err != nil {
+				return nil, err
+			}
+			return api.ReadQuota(ctx)
+		}),
+	newTool("get_site_quota", "Get the quota of a site such as the maximum number of buckets and permissions.", false,
+		func(ctx context.Context, backend objectstorage.Backend, p *siteParams) (any, error) {
+			api, err := backend.SiteStatus(p.SiteID)
+			if err != nil {
+				return nil, err
+			}
+			return api.ReadQuota(ctx)
+		}),
+	newTool("get_bucket_metering", "Get the daily metering of a bucket in a period.", false,
+		func(ctx context.Context, backend objectstorage.Backend, p *meteringParams) (any, error) {
+			api, err := backend.SiteStatus(p.SiteID)
+			if err != nil {
+				return nil, err
+			}
+			return api.ReadBucketMetering(ctx, p.Bucket, p.From, p.To)
+		}),
+	newTool("list_permissions", "List the permissions in a site and the buckets they grant access to.", false,
+		func(ctx context.Context, backend objectstorage.Backend, p *siteParams) (any, error) {
+			api, err := backend.Permissions(p.SiteID)
+			if err != nil {
+				return nil, err
+			}
+			return api.List(ctx)
+		}),
+	newTool("list_permission_keys", "List the access keys of a permission. Secrets are not included.", false,
+		func(ctx context.Context, backend objectstorage.Backend, p *permissionParams) (any, error) {
+			api, err := backend.Permissions(p.SiteID)
+			if err != nil {
+				return nil, err
+			}
+			return api.ListAccessKeys(ctx, p.PermissionID)
+		}),
+
+	newTool("create_bucket", "Create a bucket in a site.", true,
+		func(ctx context.Context, backend objectstorage.Backend, p *objectstorage.BucketCreateParams) (any, error) {
+			api, err := backend.Buckets(p.SiteId)
+			if err != nil {
+				return nil, err
+			}
+			return api.Create(ctx, p)
+		}),
+	newTool("grant_permission", "Create a permission that grants access to buckets.", true,
+		func(ctx context.Context, backend objectstorage.Backend, p *grantParams) (any, error) {
+			api, err := backend.Permissions(p.SiteID)
+			if err != nil {
+				return nil, err
+			}
+			return api.Create(ctx, p.DisplayName, p.BucketControls)
+		}),
+	requireSecretSink(newServerTool("create_permission_key", "Create an access key of a permission. The secret is delivered to the location configured by the operator and withheld from the output.", true,
+		func(ctx context.Context, s *Server, p *permissionParams) (any, error) {
+			api, err := s.backend.Permissions(p.SiteID)
+			if err != nil {
+				return nil, err
+			}
+			key, err := api.CreateAccessKey(ctx, p.PermissionID)
+			if err != nil {
+				return nil, err
+			}
+			location, err := s.opts.SecretSink(ctx, p.SiteID, p.PermissionID, key)
+			if err != nil {
+				// シークレットを受け渡せなかったキーは利用できないため削除する
+				return nil, errors.Join(
+					fmt.Errorf("failed to deliver the secret of access key %s: %w", key.ID.Value, err),
+					api.DeleteAccessKey(context.WithoutCancel(ctx), p.PermissionID, string(key.ID.Value)),
+				)
+			}
+			return &createdKeyResult{ID: string(key.ID.Value), SecretLocation: location}, nil
+		})),
+}
@@ -0,0 +1,143 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package notifier
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ContentType CloudEventsのstructured modeのContent-Type
+	ContentType = "application/cloudevents+json"
+	// SignatureHeader 署名のヘッダ。"sha256="に続けてHMAC-SHA256の16進表記を設定する
+	SignatureHeader = "X-Signature-256"
+	// TimestampHeader 署名した時刻のUNIX時間(秒)のヘッダ
+	TimestampHeader = "X-Signature-Timestamp"
+	// DefaultTolerance Receiverが許容する署名の時刻のずれのデフォルト値
+	DefaultTolerance = 5 * time.Minute
+)
+
+// CloudEvent CloudEvents 1.0のイベント
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+}
+
+// NewCloudEvent dataをJSONに変換したCloudEventを作成する
+func NewCloudEvent(source, eventType, subject string, t time.Time, data any) (*CloudEvent, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	return &CloudEvent{
+		SpecVersion:     "1.0",
+		ID:              hex.EncodeToString(id),
+		Source:          source,
+		Type:            eventType,
+		Subject:         subject,
+		Time:            t.UTC(),
+		DataContentType: "application/json",
+		Data:            b,
+	}, nil
+}
+
+// Sign 署名した時刻と本文のHMAC-SHA256をSignatureHeaderの形式で返す
+func Sign(secret []byte, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10))) //nolint:errcheck
+	mac.Write([]byte("."))                                     //nolint:errcheck
+	mac.Write(body)                                            //nolint:errcheck
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify ヘッダの署名を検証する。署名の時刻がnowからtolerance以上ずれている場合もエラーとする
+func Verify(secret []byte, header http.Header, body []byte, now time.Time, tolerance time.Duration) error {
+	unix, err := strconv.ParseInt(header.Get(TimestampHeader), 10, 64)
+	if err != nil {
+		return errors.New("invalid signature timestamp")
+	}
+	timestamp := time.Unix(unix, 0)
+	if d := now.Sub(timestamp); d > tolerance || d < -tolerance {
+		return errors.New("signature timestamp is out of tolerance")
+	}
+	signature := header.Get(SignatureHeader)
+	if !strings.HasPrefix(signature, "sha256=") || !hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body))) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// Receiver 署名を検証してCloudEventを受け取るhttp.Handler。動作確認や受信側の実装に利用する
+type Receiver struct {
+	Secret []byte
+	// Tolerance 許容する署名の時刻のずれ。0以下の場合はDefaultTolerance
+	Tolerance time.Duration
+	// Now 現在時刻を返す関数。nilの場合はtime.Now
+	Now func() time.Time
+	// Handle 受け取ったイベントを処理する関数。エラーの場合は500を返し、送信元に再送させる
+	Handle func(ctx context.Context, event *CloudEvent) error
+}
+
+var _ http.Handler = (*Receiver)(nil)
+
+const maxEventSize = 1 << 20
+
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxEventSize+1))
+	if err != nil || len(body) > maxEventSize {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+
+	now, tolerance := time.Now, r.Tolerance
+	if r.Now != nil {
+		now = r.Now
+	}
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+	if err := Verify(r.Secret, req.Header, body, now(), tolerance); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var event CloudEvent
+	if err := json.Unmarshal(body, &event); err != nil || event.SpecVersion != "1.0" || event.ID == "" || event.Type == "" {
+		http.Error(w, "invalid cloud event", http.StatusBadRequest)
+		return
+	}
+	if r.Handle != nil {
+		if err := r.Handle(req.Context(), &event); err != nil {
+			http.Error(w, fmt.Sprintf("failed to handle event: %s", err), http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
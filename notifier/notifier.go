@@ -0,0 +1,392 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package notifier バケットの使用量や制限、サイトの状態を監視し、しきい値を超えた場合にCloudEventsで通知する
+//
+// 通知は条件を満たした時点で一度送信し、条件を満たし続ける間はOptions.ResendIntervalごとに再送する。
+// 条件を満たさなくなった場合は解消の通知を送信する
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+)
+
+const (
+	// DefaultSource CloudEventのsourceのデフォルト値
+	DefaultSource = "/object-storage-api-go/notifier"
+	// DefaultResendInterval 条件を満たし続けている場合の再送の間隔のデフォルト値
+	DefaultResendInterval = time.Hour
+
+	// TypeAlertFiring 条件を満たしたことを表すCloudEventのtype
+	TypeAlertFiring = "jp.sacloud.objectstorage.alert.firing"
+	// TypeAlertResolved 条件を満たさなくなったことを表すCloudEventのtype
+	TypeAlertResolved = "jp.sacloud.objectstorage.alert.resolved"
+	// typeEventPrefix Watcherのイベントを送信する場合のCloudEventのtypeのプレフィックス
+	typeEventPrefix = "jp.sacloud.objectstorage."
+)
+
+// Condition 通知する条件
+type Condition string
+
+const (
+	// ConditionBucketUsage バケットの使用容量が上限に対してThresholds.UsageRatioを超えた
+	ConditionBucketUsage Condition = "bucket_usage"
+	// ConditionBucketObjects バケットのオブジェクト数が上限に対してThresholds.ObjectsRatioを超えた
+	ConditionBucketObjects Condition = "bucket_objects"
+	// ConditionBucketPenalty バケットにペナルティが適用された
+	ConditionBucketPenalty Condition = "bucket_penalty"
+	// ConditionSiteNotAccepting サイトが新規の受け付けを停止した
+	ConditionSiteNotAccepting Condition = "site_not_accepting"
+)
+
+// Thresholds 通知する条件のしきい値
+type Thresholds struct {
+	// UsageRatio 使用容量の上限に対する割合。0以下の場合は通知しない
+	UsageRatio float64
+	// ObjectsRatio オブジェクト数の上限に対する割合。0以下の場合は通知しない
+	ObjectsRatio float64
+	// Penalty trueの場合はペナルティの適用を通知する
+	Penalty bool
+	// NotAccepting trueの場合はサイトの新規の受け付けの停止を通知する
+	NotAccepting bool
+}
+
+// DefaultThresholds Options.Thresholdsを指定しない場合のしきい値
+var DefaultThresholds = Thresholds{
+	UsageRatio:   0.8,
+	ObjectsRatio: 0.8,
+	Penalty:      true,
+	NotAccepting: true,
+}
+
+// Options Newのオプション
+type Options struct {
+	// SiteIDs 対象のサイトのID。空の場合はSiteAPI.Listで取得した全てのサイト
+	SiteIDs []string
+	// Thresholds nilの場合はDefaultThresholds
+	Thresholds *Thresholds
+	// Source CloudEventのsource。空の場合はDefaultSource
+	Source string
+	// ResendInterval 条件を満たし続けている場合の再送の間隔。0の場合はDefaultResendInterval、負の値の場合は再送しない
+	ResendInterval time.Duration
+	// Now 現在時刻を返す関数。nilの場合はtime.Now
+	Now func() time.Time
+}
+
+// Alert 通知する条件を満たした対象。CloudEventのdataとして送信する
+type Alert struct {
+	Condition Condition `json:"condition"`
+	SiteID    string    `json:"site_id"`
+	Bucket    string    `json:"bucket,omitempty"`
+	// Value 使用量などの現在の値。ペナルティなど値のない条件の場合は0
+	Value float64 `json:"value"`
+	// Limit 上限の値。上限のない条件の場合は0
+	Limit   float64 `json:"limit"`
+	Message string  `json:"message"`
+	// Since 最初に条件を満たしたことを検出した時刻
+	Since time.Time `json:"since"`
+}
+
+func (a *Alert) key() string {
+	return string(a.Condition) + "/" + a.SiteID + "/" + a.Bucket
+}
+
+func (a *Alert) subject() string {
+	if a.Bucket == "" {
+		return "sites/" + a.SiteID
+	}
+	return "sites/" + a.SiteID + "/buckets/" + a.Bucket
+}
+
+type alertState struct {
+	alert Alert
+	// sent 最後に通知を送信した時刻。送信に失敗した場合はゼロ値
+	sent time.Time
+}
+
+// Notifier しきい値を評価し、状態の変化をSenderに送信する
+type Notifier struct {
+	backend    objectstorage.Backend
+	sender     Sender
+	opts       Options
+	thresholds Thresholds
+
+	// checkMu 同じ通知を重複して送信しないようCheckを直列化する
+	checkMu sync.Mutex
+	mu      sync.Mutex
+	active  map[string]*alertState
+}
+
+// notification Checkで送信する通知
+type notification struct {
+	key       string
+	eventType string
+	alert     Alert
+}
+
+// New Notifierを作成する
+func New(backend objectstorage.Backend, sender Sender, opts *Options) *Notifier {
+	n := &Notifier{backend: backend, sender: sender, thresholds: DefaultThresholds, active: map[string]*alertState{}}
+	if opts != nil {
+		n.opts = *opts
+	}
+	if n.opts.Thresholds != nil {
+		n.thresholds = *n.opts.Thresholds
+	}
+	if n.opts.Source == "" {
+		n.opts.Source = DefaultSource
+	}
+	if n.opts.ResendInterval == 0 {
+		n.opts.ResendInterval = DefaultResendInterval
+	}
+	if n.opts.Now == nil {
+		n.opts.Now = time.Now
+	}
+	return n
+}
+
+// Active 条件を満たしている対象を返す
+func (n *Notifier) Active() []Alert {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var res []Alert
+	for _, key := range slices.Sorted(maps.Keys(n.active)) {
+		res = append(res, n.active[key].alert)
+	}
+	return res
+}
+
+// Run intervalごとにCheckを行う。ctxがキャンセルされるまで戻らない
+func (n *Notifier) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := n.Check(ctx); err != nil && onError != nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check 現在の値を取得してしきい値を評価し、必要な通知を送信する
+//
+// 取得に失敗した対象は前回の状態を維持し、解消の通知は送信しない。
+// 送信に失敗した通知は次のCheckで再送する
+func (n *Notifier) Check(ctx context.Context) error {
+	n.checkMu.Lock()
+	defer n.checkMu.Unlock()
+	now := n.opts.Now()
+	ev, err := n.evaluate(ctx)
+	errs := []error{err}
+
+	// 送信の間はActiveなどを妨げないよう、ロックを解放して送信する
+	notifications := n.prepare(ev, now)
+	sent := make([]bool, len(notifications))
+	for i, notification := range notifications {
+		if err := n.send(ctx, notification.eventType, notification.alert.subject(), now, notification.alert); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		sent[i] = true
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for i, notification := range notifications {
+		if !sent[i] {
+			continue
+		}
+		switch notification.eventType {
+		case TypeAlertFiring:
+			if state, ok := n.active[notification.key]; ok {
+				state.sent = now
+			}
+		case TypeAlertResolved:
+			delete(n.active, notification.key)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// prepare 評価の結果で状態を更新し、送信する通知を返す。通知していない対象の解消は送信せずに削除する
+func (n *Notifier) prepare(ev *evaluation, now time.Time) []notification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var res []notification
+	for _, key := range slices.Sorted(maps.Keys(ev.firing)) {
+		alert := ev.firing[key]
+		state, ok := n.active[key]
+		if !ok {
+			alert.Since = now
+			state = &alertState{alert: alert}
+			n.active[key] = state
+		} else {
+			alert.Since = state.alert.Since
+			state.alert = alert
+		}
+		if !state.sent.IsZero() && (n.opts.ResendInterval < 0 || now.Sub(state.sent) < n.opts.ResendInterval) {
+			continue
+		}
+		res = append(res, notification{key: key, eventType: TypeAlertFiring, alert: state.alert})
+	}
+	for _, key := range slices.Sorted(maps.Keys(n.active)) {
+		state := n.active[key]
+		if !ev.resolved(&state.alert) {
+			continue
+		}
+		if state.sent.IsZero() {
+			delete(n.active, key)
+			continue
+		}
+		// 通知済みの場合のみ解消を通知する
+		res = append(res, notification{key: key, eventType: TypeAlertResolved, alert: state.alert})
+	}
+	return res
+}
+
+// NotifyEvent Watcherのイベントを送信する。typeはイベントの種類の前に"jp.sacloud.objectstorage."を付けたもの
+func (n *Notifier) NotifyEvent(ctx context.Context, event objectstorage.Event) error {
+	return n.send(ctx, typeEventPrefix+string(event.EventType()), "sites/"+event.EventSiteID(), event.EventTime(), event)
+}
+
+func (n *Notifier) send(ctx context.Context, eventType, subject string, t time.Time, data any) error {
+	event, err := NewCloudEvent(n.opts.Source, eventType, subject, t, data)
+	if err != nil {
+		return err
+	}
+	return n.sender.Send(ctx, event)
+}
+
+// evaluation しきい値の評価の結果
+type evaluation struct {
+	// firing 条件を満たしている対象
+	firing map[string]Alert
+	// evaluated 値を取得して評価できた対象のキー
+	evaluated map[string]bool
+	// listed 全てのサイトのバケットの一覧を取得できた場合はtrue
+	listed bool
+	// buckets 一覧に含まれていたバケット
+	buckets map[string]bool
+}
+
+func (ev *evaluation) check(alert Alert, ok bool) {
+	ev.evaluated[alert.key()] = true
+	if ok {
+		ev.firing[alert.key()] = alert
+	}
+}
+
+// resolved 条件を満たしていた対象が評価の結果条件を満たさなくなった、もしくは削除された場合にtrueを返す
+func (ev *evaluation) resolved(alert *Alert) bool {
+	if _, ok := ev.firing[alert.key()]; ok {
+		return false
+	}
+	if ev.evaluated[alert.key()] {
+		return true
+	}
+	return alert.Bucket != "" && ev.listed && !ev.buckets[alert.SiteID+"/"+alert.Bucket]
+}
+
+// evaluate 現在の値を取得してしきい値を評価する
+func (n *Notifier) evaluate(ctx context.Context) (*evaluation, error) {
+	ev := &evaluation{firing: map[string]Alert{}, evaluated: map[string]bool{}, buckets: map[string]bool{}}
+
+	siteIds, err := n.sites(ctx)
+	if err != nil {
+		return ev, err
+	}
+	var errs []error
+	if n.thresholds.NotAccepting {
+		for _, siteId := range siteIds {
+			status, err := n.readStatus(ctx, siteId)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to read status of site %s: %w", siteId, err))
+				continue
+			}
+			ev.check(Alert{
+				Condition: ConditionSiteNotAccepting,
+				SiteID:    siteId,
+				Message:   fmt.Sprintf("site %s is not accepting new requests: %s", siteId, status.Message.Value),
+			}, !status.AcceptNew.Or(true))
+		}
+	}
+
+	details, err := objectstorage.ListDetailed(ctx, n.backend, &objectstorage.ListDetailedOptions{
+		SiteIDs: siteIds,
+		Fields:  []objectstorage.BucketDetailField{objectstorage.BucketDetailUsage, objectstorage.BucketDetailQuota, objectstorage.BucketDetailPenalty},
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+	ev.listed = err == nil
+	for _, detail := range details {
+		bucket := string(detail.Bucket.Name)
+		ev.buckets[detail.SiteID+"/"+bucket] = true
+		for field, err := range detail.Errors {
+			errs = append(errs, fmt.Errorf("failed to read %s of bucket %s: %w", field, bucket, err))
+		}
+		if detail.Usage != nil && detail.Quota != nil {
+			if ratio := n.thresholds.UsageRatio; ratio > 0 {
+				used := float64(detail.Usage.AmountGibPerBucket.Value)
+				limit := float64(detail.Quota.AmountGibPerBucket.Value)
+				ev.check(Alert{
+					Condition: ConditionBucketUsage,
+					SiteID:    detail.SiteID,
+					Bucket:    bucket,
+					Value:     used,
+					Limit:     limit,
+					Message:   fmt.Sprintf("bucket %s uses %.2f GiB of %.2f GiB", bucket, used, limit),
+				}, limit > 0 && used > limit*ratio)
+			}
+			if ratio := n.thresholds.ObjectsRatio; ratio > 0 {
+				used := float64(detail.Usage.NumObjectsPerBucket.Value)
+				limit := float64(detail.Quota.NumObjectsPerBucket.Value)
+				ev.check(Alert{
+					Condition: ConditionBucketObjects,
+					SiteID:    detail.SiteID,
+					Bucket:    bucket,
+					Value:     used,
+					Limit:     limit,
+					Message:   fmt.Sprintf("bucket %s has %.0f objects of %.0f", bucket, used, limit),
+				}, limit > 0 && used > limit*ratio)
+			}
+		}
+		if detail.Penalty != nil && n.thresholds.Penalty {
+			ev.check(Alert{
+				Condition: ConditionBucketPenalty,
+				SiteID:    detail.SiteID,
+				Bucket:    bucket,
+				Message:   fmt.Sprintf("penalty is applied to bucket %s", bucket),
+			}, detail.Penalty.AmountGibPerBucket.Value.IsApplied.Value || detail.Penalty.NumObjectsPerBucket.Value.IsApplied.Value)
+		}
+	}
+	return ev, errors.Join(errs...)
+}
+
+func (n *Notifier) sites(ctx context.Context) ([]string, error) {
+	if len(n.opts.SiteIDs) > 0 {
+		return n.opts.SiteIDs, nil
+	}
+	return objectstorage.ListSiteIDs(ctx, n.backend)
+}
+
+func (n *Notifier) readStatus(ctx context.Context, siteId string) (*v2.StatusData, error) {
+	api, err := n.backend.SiteStatus(siteId)
+	if err != nil {
+		return nil, err
+	}
+	return api.Read(ctx)
+}
@@ -0,0 +1,270 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package notifier_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/sacloud/object-storage-api-go/notifier"
+	"github.com/sacloud/object-storage-api-go/objectstoragetest"
+	"github.com/stretchr/testify/require"
+)
+
+var testSecret = []byte("secret")
+
+// testReceiver Receiverで受け取ったイベントを記録するテスト用の受信先
+type testReceiver struct {
+	server *httptest.Server
+
+	mu     sync.Mutex
+	events []*notifier.CloudEvent
+}
+
+func newTestReceiver(t *testing.T) *testReceiver {
+	t.Helper()
+	r := &testReceiver{}
+	r.server = httptest.NewServer(&notifier.Receiver{
+		Secret: testSecret,
+		Handle: func(ctx context.Context, event *notifier.CloudEvent) error {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.events = append(r.events, event)
+			return nil
+		},
+	})
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+func (r *testReceiver) webhook() *notifier.Webhook {
+	return &notifier.Webhook{URL: r.server.URL, Secret: testSecret, Backoff: time.Millisecond}
+}
+
+// take 受け取ったイベントのtypeとsubjectを返し、記録を消去する
+func (r *testReceiver) take() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var res []string
+	for _, event := range r.events {
+		res = append(res, event.Type+" "+event.Subject)
+	}
+	r.events = nil
+	return res
+}
+
+func TestNotifier_Check(t *testing.T) {
+	ctx := context.Background()
+	fake := objectstoragetest.NewFake()
+	buckets, err := fake.Buckets("isk01")
+	require.NoError(t, err)
+	_, err = buckets.Create(ctx, &objectstorage.BucketCreateParams{Bucket: "bucket1"})
+	require.NoError(t, err)
+	require.NoError(t, fake.SetBucketQuota("isk01", "bucket1", v2.BucketQuotaData{
+		NumObjectsPerBucket: v2.NewOptInt(1000),
+		AmountGibPerBucket:  v2.NewOptFloat32(10),
+	}))
+
+	receiver := newTestReceiver(t)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	n := notifier.New(fake, receiver.webhook(), &notifier.Options{
+		SiteIDs:        []string{"isk01"},
+		ResendInterval: time.Hour,
+		Now:            func() time.Time { return now },
+	})
+
+	require.NoError(t, n.Check(ctx))
+	require.Empty(t, receiver.take())
+
+	require.NoError(t, fake.SetBucketUsage("isk01", "bucket1", v2.BucketUsageData{
+		NumObjectsPerBucket: v2.NewOptInt(10),
+		AmountGibPerBucket:  v2.NewOptFloat32(9),
+	}))
+	require.NoError(t, n.Check(ctx))
+	require.Equal(t, []string{notifier.TypeAlertFiring + " sites/isk01/buckets/bucket1"}, receiver.take())
+	alerts := n.Active()
+	require.Len(t, alerts, 1)
+	require.Equal(t, notifier.ConditionBucketUsage, alerts[0].Condition)
+	require.Equal(t, float64(9), alerts[0].Value)
+	require.Equal(t, float64(10), alerts[0].Limit)
+
+	// 再送の間隔が経過するまでは送信しない
+	now = now.Add(30 * time.Minute)
+	require.NoError(t, n.Check(ctx))
+	require.Empty(t, receiver.take())
+	now = now.Add(30 * time.Minute)
+	require.NoError(t, n.Check(ctx))
+	require.Equal(t, []string{notifier.TypeAlertFiring + " sites/isk01/buckets/bucket1"}, receiver.take())
+
+	require.NoError(t, fake.SetBucketUsage("isk01", "bucket1", v2.BucketUsageData{
+		NumObjectsPerBucket: v2.NewOptInt(10),
+		AmountGibPerBucket:  v2.NewOptFloat32(1),
+	}))
+	require.NoError(t, fake.SetBucketPenalty("isk01", "bucket1", v2.BucketPenaltyData{
+		AmountGibPerBucket: v2.NewOptBucketPenaltyDataAmountGibPerBucket(v2.BucketPenaltyDataAmountGibPerBucket{IsApplied: v2.NewOptBool(true)}),
+	}))
+	require.NoError(t, fake.SetSiteStatus("isk01", v2.StatusData{AcceptNew: v2.NewOptBool(false), Message: v2.NewOptString("maintenance")}))
+	require.NoError(t, n.Check(ctx))
+	require.Equal(t, []string{
+		notifier.TypeAlertFiring + " sites/isk01/buckets/bucket1",
+		notifier.TypeAlertFiring + " sites/isk01",
+		notifier.TypeAlertResolved + " sites/isk01/buckets/bucket1",
+	}, receiver.take())
+}
+
+func TestNotifier_CheckError(t *testing.T) {
+	ctx := context.Background()
+	fake := objectstoragetest.NewFake()
+	require.NoError(t, fake.SetSiteStatus("isk01", v2.StatusData{AcceptNew: v2.NewOptBool(false)}))
+	receiver := newTestReceiver(t)
+	n := notifier.New(fake, receiver.webhook(), &notifier.Options{SiteIDs: []string{"isk01"}})
+	require.NoError(t, n.Check(ctx))
+	require.Len(t, receiver.take(), 1)
+
+	// 取得に失敗した場合は解消として扱わない
+	fake.FailOn("SiteStatus.Read", errors.New("unavailable"))
+	require.Error(t, n.Check(ctx))
+	require.Empty(t, receiver.take())
+	require.Len(t, n.Active(), 1)
+
+	// 送信に失敗した通知は次のCheckで送信する
+	fake.ClearHooks()
+	require.NoError(t, fake.SetSiteStatus("isk01", v2.StatusData{AcceptNew: v2.NewOptBool(true)}))
+	receiver.server.Close()
+	require.Error(t, n.Check(ctx))
+	require.Len(t, n.Active(), 1)
+}
+
+// senderFunc 関数をSenderとして用いる
+type senderFunc func(ctx context.Context, event *notifier.CloudEvent) error
+
+func (f senderFunc) Send(ctx context.Context, event *notifier.CloudEvent) error {
+	return f(ctx, event)
+}
+
+func TestNotifier_CheckUnlockedSend(t *testing.T) {
+	ctx := context.Background()
+	fake := objectstoragetest.NewFake()
+	require.NoError(t, fake.SetSiteStatus("isk01", v2.StatusData{AcceptNew: v2.NewOptBool(false)}))
+
+	// 送信中もActiveを参照でき、送信前の状態を返す
+	var n *notifier.Notifier
+	var active []notifier.Alert
+	n = notifier.New(fake, senderFunc(func(ctx context.Context, event *notifier.CloudEvent) error {
+		active = n.Active()
+		return nil
+	}), &notifier.Options{SiteIDs: []string{"isk01"}})
+	done := make(chan error)
+	go func() { done <- n.Check(ctx) }()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Check did not return while the sender read the active alerts")
+	}
+	require.Len(t, active, 1)
+
+	// 解消の通知を送信するまでは通知中のままとなる
+	require.NoError(t, fake.SetSiteStatus("isk01", v2.StatusData{AcceptNew: v2.NewOptBool(true)}))
+	go func() { done <- n.Check(ctx) }()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Check did not return while the sender read the active alerts")
+	}
+	require.Len(t, active, 1)
+	require.Empty(t, n.Active())
+}
+
+func TestNotifier_NotifyEvent(t *testing.T) {
+	receiver := newTestReceiver(t)
+	n := notifier.New(objectstoragetest.NewFake(), receiver.webhook(), nil)
+	event := &objectstorage.BucketCreated{
+		EventHeader: objectstorage.EventHeader{Type: objectstorage.EventBucketCreated, SiteID: "isk01", Time: time.Now()},
+		Bucket:      "bucket1",
+	}
+	require.NoError(t, n.NotifyEvent(context.Background(), event))
+
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+	require.Len(t, receiver.events, 1)
+	require.Equal(t, "jp.sacloud.objectstorage.BucketCreated", receiver.events[0].Type)
+	require.Equal(t, notifier.DefaultSource, receiver.events[0].Source)
+	var data objectstorage.BucketCreated
+	require.NoError(t, json.Unmarshal(receiver.events[0].Data, &data))
+	require.Equal(t, "bucket1", data.Bucket)
+}
+
+func TestWebhook_Retry(t *testing.T) {
+	var mu sync.Mutex
+	var attempts int
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(status)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	event, err := notifier.NewCloudEvent("test", "test.event", "", time.Now(), map[string]string{})
+	require.NoError(t, err)
+	webhook := &notifier.Webhook{URL: server.URL, Backoff: time.Millisecond}
+	require.NoError(t, webhook.Send(context.Background(), event))
+	require.Equal(t, 3, attempts)
+
+	// 再送しても成功しないステータスは再送しない
+	attempts, status = 0, http.StatusBadRequest
+	err = webhook.Send(context.Background(), event)
+	var e *notifier.WebhookError
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, http.StatusBadRequest, e.StatusCode)
+	require.Equal(t, 1, attempts)
+
+	attempts, status = 0, http.StatusInternalServerError
+	webhook.MaxAttempts = 2
+	require.Error(t, webhook.Send(context.Background(), event))
+	require.Equal(t, 2, attempts)
+}
+
+func TestReceiver_Verify(t *testing.T) {
+	receiver := newTestReceiver(t)
+	event, err := notifier.NewCloudEvent("test", "test.event", "", time.Now(), nil)
+	require.NoError(t, err)
+	body, err := json.Marshal(event)
+	require.NoError(t, err)
+
+	post := func(timestamp time.Time, signature string) int {
+		req, err := http.NewRequest(http.MethodPost, receiver.server.URL, bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set(notifier.TimestampHeader, strconv.FormatInt(timestamp.Unix(), 10))
+		req.Header.Set(notifier.SignatureHeader, signature)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		res.Body.Close() //nolint:errcheck
+		return res.StatusCode
+	}
+
+	now := time.Now()
+	require.Equal(t, http.StatusNoContent, post(now, notifier.Sign(testSecret, now, body)))
+	require.Equal(t, http.StatusUnauthorized, post(now, notifier.Sign([]byte("other"), now, body)))
+	old := now.Add(-time.Hour)
+	require.Equal(t, http.StatusUnauthorized, post(old, notifier.Sign(testSecret, old, body)))
+	require.Len(t, receiver.take(), 1)
+}
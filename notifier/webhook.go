@@ -0,0 +1,136 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultMaxAttempts Webhookの送信の試行回数のデフォルト値
+	DefaultMaxAttempts = 5
+	// DefaultBackoff Webhookの再送までの待ち時間の初期値のデフォルト値。再送ごとに2倍にする
+	DefaultBackoff = time.Second
+	// maxBackoff 再送までの待ち時間の上限
+	maxBackoff = time.Minute
+)
+
+// Sender CloudEventの送信先
+type Sender interface {
+	Send(ctx context.Context, event *CloudEvent) error
+}
+
+// Webhook CloudEventを署名付きでHTTPでPOSTするSender
+//
+// 通信エラー、429、5xxの場合は待ち時間を倍にしながら再送する。それ以外の4xxは再送しない
+type Webhook struct {
+	URL string
+	// Secret 署名の鍵。空の場合は署名しない
+	Secret []byte
+	// Client nilの場合はhttp.DefaultClient
+	Client *http.Client
+	// MaxAttempts 試行回数。0以下の場合はDefaultMaxAttempts
+	MaxAttempts int
+	// Backoff 再送までの待ち時間の初期値。0以下の場合はDefaultBackoff
+	Backoff time.Duration
+	// Now 署名の時刻を返す関数。nilの場合はtime.Now
+	Now func() time.Time
+}
+
+var _ Sender = (*Webhook)(nil)
+
+// WebhookError Webhookの送信先が成功以外のステータスを返した
+type WebhookError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *WebhookError) Error() string {
+	return fmt.Sprintf("webhook responded with status %d: %s", e.StatusCode, e.Body)
+}
+
+func (e *WebhookError) retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// Send イベントを送信する。全ての試行に失敗した場合は最後のエラーを返す
+func (w *Webhook) Send(ctx context.Context, event *CloudEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	attempts, backoff := w.MaxAttempts, w.Backoff
+	if attempts <= 0 {
+		attempts = DefaultMaxAttempts
+	}
+	if backoff <= 0 {
+		backoff = DefaultBackoff
+	}
+
+	for attempt := 1; ; attempt++ {
+		wait, err := w.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		var e *WebhookError
+		if (errors.As(err, &e) && !e.retryable()) || attempt >= attempts || ctx.Err() != nil {
+			return fmt.Errorf("failed to send event %s: %w", event.ID, err)
+		}
+
+		timer := time.NewTimer(max(wait, backoff))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("failed to send event %s: %w", event.ID, ctx.Err())
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// post 一度送信する。送信先がRetry-Afterを返した場合はその時間を返す
+func (w *Webhook) post(ctx context.Context, body []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", ContentType)
+	if len(w.Secret) > 0 {
+		now := time.Now
+		if w.Now != nil {
+			now = w.Now
+		}
+		timestamp := now()
+		req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp.Unix(), 10))
+		req.Header.Set(SignatureHeader, Sign(w.Secret, timestamp, body))
+	}
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close() //nolint:errcheck
+	msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return 0, nil
+	}
+
+	var wait time.Duration
+	if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		wait = min(time.Duration(seconds)*time.Second, maxBackoff)
+	}
+	return wait, &WebhookError{StatusCode: res.StatusCode, Body: string(bytes.TrimSpace(msg))}
+}
@@ -0,0 +1,356 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package objectstoragetest objectstorageパッケージを利用するコードのテストのためのユーティリティ
+//
+// HTTPを用いずに各APIのインターフェースを実装するインメモリのフェイクを提供する
+package objectstoragetest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+)
+
+// DefaultSiteQuota フェイクのサイトの制限値のデフォルト値
+var DefaultSiteQuota = v2.QuotaData{
+	NumRootKeys:             v2.NewOptInt(2),
+	NumBuckets:              v2.NewOptInt(100),
+	NumPermissions:          v2.NewOptInt(100),
+	NumKeysPerPermission:    v2.NewOptInt(2),
+	NumBucketsPerPermission: v2.NewOptInt(50),
+	NumObjectsPerBucket:     v2.NewOptInt(10_000_000),
+	AmountGibPerBucket:      v2.NewOptFloat32(1024),
+}
+
+// FakeOption NewFakeのオプション
+type FakeOption func(*Fake)
+
+// WithSites フェイクのサイトを指定する。デフォルトはisk01とtky01
+func WithSites(sites ...v2.ModelCluster) FakeOption {
+	return func(f *Fake) {
+		f.sites = nil
+		for _, site := range sites {
+			f.addSite(site)
+		}
+	}
+}
+
+// WithClock 作成日時などに用いる現在時刻を返す関数を指定する。デフォルトはtime.Now
+func WithClock(now func() time.Time) FakeOption {
+	return func(f *Fake) { f.now = now }
+}
+
+var _ objectstorage.Backend = (*Fake)(nil)
+
+// Fake objectstorage.Backendのインメモリの実装
+//
+// Fakeが返す全てのAPIは1つの状態を共有する。例えばBucketAPIで作成したバケットは
+// BucketExtraAPIやPermissionsAPIからも参照でき、削除後はPermissionsAPIでの指定がエラーとなる。
+// エラーは実際のラッパーと同様にobjectstorage.NewAPIErrorで生成され、ステータスコードはAPIの定義に従う
+type Fake struct {
+	mu  sync.Mutex
+	now func() time.Time
+
+	sites   []*fakeSite
+	buckets []*fakeBucket
+	seq     int64
+
+	calls []objectstorage.Call
+	hooks []func(call *objectstorage.Call) error
+}
+
+type fakeSite struct {
+	cluster     v2.ModelCluster
+	status      v2.StatusData
+	quota       v2.QuotaData
+	account     *v2.AccountData
+	accountKeys []*v2.AccountKeyData
+	permissions []*fakePermission
+}
+
+type fakeBucket struct {
+	siteId      string
+	name        string
+	resourceId  string
+	plan        v2.ModelBucketPlan
+	usage       v2.BucketUsageData
+	quota       *v2.BucketQuotaData
+	penalty     v2.BucketPenaltyData
+	encryption  *v2.HandlerEncryptionConfigRes
+	replication *v2.ModelReplication
+	metering    []v2.BucketBillingItem
+}
+
+type fakePermission struct {
+	data v2.PermissionData
+	keys []*v2.PermissionKeyData
+}
+
+// NewFake Fakeを生成する。各サイトのサイトアカウントは作成済みの状態となる
+func NewFake(opts ...FakeOption) *Fake {
+	f := &Fake{now: time.Now}
+	for _, site := range []string{"isk01", "tky01"} {
+		f.addSite(v2.ModelCluster{
+			ID:              v2.NewOptString(site),
+			DisplayName:     v2.NewOptString(site),
+			DisplayNameEnUs: v2.NewOptString(site),
+			DisplayNameJa:   v2.NewOptString(site),
+			EndpointBase:    v2.NewOptString(site + ".sakurastorage.jp"),
+			S3Endpoint:      v2.NewOptString("s3." + site + ".sakurastorage.jp"),
+			IamEndpoint:     v2.NewOptString("iam." + site + ".sakurastorage.jp"),
+			Region:          v2.NewOptString("jp-north-1"),
+			PlanFamily:      v2.NewOptModelClusterPlanFamily(v2.ModelClusterPlanFamilyStandard),
+		})
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	for _, site := range f.sites {
+		f.seq++
+		site.account = &v2.AccountData{
+			ResourceID: v2.NewOptResourceID(v2.ResourceID(fmt.Sprintf("1100000%05d", f.seq))),
+			Code:       v2.NewOptCode(v2.Code(fmt.Sprintf("fake%06d", f.seq))),
+			CreatedAt:  v2.NewOptCreatedAt(v2.CreatedAt(f.now())),
+		}
+	}
+	return f
+}
+
+func (f *Fake) addSite(cluster v2.ModelCluster) {
+	f.sites = append(f.sites, &fakeSite{
+		cluster: cluster,
+		status: v2.StatusData{
+			AcceptNew:  v2.NewOptBool(true),
+			StatusCode: v2.NewOptStatusDataStatusCode(v2.StatusDataStatusCode{ID: v2.NewOptInt(1), Status: v2.NewOptString("ok")}),
+		},
+		quota: DefaultSiteQuota,
+	})
+}
+
+// OnCall 各メソッドの呼び出し時に呼ばれるフックを追加する
+//
+// フックがエラーを返した場合、状態を変更せずにそのエラーを返す。特定のメソッドを失敗させる場合はFailOnを用いる
+func (f *Fake) OnCall(hook func(call *objectstorage.Call) error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.hooks = append(f.hooks, hook)
+}
+
+// FailOn methodの呼び出しでerrを返すようにする。methodは"Buckets.Create"のような形式
+func (f *Fake) FailOn(method string, err error) {
+	f.OnCall(func(call *objectstorage.Call) error {
+		if call.Method == method {
+			return err
+		}
+		return nil
+	})
+}
+
+// ClearHooks 追加したフックを全て削除する
+func (f *Fake) ClearHooks() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.hooks = nil
+}
+
+// Calls これまでの呼び出しを順に返す
+func (f *Fake) Calls() []objectstorage.Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.calls)
+}
+
+// CallsTo methodの呼び出しを順に返す
+func (f *Fake) CallsTo(method string) []objectstorage.Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var calls []objectstorage.Call
+	for _, call := range f.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// ResetCalls 記録した呼び出しを削除する
+func (f *Fake) ResetCalls() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = nil
+}
+
+// APIError methodがcodeのステータスコードで失敗した場合のエラーを返す。FailOnなどに渡すエラーの生成に用いる
+func APIError(method string, code int) error {
+	return objectstorage.NewAPIError(method, code, errors.New(http.StatusText(code)))
+}
+
+// begin 呼び出しを記録してフックを実行し、ロックを取得する。呼び出し元はエラーがない場合にf.mu.Unlockを呼ぶこと
+func (f *Fake) begin(ctx context.Context, method, siteId, bucket string) error {
+	if err := ctx.Err(); err != nil {
+		return objectstorage.NewAPIError(method, 0, err)
+	}
+	call := objectstorage.Call{Method: method, SiteID: siteId, Bucket: bucket}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, call)
+	hooks := slices.Clone(f.hooks)
+	f.mu.Unlock()
+
+	for _, hook := range hooks {
+		if err := hook(&call); err != nil {
+			return err
+		}
+	}
+	f.mu.Lock()
+	return nil
+}
+
+func apiError(method string, code int, message string) error {
+	return objectstorage.NewAPIError(method, code, errors.New(message))
+}
+
+func (f *Fake) site(siteId string) *fakeSite {
+	for _, site := range f.sites {
+		if site.cluster.ID.Value == siteId {
+			return site
+		}
+	}
+	return nil
+}
+
+func (f *Fake) bucket(siteId, name string) *fakeBucket {
+	for _, b := range f.buckets {
+		if b.name == name && (siteId == "" || b.siteId == siteId) {
+			return b
+		}
+	}
+	return nil
+}
+
+func (f *Fake) nextId() int64 {
+	f.seq++
+	return f.seq
+}
+
+// newKey 決定的なアクセスキーIDとシークレットを生成する
+func (f *Fake) newKey(prefix string) (string, string) {
+	n := f.nextId()
+	id := fmt.Sprintf("%s%016d", prefix, n)
+	sum := sha256.Sum256([]byte(id))
+	return id, base64.StdEncoding.EncodeToString(sum[:])[:40]
+}
+
+// Backend
+
+// Sites implements objectstorage.Backend
+func (f *Fake) Sites(siteId string) (objectstorage.SiteAPI, error) {
+	return &fakeSiteAPI{f: f, siteId: siteId}, nil
+}
+
+// Buckets implements objectstorage.Backend
+func (f *Fake) Buckets(siteId string) (objectstorage.BucketAPI, error) {
+	if siteId == "" {
+		return nil, objectstorage.NewError("site id is required", nil)
+	}
+	return &fakeBucketAPI{f: f, siteId: siteId}, nil
+}
+
+// BucketExtra implements objectstorage.Backend
+func (f *Fake) BucketExtra(siteId, bucket string) (objectstorage.BucketExtraAPI, error) {
+	if siteId == "" {
+		return nil, objectstorage.NewError("site id is required", nil)
+	}
+	return &fakeBucketExtraAPI{f: f, siteId: siteId, bucket: bucket}, nil
+}
+
+// Accounts implements objectstorage.Backend
+func (f *Fake) Accounts(siteId string) (objectstorage.AccountAPI, error) {
+	if siteId == "" {
+		return nil, objectstorage.NewError("site id is required", nil)
+	}
+	return &fakeAccountAPI{f: f, siteId: siteId}, nil
+}
+
+// Permissions implements objectstorage.Backend
+func (f *Fake) Permissions(siteId string) (objectstorage.PermissionsAPI, error) {
+	if siteId == "" {
+		return nil, objectstorage.NewError("site id is required", nil)
+	}
+	return &fakePermissionsAPI{f: f, siteId: siteId}, nil
+}
+
+// SiteStatus implements objectstorage.Backend
+func (f *Fake) SiteStatus(siteId string) (objectstorage.SiteStatusAPI, error) {
+	if siteId == "" {
+		return nil, objectstorage.NewError("site id is required", nil)
+	}
+	return &fakeSiteStatusAPI{f: f, siteId: siteId}, nil
+}
+
+// 状態の設定
+
+// SetSiteStatus サイトのステータスを設定する
+func (f *Fake) SetSiteStatus(siteId string, status v2.StatusData) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	site := f.site(siteId)
+	if site == nil {
+		return objectstorage.NewError(fmt.Sprintf("site %q not found", siteId), nil)
+	}
+	site.status = status
+	return nil
+}
+
+// SetSiteQuota サイトの制限値を設定する
+func (f *Fake) SetSiteQuota(siteId string, quota v2.QuotaData) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	site := f.site(siteId)
+	if site == nil {
+		return objectstorage.NewError(fmt.Sprintf("site %q not found", siteId), nil)
+	}
+	site.quota = quota
+	return nil
+}
+
+// SetBucketUsage バケットの使用量を設定する
+func (f *Fake) SetBucketUsage(siteId, bucket string, usage v2.BucketUsageData) error {
+	return f.updateBucket(siteId, bucket, func(b *fakeBucket) { b.usage = usage })
+}
+
+// SetBucketQuota バケットの制限値を設定する。設定しない場合はサイトの制限値が用いられる
+func (f *Fake) SetBucketQuota(siteId, bucket string, quota v2.BucketQuotaData) error {
+	return f.updateBucket(siteId, bucket, func(b *fakeBucket) { b.quota = &quota })
+}
+
+// SetBucketPenalty バケットのペナルティ状況を設定する
+func (f *Fake) SetBucketPenalty(siteId, bucket string, penalty v2.BucketPenaltyData) error {
+	return f.updateBucket(siteId, bucket, func(b *fakeBucket) { b.penalty = penalty })
+}
+
+// SetBucketMetering バケットの利用実績を設定する
+func (f *Fake) SetBucketMetering(siteId, bucket string, items []v2.BucketBillingItem) error {
+	return f.updateBucket(siteId, bucket, func(b *fakeBucket) { b.metering = slices.Clone(items) })
+}
+
+func (f *Fake) updateBucket(siteId, bucket string, update func(b *fakeBucket)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b := f.bucket(siteId, bucket)
+	if b == nil {
+		return objectstorage.NewError(fmt.Sprintf("bucket %q not found in site %q", bucket, siteId), nil)
+	}
+	update(b)
+	return nil
+}
@@ -0,0 +1,784 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstoragetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+)
+
+// SiteAPI
+
+type fakeSiteAPI struct {
+	f      *Fake
+	siteId string
+}
+
+func (s *fakeSiteAPI) List(ctx context.Context) ([]v2.ModelCluster, error) {
+	if err := s.f.begin(ctx, "Site.List", "", ""); err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	var sites []v2.ModelCluster
+	for _, site := range s.f.sites {
+		sites = append(sites, site.cluster)
+	}
+	return sites, nil
+}
+
+func (s *fakeSiteAPI) Read(ctx context.Context, siteId string) (*v2.ModelCluster, error) {
+	if err := s.f.begin(ctx, "Site.Read", siteId, ""); err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	site := s.f.site(siteId)
+	if site == nil {
+		return nil, apiError("Site.Read", http.StatusNotFound, "site not found")
+	}
+	cluster := site.cluster
+	return &cluster, nil
+}
+
+func (s *fakeSiteAPI) ListPlans(ctx context.Context) ([]v2.PlanItem, error) {
+	if s.siteId == "" {
+		return nil, objectstorage.NewError("Site.ListPlans", errors.New("site client is not specified"))
+	}
+	if err := s.f.begin(ctx, "Site.ListPlans", s.siteId, ""); err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	site := s.f.site(s.siteId)
+	if site == nil {
+		return nil, apiError("Site.ListPlans", http.StatusNotFound, "site not found")
+	}
+	planType := v2.ModelPlanType(site.cluster.PlanFamily.Or(v2.ModelClusterPlanFamilyStandard))
+	return []v2.PlanItem{{
+		ServiceClassPath: v2.NewOptServiceClassPath(v2.ServiceClassPath(fmt.Sprintf("objectstorage/%s/bucket", s.siteId))),
+		Type:             v2.NewOptModelPlanType(planType),
+		ClusterID:        v2.NewOptString(s.siteId),
+	}}, nil
+}
+
+// SiteStatusAPI
+
+type fakeSiteStatusAPI struct {
+	f      *Fake
+	siteId string
+}
+
+func (s *fakeSiteStatusAPI) Read(ctx context.Context) (*v2.StatusData, error) {
+	if err := s.f.begin(ctx, "SiteStatus.Read", s.siteId, ""); err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	site := s.f.site(s.siteId)
+	if site == nil {
+		return nil, apiError("SiteStatus.Read", http.StatusNotFound, "site not found")
+	}
+	status := site.status
+	return &status, nil
+}
+
+func (s *fakeSiteStatusAPI) ReadQuota(ctx context.Context) (*v2.QuotaData, error) {
+	if err := s.f.begin(ctx, "SiteStatus.ReadQuota", s.siteId, ""); err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	site := s.f.site(s.siteId)
+	if site == nil {
+		return nil, apiError("SiteStatus.ReadQuota", http.StatusNotFound, "site not found")
+	}
+	quota := site.quota
+	return &quota, nil
+}
+
+func (s *fakeSiteStatusAPI) ReadBucketMetering(ctx context.Context, bucketName string, from, to time.Time) ([]v2.BucketBillingItem, error) {
+	if err := s.f.begin(ctx, "SiteStatus.ReadBucketMetering", s.siteId, bucketName); err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	if to.Before(from) {
+		return nil, apiError("SiteStatus.ReadBucketMetering", http.StatusBadRequest, "invalid range")
+	}
+	b := s.f.bucket(s.siteId, bucketName)
+	if b == nil {
+		return nil, nil
+	}
+	return slices.Clone(b.metering), nil
+}
+
+// BucketAPI
+
+type fakeBucketAPI struct {
+	f      *Fake
+	siteId string
+}
+
+func (s *fakeBucketAPI) List(ctx context.Context) ([]v2.BucketListDataItem, error) {
+	if err := s.f.begin(ctx, "Buckets.List", s.siteId, ""); err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	res := []v2.BucketListDataItem{}
+	for _, b := range s.f.buckets {
+		if b.siteId != s.siteId {
+			continue
+		}
+		res = append(res, v2.BucketListDataItem{
+			Name:       v2.BucketName(b.name),
+			ResourceID: v2.NewOptResourceID(v2.ResourceID(b.resourceId)),
+			Plan: v2.NewOptBucketListDataItemPlan(v2.BucketListDataItemPlan{
+				Type:             b.plan.Type,
+				ServiceClassPath: v2.NewOptServiceClassPath(v2.ServiceClassPath(b.plan.ServiceClassPath.Value)),
+			}),
+		})
+	}
+	return res, nil
+}
+
+func (s *fakeBucketAPI) Create(ctx context.Context, params *objectstorage.BucketCreateParams) (*v2.ModelBucket, error) {
+	siteId := params.SiteId
+	if siteId == "" {
+		siteId = s.siteId
+	}
+	if err := s.f.begin(ctx, "Buckets.Create", siteId, params.Bucket); err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	if err := v2.BucketName(params.Bucket).Validate(); err != nil || len(params.Bucket) > 63 {
+		return nil, apiError("Buckets.Create", http.StatusBadRequest, "invalid bucket name")
+	}
+	site := s.f.site(siteId)
+	if site == nil || site.account == nil {
+		return nil, apiError("Buckets.Create", http.StatusNotFound, "site account not found")
+	}
+	if s.f.bucket("", params.Bucket) != nil {
+		return nil, apiError("Buckets.Create", http.StatusConflict, "bucket already exists")
+	}
+	if limit, ok := site.quota.NumBuckets.Get(); ok && len(s.f.bucketsIn(siteId)) >= limit {
+		return nil, apiError("Buckets.Create", http.StatusConflict, "number of buckets exceeds the limit")
+	}
+
+	plan := v2.ModelBucketPlan{
+		Type:             v2.NewOptModelPlanType(v2.ModelPlanTypeStandard),
+		ServiceClassPath: v2.NewOptString(fmt.Sprintf("objectstorage/%s/bucket", siteId)),
+	}
+	if site.cluster.PlanFamily.Value == v2.ModelClusterPlanFamilyArchive {
+		plan = v2.ModelBucketPlan{
+			Type:             v2.NewOptModelPlanType(v2.ModelPlanTypeArchive),
+			ServiceClassPath: v2.NewOptString(fmt.Sprintf("objectstorage/%s/bucket/%s", siteId, params.Plan)),
+		}
+	}
+	s.f.buckets = append(s.f.buckets, &fakeBucket{
+		siteId:     siteId,
+		name:       params.Bucket,
+		resourceId: fmt.Sprintf("1130000%05d", s.f.nextId()),
+		plan:       plan,
+		usage:      v2.BucketUsageData{NumObjectsPerBucket: v2.NewOptInt(0), AmountGibPerBucket: v2.NewOptFloat32(0)},
+		penalty: v2.BucketPenaltyData{
+			NumObjectsPerBucket: v2.NewOptBucketPenaltyDataNumObjectsPerBucket(v2.BucketPenaltyDataNumObjectsPerBucket{IsApplied: v2.NewOptBool(false)}),
+			AmountGibPerBucket:  v2.NewOptBucketPenaltyDataAmountGibPerBucket(v2.BucketPenaltyDataAmountGibPerBucket{IsApplied: v2.NewOptBool(false)}),
+		},
+	})
+	return &v2.ModelBucket{
+		ClusterID: v2.NewOptString(siteId),
+		Name:      v2.NewOptString(params.Bucket),
+		Plan:      v2.NewOptModelBucketPlan(plan),
+	}, nil
+}
+
+// Delete 存在しないバケットの削除は成功する(APIの定義deleteBucketに404がないため)。
+// パーミッションやレプリケーションから参照されている場合は409となる
+//
+// APIの定義では409は「バケットに関係するリソースが残っています」とされているが、対象のリソースは明記されていない。
+// フェイクではレプリケーションの設定とパーミッションのバケットの指定を関係するリソースとみなす
+func (s *fakeBucketAPI) Delete(ctx context.Context, bucketName string) error {
+	if err := s.f.begin(ctx, "Buckets.Delete", s.siteId, bucketName); err != nil {
+		return err
+	}
+	defer s.f.mu.Unlock()
+
+	if err := v2.BucketName(bucketName).Validate(); err != nil {
+		return apiError("Buckets.Delete", http.StatusBadRequest, "invalid bucket name")
+	}
+	b := s.f.bucket("", bucketName)
+	if b == nil {
+		return nil
+	}
+	if s.f.referenced(b) {
+		return apiError("Buckets.Delete", http.StatusConflict, "resources related to the bucket still exist")
+	}
+	s.f.buckets = slices.DeleteFunc(s.f.buckets, func(x *fakeBucket) bool { return x == b })
+	return nil
+}
+
+func (f *Fake) bucketsIn(siteId string) []*fakeBucket {
+	var res []*fakeBucket
+	for _, b := range f.buckets {
+		if b.siteId == siteId {
+			res = append(res, b)
+		}
+	}
+	return res
+}
+
+// referenced バケットがパーミッションやレプリケーションから参照されているか。Deleteの409の判定に用いる
+func (f *Fake) referenced(b *fakeBucket) bool {
+	if b.replication != nil {
+		return true
+	}
+	for _, other := range f.buckets {
+		if r := other.replication; r != nil && r.DestBucket.Name.Value == b.name {
+			return true
+		}
+	}
+	if site := f.site(b.siteId); site != nil {
+		for _, p := range site.permissions {
+			for _, c := range p.data.BucketControls {
+				if string(c.BucketName.Value) == b.name {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// BucketExtraAPI
+
+type fakeBucketExtraAPI struct {
+	f      *Fake
+	siteId string
+	bucket string
+}
+
+// begin 呼び出しを記録し、バケットを返す。バケットが存在しない場合は404のエラーを返しロックを解放する
+func (s *fakeBucketExtraAPI) begin(ctx context.Context, method string) (*fakeBucket, error) {
+	if err := s.f.begin(ctx, method, s.siteId, s.bucket); err != nil {
+		return nil, err
+	}
+	b := s.f.bucket(s.siteId, s.bucket)
+	if b == nil {
+		s.f.mu.Unlock()
+		return nil, apiError(method, http.StatusNotFound, "bucket not found")
+	}
+	return b, nil
+}
+
+func (s *fakeBucketExtraAPI) ReadEncryption(ctx context.Context) (*v2.HandlerEncryptionConfigRes, error) {
+	b, err := s.begin(ctx, "BucketExtra.ReadEncryption")
+	if err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	if b.encryption == nil {
+		return &v2.HandlerEncryptionConfigRes{}, nil
+	}
+	enc := *b.encryption
+	return &enc, nil
+}
+
+func (s *fakeBucketExtraAPI) EnableEncryption(ctx context.Context, keyId string) error {
+	b, err := s.begin(ctx, "BucketExtra.EnableEncryption")
+	if err != nil {
+		return err
+	}
+	defer s.f.mu.Unlock()
+
+	if keyId == "" {
+		return apiError("BucketExtra.EnableEncryption", http.StatusBadRequest, "kms_key_id is required")
+	}
+	b.encryption = &v2.HandlerEncryptionConfigRes{
+		KmsKeyID:     v2.NewOptResourceID(v2.ResourceID(keyId)),
+		ConfiguredAt: v2.NewOptCreatedAt(v2.CreatedAt(s.f.now())),
+	}
+	return nil
+}
+
+func (s *fakeBucketExtraAPI) DisableEncryption(ctx context.Context) error {
+	b, err := s.begin(ctx, "BucketExtra.DisableEncryption")
+	if err != nil {
+		return err
+	}
+	defer s.f.mu.Unlock()
+
+	b.encryption = nil
+	return nil
+}
+
+func (s *fakeBucketExtraAPI) ReadReplication(ctx context.Context) (*v2.ModelReplication, error) {
+	b, err := s.begin(ctx, "BucketExtra.ReadReplication")
+	if err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	if b.replication == nil {
+		return nil, apiError("BucketExtra.ReadReplication", http.StatusNotFound, "replication is not configured")
+	}
+	rep := *b.replication
+	return &rep, nil
+}
+
+func (s *fakeBucketExtraAPI) EnableReplication(ctx context.Context, targetBucket string) (*v2.ModelReplication, error) {
+	b, err := s.begin(ctx, "BucketExtra.EnableReplication")
+	if err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	target := s.f.bucket("", targetBucket)
+	if target == nil {
+		return nil, apiError("BucketExtra.EnableReplication", http.StatusNotFound, "destination bucket not found")
+	}
+	if target.siteId == b.siteId {
+		return nil, apiError("BucketExtra.EnableReplication", http.StatusBadRequest, "destination bucket must be in another site")
+	}
+	b.replication = &v2.ModelReplication{
+		SourceBucket: v2.ModelReplicationSourceBucket{Name: v2.NewOptString(b.name), ClusterID: v2.NewOptString(b.siteId)},
+		DestBucket:   v2.ModelReplicationDestBucket{Name: v2.NewOptString(target.name), ClusterID: v2.NewOptString(target.siteId)},
+		ConfigStatus: v2.ModelReplicationConfigStatusCreated,
+		CreatedAt:    s.f.now(),
+	}
+	rep := *b.replication
+	return &rep, nil
+}
+
+func (s *fakeBucketExtraAPI) DisableReplication(ctx context.Context) error {
+	b, err := s.begin(ctx, "BucketExtra.DisableReplication")
+	if err != nil {
+		return err
+	}
+	defer s.f.mu.Unlock()
+
+	if b.replication == nil {
+		return apiError("BucketExtra.DisableReplication", http.StatusNotFound, "replication is not configured")
+	}
+	b.replication = nil
+	return nil
+}
+
+func (s *fakeBucketExtraAPI) ReadPenalty(ctx context.Context) (*v2.BucketPenaltyData, error) {
+	b, err := s.begin(ctx, "BucketExtra.ReadPenalty")
+	if err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	penalty := b.penalty
+	return &penalty, nil
+}
+
+func (s *fakeBucketExtraAPI) ReadUsage(ctx context.Context) (*v2.BucketUsageData, error) {
+	b, err := s.begin(ctx, "BucketExtra.ReadUsage")
+	if err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	usage := b.usage
+	return &usage, nil
+}
+
+func (s *fakeBucketExtraAPI) ReadQuota(ctx context.Context) (*v2.BucketQuotaData, error) {
+	b, err := s.begin(ctx, "BucketExtra.ReadQuota")
+	if err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	if b.quota != nil {
+		quota := *b.quota
+		return &quota, nil
+	}
+	site := s.f.site(b.siteId)
+	return &v2.BucketQuotaData{
+		NumObjectsPerBucket: site.quota.NumObjectsPerBucket,
+		AmountGibPerBucket:  site.quota.AmountGibPerBucket,
+	}, nil
+}
+
+// AccountAPI
+
+type fakeAccountAPI struct {
+	f      *Fake
+	siteId string
+}
+
+func (s *fakeAccountAPI) Create(ctx context.Context) (*v2.AccountData, error) {
+	if err := s.f.begin(ctx, "Accounts.Create", s.siteId, ""); err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	site := s.f.site(s.siteId)
+	if site == nil {
+		return nil, apiError("Accounts.Create", http.StatusForbidden, "site not found")
+	}
+	if site.account != nil {
+		return nil, apiError("Accounts.Create", http.StatusConflict, "site account already exists")
+	}
+	if !site.status.AcceptNew.Or(true) {
+		return nil, apiError("Accounts.Create", http.StatusForbidden, "the site does not accept new accounts")
+	}
+	n := s.f.nextId()
+	site.account = &v2.AccountData{
+		ResourceID: v2.NewOptResourceID(v2.ResourceID(fmt.Sprintf("1100000%05d", n))),
+		Code:       v2.NewOptCode(v2.Code(fmt.Sprintf("fake%06d", n))),
+		CreatedAt:  v2.NewOptCreatedAt(v2.CreatedAt(s.f.now())),
+	}
+	account := *site.account
+	return &account, nil
+}
+
+// account サイトアカウントを返す。存在しない場合はnil
+func (s *fakeAccountAPI) account() *fakeSite {
+	site := s.f.site(s.siteId)
+	if site == nil || site.account == nil {
+		return nil
+	}
+	return site
+}
+
+func (s *fakeAccountAPI) Read(ctx context.Context) (*v2.AccountData, error) {
+	if err := s.f.begin(ctx, "Accounts.Read", s.siteId, ""); err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	site := s.account()
+	if site == nil {
+		return nil, apiError("Accounts.Read", http.StatusNotFound, "site account not found")
+	}
+	account := *site.account
+	return &account, nil
+}
+
+// Delete 存在しないサイトアカウントの削除は成功する。バケットやパーミッション、アクセスキーが残っている場合は409となる
+func (s *fakeAccountAPI) Delete(ctx context.Context) error {
+	if err := s.f.begin(ctx, "Accounts.Delete", s.siteId, ""); err != nil {
+		return err
+	}
+	defer s.f.mu.Unlock()
+
+	site := s.account()
+	if site == nil {
+		return nil
+	}
+	if len(s.f.bucketsIn(s.siteId)) > 0 || len(site.permissions) > 0 || len(site.accountKeys) > 0 {
+		return apiError("Accounts.Delete", http.StatusConflict, "resources related to the site account still exist")
+	}
+	site.account = nil
+	return nil
+}
+
+func (s *fakeAccountAPI) ListAccessKeys(ctx context.Context) ([]v2.AccountKeysDataItem, error) {
+	if err := s.f.begin(ctx, "Accounts.ListAccessKeys", s.siteId, ""); err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	site := s.account()
+	if site == nil {
+		return nil, apiError("Accounts.ListAccessKeys", http.StatusNotFound, "site account not found")
+	}
+	res := []v2.AccountKeysDataItem{}
+	for _, key := range site.accountKeys {
+		res = append(res, v2.AccountKeysDataItem{ID: key.ID, CreatedAt: key.CreatedAt})
+	}
+	return res, nil
+}
+
+func (s *fakeAccountAPI) CreateAccessKey(ctx context.Context) (*v2.AccountKeyData, error) {
+	if err := s.f.begin(ctx, "Accounts.CreateAccessKey", s.siteId, ""); err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	site := s.account()
+	if site == nil {
+		return nil, apiError("Accounts.CreateAccessKey", http.StatusNotFound, "site account not found")
+	}
+	if limit, ok := site.quota.NumRootKeys.Get(); ok && len(site.accountKeys) >= limit {
+		return nil, apiError("Accounts.CreateAccessKey", http.StatusConflict, "number of access keys exceeds the limit")
+	}
+	id, secret := s.f.newKey("FAKEROOT")
+	key := &v2.AccountKeyData{
+		ID:        v2.NewOptAccessKeyID(v2.AccessKeyID(id)),
+		CreatedAt: v2.NewOptCreatedAt(v2.CreatedAt(s.f.now())),
+	}
+	site.accountKeys = append(site.accountKeys, key)
+
+	created := *key
+	created.Secret = v2.NewOptSecretAccessKey(v2.SecretAccessKey(secret))
+	return &created, nil
+}
+
+func (s *fakeAccountAPI) ReadAccessKey(ctx context.Context, keyId string) (*v2.AccountKeyData, error) {
+	if err := s.f.begin(ctx, "Accounts.ReadAccessKey", s.siteId, ""); err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	if site := s.account(); site != nil {
+		for _, key := range site.accountKeys {
+			if string(key.ID.Value) == keyId {
+				res := *key
+				return &res, nil
+			}
+		}
+	}
+	return nil, apiError("Accounts.ReadAccessKey", http.StatusNotFound, "access key not found")
+}
+
+// DeleteAccessKey 存在しないアクセスキーの削除は成功する(APIの定義deleteAccountKeyに404がないため)。
+// パーミッションのアクセスキーの削除とは異なる点に注意
+func (s *fakeAccountAPI) DeleteAccessKey(ctx context.Context, keyId string) error {
+	if err := s.f.begin(ctx, "Accounts.DeleteAccessKey", s.siteId, ""); err != nil {
+		return err
+	}
+	defer s.f.mu.Unlock()
+
+	if site := s.account(); site != nil {
+		site.accountKeys = slices.DeleteFunc(site.accountKeys, func(key *v2.AccountKeyData) bool { return string(key.ID.Value) == keyId })
+	}
+	return nil
+}
+
+// PermissionsAPI
+
+type fakePermissionsAPI struct {
+	f      *Fake
+	siteId string
+}
+
+func (s *fakePermissionsAPI) permission(site *fakeSite, permissionId string) *fakePermission {
+	if site == nil {
+		return nil
+	}
+	id, err := strconv.ParseInt(permissionId, 10, 64)
+	if err != nil {
+		return nil
+	}
+	for _, p := range site.permissions {
+		if int64(p.data.ID.Value) == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (p *fakePermission) snapshot() v2.PermissionData {
+	data := p.data
+	data.BucketControls = slices.Clone(p.data.BucketControls)
+	return data
+}
+
+// validateControls 指定されたバケットが全て存在し、制限値を超えていないかを確認する
+func (s *fakePermissionsAPI) validateControls(method string, site *fakeSite, controls v2.BucketControls) error {
+	for _, c := range controls {
+		if s.f.bucket(s.siteId, string(c.BucketName.Value)) == nil {
+			return apiError(method, http.StatusNotFound, fmt.Sprintf("bucket %q not found", c.BucketName.Value))
+		}
+	}
+	if limit, ok := site.quota.NumBucketsPerPermission.Get(); ok && len(controls) > limit {
+		return apiError(method, http.StatusConflict, "number of buckets exceeds the limit")
+	}
+	return nil
+}
+
+func (s *fakePermissionsAPI) controls(controls v2.BucketControls) v2.BucketControls {
+	res := v2.BucketControls{}
+	for _, c := range controls {
+		c.CreatedAt = v2.NewOptCreatedAt(v2.CreatedAt(s.f.now()))
+		res = append(res, c)
+	}
+	return res
+}
+
+func (s *fakePermissionsAPI) List(ctx context.Context) ([]v2.PermissionsDataItem, error) {
+	if err := s.f.begin(ctx, "Permissions.List", s.siteId, ""); err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	res := []v2.PermissionsDataItem{}
+	if site := s.f.site(s.siteId); site != nil {
+		for _, p := range site.permissions {
+			res = append(res, v2.PermissionsDataItem(p.snapshot()))
+		}
+	}
+	return res, nil
+}
+
+func (s *fakePermissionsAPI) Create(ctx context.Context, displayName string, controls v2.BucketControls) (*v2.PermissionData, error) {
+	if err := s.f.begin(ctx, "Permissions.Create", s.siteId, ""); err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	site := s.f.site(s.siteId)
+	if site == nil || site.account == nil {
+		return nil, apiError("Permissions.Create", http.StatusNotFound, "site account not found")
+	}
+	if err := s.validateControls("Permissions.Create", site, controls); err != nil {
+		return nil, err
+	}
+	if limit, ok := site.quota.NumPermissions.Get(); ok && len(site.permissions) >= limit {
+		return nil, apiError("Permissions.Create", http.StatusConflict, "number of permissions exceeds the limit")
+	}
+	p := &fakePermission{data: v2.PermissionData{
+		ID:             v2.NewOptPermissionID(v2.PermissionID(s.f.nextId())),
+		DisplayName:    v2.NewOptDisplayName(v2.DisplayName(displayName)),
+		BucketControls: s.controls(controls),
+		CreatedAt:      v2.NewOptCreatedAt(v2.CreatedAt(s.f.now())),
+	}}
+	site.permissions = append(site.permissions, p)
+	data := p.snapshot()
+	return &data, nil
+}
+
+func (s *fakePermissionsAPI) Read(ctx context.Context, permissionId string) (*v2.PermissionData, error) {
+	if err := s.f.begin(ctx, "Permissions.Read", s.siteId, ""); err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	p := s.permission(s.f.site(s.siteId), permissionId)
+	if p == nil {
+		return nil, apiError("Permissions.Read", http.StatusNotFound, "permission not found")
+	}
+	data := p.snapshot()
+	return &data, nil
+}
+
+func (s *fakePermissionsAPI) Update(ctx context.Context, permissionId string, displayName string, controls v2.BucketControls) (*v2.PermissionData, error) {
+	if err := s.f.begin(ctx, "Permissions.Update", s.siteId, ""); err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	site := s.f.site(s.siteId)
+	p := s.permission(site, permissionId)
+	if p == nil {
+		return nil, apiError("Permissions.Update", http.StatusNotFound, "permission not found")
+	}
+	if err := s.validateControls("Permissions.Update", site, controls); err != nil {
+		return nil, err
+	}
+	p.data.DisplayName = v2.NewOptDisplayName(v2.DisplayName(displayName))
+	p.data.BucketControls = s.controls(controls)
+	data := p.snapshot()
+	return &data, nil
+}
+
+// Delete 存在しないパーミッションの削除は成功する(APIの定義deletePermissionに404がないため)。パーミッションのアクセスキーも削除される
+func (s *fakePermissionsAPI) Delete(ctx context.Context, permissionId string) error {
+	if err := s.f.begin(ctx, "Permissions.Delete", s.siteId, ""); err != nil {
+		return err
+	}
+	defer s.f.mu.Unlock()
+
+	site := s.f.site(s.siteId)
+	if p := s.permission(site, permissionId); p != nil {
+		site.permissions = slices.DeleteFunc(site.permissions, func(x *fakePermission) bool { return x == p })
+	}
+	return nil
+}
+
+func (s *fakePermissionsAPI) ListAccessKeys(ctx context.Context, permissionId string) ([]v2.PermissionKeysDataItem, error) {
+	if err := s.f.begin(ctx, "Permissions.ListAccessKeys", s.siteId, ""); err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	p := s.permission(s.f.site(s.siteId), permissionId)
+	if p == nil {
+		return nil, apiError("Permissions.ListAccessKeys", http.StatusNotFound, "permission not found")
+	}
+	res := []v2.PermissionKeysDataItem{}
+	for _, key := range p.keys {
+		res = append(res, v2.PermissionKeysDataItem{ID: key.ID, CreatedAt: key.CreatedAt})
+	}
+	return res, nil
+}
+
+func (s *fakePermissionsAPI) CreateAccessKey(ctx context.Context, permissionId string) (*v2.PermissionKeyData, error) {
+	if err := s.f.begin(ctx, "Permissions.CreateAccessKey", s.siteId, ""); err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	site := s.f.site(s.siteId)
+	p := s.permission(site, permissionId)
+	if p == nil {
+		return nil, apiError("Permissions.CreateAccessKey", http.StatusNotFound, "permission not found")
+	}
+	if limit, ok := site.quota.NumKeysPerPermission.Get(); ok && len(p.keys) >= limit {
+		return nil, apiError("Permissions.CreateAccessKey", http.StatusConflict, "number of access keys exceeds the limit")
+	}
+	id, secret := s.f.newKey("FAKEPERM")
+	key := &v2.PermissionKeyData{
+		ID:        v2.NewOptPermissionKeyID(v2.PermissionKeyID(id)),
+		CreatedAt: v2.NewOptCreatedAt(v2.CreatedAt(s.f.now())),
+	}
+	p.keys = append(p.keys, key)
+
+	created := *key
+	created.Secret = v2.NewOptPermissionSecret(v2.PermissionSecret(secret))
+	return &created, nil
+}
+
+func (s *fakePermissionsAPI) ReadAccessKey(ctx context.Context, permissionId string, accessKeyId string) (*v2.PermissionKeyData, error) {
+	if err := s.f.begin(ctx, "Permissions.ReadAccessKey", s.siteId, ""); err != nil {
+		return nil, err
+	}
+	defer s.f.mu.Unlock()
+
+	if p := s.permission(s.f.site(s.siteId), permissionId); p != nil {
+		for _, key := range p.keys {
+			if string(key.ID.Value) == accessKeyId {
+				res := *key
+				return &res, nil
+			}
+		}
+	}
+	return nil, apiError("Permissions.ReadAccessKey", http.StatusNotFound, "access key not found")
+}
+
+// DeleteAccessKey 存在しないアクセスキーの削除は404となる(APIの定義deletePermissionKeyに404があるため)。
+// サイトアカウントのアクセスキーの削除とは異なる点に注意
+func (s *fakePermissionsAPI) DeleteAccessKey(ctx context.Context, permissionId string, accessKeyId string) error {
+	if err := s.f.begin(ctx, "Permissions.DeleteAccessKey", s.siteId, ""); err != nil {
+		return err
+	}
+	defer s.f.mu.Unlock()
+
+	if p := s.permission(s.f.site(s.siteId), permissionId); p != nil {
+		for i, key := range p.keys {
+			if string(key.ID.Value) == accessKeyId {
+				p.keys = slices.Delete(p.keys, i, i+1)
+				return nil
+			}
+		}
+	}
+	return apiError("Permissions.DeleteAccessKey", http.StatusNotFound, "access key not found")
+}
@@ -0,0 +1,147 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstoragetest
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/sacloud/saclient-go"
+	"github.com/stretchr/testify/require"
+)
+
+func controls(buckets ...string) v2.BucketControls {
+	var res v2.BucketControls
+	for _, b := range buckets {
+		res = append(res, v2.BucketControlsItem{
+			BucketName: v2.NewOptBucketName(v2.BucketName(b)),
+			CanRead:    v2.NewOptCanRead(true),
+			CanWrite:   v2.NewOptCanWrite(true),
+		})
+	}
+	return res
+}
+
+func TestFake_SharedState(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+
+	buckets, err := fake.Buckets("isk01")
+	require.NoError(t, err)
+	permissions, err := fake.Permissions("isk01")
+	require.NoError(t, err)
+
+	created, err := buckets.Create(ctx, &objectstorage.BucketCreateParams{SiteId: "isk01", Bucket: "bucket1"})
+	require.NoError(t, err)
+	require.Equal(t, "isk01", created.ClusterID.Value)
+
+	_, err = buckets.Create(ctx, &objectstorage.BucketCreateParams{SiteId: "tky01", Bucket: "bucket1"})
+	require.ErrorContains(t, err, "bucket already exists")
+
+	// 作成したバケットはBucketExtraAPIから参照できる
+	require.NoError(t, fake.SetBucketUsage("isk01", "bucket1", v2.BucketUsageData{NumObjectsPerBucket: v2.NewOptInt(42)}))
+	extra, err := fake.BucketExtra("isk01", "bucket1")
+	require.NoError(t, err)
+	usage, err := extra.ReadUsage(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 42, usage.NumObjectsPerBucket.Value)
+	quota, err := extra.ReadQuota(ctx)
+	require.NoError(t, err)
+	require.Equal(t, DefaultSiteQuota.NumObjectsPerBucket, quota.NumObjectsPerBucket)
+
+	// パーミッションから参照されている間は削除できない
+	permission, err := permissions.Create(ctx, "perm", controls("bucket1"))
+	require.NoError(t, err)
+	permissionId := strconv.FormatInt(int64(permission.ID.Value), 10)
+	err = buckets.Delete(ctx, "bucket1")
+	require.Error(t, err)
+	require.ErrorContains(t, err, "resources related to the bucket still exist")
+
+	key, err := permissions.CreateAccessKey(ctx, permissionId)
+	require.NoError(t, err)
+	require.NotEmpty(t, key.Secret.Value)
+	read, err := permissions.ReadAccessKey(ctx, permissionId, string(key.ID.Value))
+	require.NoError(t, err)
+	require.False(t, read.Secret.Set, "secret must only be returned on create")
+
+	require.NoError(t, permissions.Delete(ctx, permissionId))
+	require.NoError(t, buckets.Delete(ctx, "bucket1"))
+
+	// 削除後はBucketExtraAPI/PermissionsAPIで404となる
+	_, err = extra.ReadUsage(ctx)
+	require.True(t, saclient.IsNotFoundError(err))
+	_, err = permissions.Create(ctx, "perm", controls("bucket1"))
+	require.True(t, saclient.IsNotFoundError(err))
+
+	list, err := buckets.List(ctx)
+	require.NoError(t, err)
+	require.Empty(t, list)
+}
+
+func TestFake_Replication(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	for site, bucket := range map[string]string{"isk01": "src", "tky01": "dst"} {
+		buckets, err := fake.Buckets(site)
+		require.NoError(t, err)
+		_, err = buckets.Create(ctx, &objectstorage.BucketCreateParams{SiteId: site, Bucket: bucket})
+		require.NoError(t, err)
+	}
+
+	extra, err := fake.BucketExtra("isk01", "src")
+	require.NoError(t, err)
+	_, err = extra.ReadReplication(ctx)
+	require.True(t, saclient.IsNotFoundError(err))
+
+	rep, err := extra.EnableReplication(ctx, "dst")
+	require.NoError(t, err)
+	require.Equal(t, "tky01", rep.DestBucket.ClusterID.Value)
+
+	buckets, err := fake.Buckets("tky01")
+	require.NoError(t, err)
+	require.Error(t, buckets.Delete(ctx, "dst"), "the destination of a replication must not be deleted")
+
+	require.NoError(t, extra.DisableReplication(ctx))
+	require.NoError(t, buckets.Delete(ctx, "dst"))
+}
+
+func TestFake_HooksAndCalls(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	fake.FailOn("Buckets.Create", APIError("Buckets.Create", http.StatusInternalServerError))
+
+	buckets, err := fake.Buckets("isk01")
+	require.NoError(t, err)
+	// Interceptorと組み合わせて用いることができる
+	var intercepted []string
+	buckets = objectstorage.InterceptBucketAPI(buckets, "isk01", func(ctx context.Context, call *objectstorage.Call, invoke func(ctx context.Context) error) error {
+		intercepted = append(intercepted, call.Method)
+		return invoke(ctx)
+	})
+
+	_, err = buckets.Create(ctx, &objectstorage.BucketCreateParams{Bucket: "bucket1"})
+	require.Error(t, err)
+	list, err := buckets.List(ctx)
+	require.NoError(t, err)
+	require.Empty(t, list, "state must not change when a hook fails")
+
+	fake.ClearHooks()
+	_, err = buckets.Create(ctx, &objectstorage.BucketCreateParams{Bucket: "bucket1"})
+	require.NoError(t, err)
+
+	require.Equal(t, []objectstorage.Call{
+		{Method: "Buckets.Create", SiteID: "isk01", Bucket: "bucket1"},
+		{Method: "Buckets.List", SiteID: "isk01"},
+		{Method: "Buckets.Create", SiteID: "isk01", Bucket: "bucket1"},
+	}, fake.Calls())
+	require.Len(t, fake.CallsTo("Buckets.Create"), 2)
+	require.Equal(t, []string{"Buckets.Create", "Buckets.List", "Buckets.Create"}, intercepted)
+
+	fake.ResetCalls()
+	require.Empty(t, fake.Calls())
+}
@@ -0,0 +1,518 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstoragetest
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/sacloud/saclient-go"
+	"github.com/stretchr/testify/require"
+)
+
+// SuiteFactory 適合性テストの対象となるBackendを返す。サブテストごとに呼ばれる
+//
+// 特定のAPIのデコレーターを検証する場合は、Backendを埋め込んだ構造体で該当のメソッドのみを上書きする
+type SuiteFactory func(t *testing.T) objectstorage.Backend
+
+// Suite 各APIのインターフェースのドキュメントに記載された振る舞いを検証する適合性テスト
+//
+// 実際のAPI、Fake、利用者によるデコレーターのいずれに対しても実行できる。
+// テストで作成したリソースはt.Cleanupで削除するが、サイトのアカウントの作成/削除は行わない
+type Suite struct {
+	// Factory 対象のBackendを返す関数
+	Factory SuiteFactory
+	// SiteID 対象のサイトのID。空の場合はisk01
+	SiteID string
+	// NamePrefix 作成するバケットなどの名前のプレフィックス。空の場合はobjectstoragetest-
+	NamePrefix string
+	// NewName 作成するバケットなどの名前を返す関数。nilの場合はNamePrefixに乱数を付加した名前を用いる
+	//
+	// 記録したやりとりを再生する場合など、名前を決定的にする必要がある場合に指定する
+	NewName func() string
+	// ReplicationSiteID レプリケーションの検証に用いる別のサイトのID。空の場合は検証しない
+	ReplicationSiteID string
+	// KMSKeyID 暗号化の検証に用いるKMSのキーID。空の場合は検証しない
+	KMSKeyID string
+}
+
+// RunSiteAPISuite デフォルトの設定でSiteAPIの適合性テストを実行する
+func RunSiteAPISuite(t *testing.T, factory SuiteFactory) { Suite{Factory: factory}.RunSiteAPI(t) }
+
+// RunBucketAPISuite デフォルトの設定でBucketAPIの適合性テストを実行する
+func RunBucketAPISuite(t *testing.T, factory SuiteFactory) { Suite{Factory: factory}.RunBucketAPI(t) }
+
+// RunBucketExtraAPISuite デフォルトの設定でBucketExtraAPIの適合性テストを実行する
+func RunBucketExtraAPISuite(t *testing.T, factory SuiteFactory) {
+	Suite{Factory: factory}.RunBucketExtraAPI(t)
+}
+
+// RunAccountAPISuite デフォルトの設定でAccountAPIの適合性テストを実行する
+func RunAccountAPISuite(t *testing.T, factory SuiteFactory) { Suite{Factory: factory}.RunAccountAPI(t) }
+
+// RunPermissionsAPISuite デフォルトの設定でPermissionsAPIの適合性テストを実行する
+func RunPermissionsAPISuite(t *testing.T, factory SuiteFactory) {
+	Suite{Factory: factory}.RunPermissionsAPI(t)
+}
+
+// RunSiteStatusAPISuite デフォルトの設定でSiteStatusAPIの適合性テストを実行する
+func RunSiteStatusAPISuite(t *testing.T, factory SuiteFactory) {
+	Suite{Factory: factory}.RunSiteStatusAPI(t)
+}
+
+// Run 全てのAPIの適合性テストを実行する
+func (s Suite) Run(t *testing.T) {
+	t.Run("SiteAPI", s.RunSiteAPI)
+	t.Run("BucketAPI", s.RunBucketAPI)
+	t.Run("BucketExtraAPI", s.RunBucketExtraAPI)
+	t.Run("AccountAPI", s.RunAccountAPI)
+	t.Run("PermissionsAPI", s.RunPermissionsAPI)
+	t.Run("SiteStatusAPI", s.RunSiteStatusAPI)
+}
+
+// RunSiteAPI SiteAPIの適合性テストを実行する
+func (s Suite) RunSiteAPI(t *testing.T) {
+	ctx := context.Background()
+	newAPI := func(t *testing.T) objectstorage.SiteAPI {
+		api, err := s.Factory(t).Sites(s.siteId())
+		require.NoError(t, err)
+		return api
+	}
+
+	t.Run("list and read are consistent", func(t *testing.T) {
+		api := newAPI(t)
+		sites, err := api.List(ctx)
+		require.NoError(t, err)
+		require.NotEmpty(t, sites)
+
+		idx := slices.IndexFunc(sites, func(site v2.ModelCluster) bool { return site.ID.Value == s.siteId() })
+		require.NotEqual(t, -1, idx, "site %q must be listed", s.siteId())
+
+		site, err := api.Read(ctx, s.siteId())
+		require.NoError(t, err)
+		require.Equal(t, sites[idx].ID, site.ID)
+		require.Equal(t, sites[idx].DisplayName, site.DisplayName)
+	})
+
+	t.Run("read returns 404 for missing site", func(t *testing.T) {
+		_, err := newAPI(t).Read(ctx, s.name())
+		requireStatus(t, err, http.StatusNotFound)
+	})
+
+	t.Run("list plans", func(t *testing.T) {
+		_, err := newAPI(t).ListPlans(ctx)
+		require.NoError(t, err)
+	})
+}
+
+// RunBucketAPI BucketAPIの適合性テストを実行する
+func (s Suite) RunBucketAPI(t *testing.T) {
+	ctx := context.Background()
+	newAPI := func(t *testing.T) objectstorage.BucketAPI {
+		api, err := s.Factory(t).Buckets(s.siteId())
+		require.NoError(t, err)
+		return api
+	}
+
+	t.Run("create, list and delete", func(t *testing.T) {
+		api := newAPI(t)
+		name := s.name()
+		created, err := api.Create(ctx, &objectstorage.BucketCreateParams{SiteId: s.siteId(), Bucket: name})
+		require.NoError(t, err)
+		t.Cleanup(func() { cleanup(t, "bucket "+name, api.Delete(context.Background(), name)) })
+		require.Equal(t, name, created.Name.Value)
+		if created.ClusterID.Set {
+			require.Equal(t, s.siteId(), created.ClusterID.Value)
+		}
+		require.Contains(t, bucketNames(t, api), name)
+
+		require.NoError(t, api.Delete(ctx, name))
+		require.NotContains(t, bucketNames(t, api), name)
+
+		// 存在しないバケットの削除は成功するか404となる
+		requireDeleted(t, api.Delete(ctx, name))
+	})
+
+	t.Run("create returns 409 for duplicate name", func(t *testing.T) {
+		api := newAPI(t)
+		name := s.bucket(t, api, s.siteId())
+		_, err := api.Create(ctx, &objectstorage.BucketCreateParams{SiteId: s.siteId(), Bucket: name})
+		requireStatus(t, err, http.StatusConflict)
+		require.Equal(t, 1, countOf(bucketNames(t, api), name))
+	})
+
+	t.Run("create rejects invalid name", func(t *testing.T) {
+		api := newAPI(t)
+		name := "0" + s.name()
+		_, err := api.Create(ctx, &objectstorage.BucketCreateParams{SiteId: s.siteId(), Bucket: name})
+		require.Error(t, err)
+		// クライアント側の検証で弾かれた場合はステータスコードを持たない
+		if code := objectstorage.StatusCode(err); code != 0 {
+			require.Equal(t, http.StatusBadRequest, code, err.Error())
+		}
+		require.NotContains(t, bucketNames(t, api), name)
+	})
+}
+
+// RunBucketExtraAPI BucketExtraAPIの適合性テストを実行する
+func (s Suite) RunBucketExtraAPI(t *testing.T) {
+	ctx := context.Background()
+	newAPI := func(t *testing.T) (objectstorage.Backend, objectstorage.BucketExtraAPI, string) {
+		backend := s.Factory(t)
+		buckets, err := backend.Buckets(s.siteId())
+		require.NoError(t, err)
+		bucket := s.bucket(t, buckets, s.siteId())
+		api, err := backend.BucketExtra(s.siteId(), bucket)
+		require.NoError(t, err)
+		return backend, api, bucket
+	}
+
+	t.Run("read usage, quota and penalty", func(t *testing.T) {
+		_, api, _ := newAPI(t)
+		_, err := api.ReadUsage(ctx)
+		require.NoError(t, err)
+		_, err = api.ReadQuota(ctx)
+		require.NoError(t, err)
+		_, err = api.ReadPenalty(ctx)
+		require.NoError(t, err)
+	})
+
+	t.Run("read returns 404 for missing bucket", func(t *testing.T) {
+		api, err := s.Factory(t).BucketExtra(s.siteId(), s.name())
+		require.NoError(t, err)
+		_, err = api.ReadUsage(ctx)
+		requireStatus(t, err, http.StatusNotFound)
+		_, err = api.ReadQuota(ctx)
+		requireStatus(t, err, http.StatusNotFound)
+	})
+
+	t.Run("encryption", func(t *testing.T) {
+		_, api, _ := newAPI(t)
+		enc, err := api.ReadEncryption(ctx)
+		require.NoError(t, err)
+		require.Empty(t, enc.KmsKeyID.Value, "encryption must not be configured on a new bucket")
+
+		if s.KMSKeyID == "" {
+			t.Skip("KMSKeyID is not specified")
+		}
+		require.NoError(t, api.EnableEncryption(ctx, s.KMSKeyID))
+		enc, err = api.ReadEncryption(ctx)
+		require.NoError(t, err)
+		require.Equal(t, s.KMSKeyID, string(enc.KmsKeyID.Value))
+
+		require.NoError(t, api.DisableEncryption(ctx))
+		enc, err = api.ReadEncryption(ctx)
+		require.NoError(t, err)
+		require.Empty(t, enc.KmsKeyID.Value)
+	})
+
+	t.Run("replication", func(t *testing.T) {
+		backend, api, _ := newAPI(t)
+		_, err := api.ReadReplication(ctx)
+		requireStatus(t, err, http.StatusNotFound)
+
+		if s.ReplicationSiteID == "" {
+			t.Skip("ReplicationSiteID is not specified")
+		}
+		dstBuckets, err := backend.Buckets(s.ReplicationSiteID)
+		require.NoError(t, err)
+		dst := s.bucket(t, dstBuckets, s.ReplicationSiteID)
+
+		rep, err := api.EnableReplication(ctx, dst)
+		require.NoError(t, err)
+		t.Cleanup(func() { cleanup(t, "replication", api.DisableReplication(context.Background())) })
+		require.Equal(t, dst, rep.DestBucket.Name.Value)
+
+		read, err := api.ReadReplication(ctx)
+		require.NoError(t, err)
+		require.Equal(t, dst, read.DestBucket.Name.Value)
+
+		require.NoError(t, api.DisableReplication(ctx))
+		_, err = api.ReadReplication(ctx)
+		requireStatus(t, err, http.StatusNotFound)
+	})
+}
+
+// RunAccountAPI AccountAPIの適合性テストを実行する
+//
+// 対象のサイトではアカウントが作成済みである必要がある
+func (s Suite) RunAccountAPI(t *testing.T) {
+	ctx := context.Background()
+	newAPI := func(t *testing.T) objectstorage.AccountAPI {
+		api, err := s.Factory(t).Accounts(s.siteId())
+		require.NoError(t, err)
+		return api
+	}
+
+	t.Run("create returns 409 for existing account", func(t *testing.T) {
+		api := newAPI(t)
+		_, err := api.Read(ctx)
+		require.NoError(t, err)
+		_, err = api.Create(ctx)
+		requireStatus(t, err, http.StatusConflict)
+	})
+
+	t.Run("access keys", func(t *testing.T) {
+		api := newAPI(t)
+		key, err := api.CreateAccessKey(ctx)
+		require.NoError(t, err)
+		keyId := string(key.ID.Value)
+		t.Cleanup(func() { cleanup(t, "access key "+keyId, api.DeleteAccessKey(context.Background(), keyId)) })
+		require.NotEmpty(t, keyId)
+		// シークレットは作成時のみ返される
+		require.NotEmpty(t, key.Secret.Value)
+
+		read, err := api.ReadAccessKey(ctx, keyId)
+		require.NoError(t, err)
+		require.Equal(t, key.ID, read.ID)
+		require.Empty(t, read.Secret.Value, "secret must only be returned on create")
+
+		keys, err := api.ListAccessKeys(ctx)
+		require.NoError(t, err)
+		idx := slices.IndexFunc(keys, func(k v2.AccountKeysDataItem) bool { return k.ID == key.ID })
+		require.NotEqual(t, -1, idx, "created key must be listed")
+		for _, k := range keys {
+			require.Empty(t, k.Secret.Value, "secret must only be returned on create")
+		}
+
+		require.NoError(t, api.DeleteAccessKey(ctx, keyId))
+		_, err = api.ReadAccessKey(ctx, keyId)
+		requireStatus(t, err, http.StatusNotFound)
+		keys, err = api.ListAccessKeys(ctx)
+		require.NoError(t, err)
+		require.False(t, slices.ContainsFunc(keys, func(k v2.AccountKeysDataItem) bool { return k.ID == key.ID }))
+
+		requireDeleted(t, api.DeleteAccessKey(ctx, keyId))
+	})
+}
+
+// RunPermissionsAPI PermissionsAPIの適合性テストを実行する
+func (s Suite) RunPermissionsAPI(t *testing.T) {
+	ctx := context.Background()
+	newAPI := func(t *testing.T) (objectstorage.PermissionsAPI, string) {
+		backend := s.Factory(t)
+		buckets, err := backend.Buckets(s.siteId())
+		require.NoError(t, err)
+		bucket := s.bucket(t, buckets, s.siteId())
+		api, err := backend.Permissions(s.siteId())
+		require.NoError(t, err)
+		return api, bucket
+	}
+	// create 作成したパーミッションのIDを返す。削除はt.Cleanupで行う
+	create := func(t *testing.T, api objectstorage.PermissionsAPI, displayName string, controls v2.BucketControls) (*v2.PermissionData, string) {
+		permission, err := api.Create(ctx, displayName, controls)
+		require.NoError(t, err)
+		id := strconv.FormatInt(int64(permission.ID.Value), 10)
+		t.Cleanup(func() { cleanup(t, "permission "+id, api.Delete(context.Background(), id)) })
+		return permission, id
+	}
+
+	t.Run("create, read, update and delete", func(t *testing.T) {
+		api, bucket := newAPI(t)
+		name := s.name()
+		created, id := create(t, api, name, suiteControls(bucket, false))
+		require.Equal(t, name, string(created.DisplayName.Value))
+		requireControls(t, suiteControls(bucket, false), created.BucketControls)
+
+		read, err := api.Read(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, created.ID, read.ID)
+		require.Equal(t, created.DisplayName, read.DisplayName)
+		requireControls(t, created.BucketControls, read.BucketControls)
+
+		list, err := api.List(ctx)
+		require.NoError(t, err)
+		require.True(t, slices.ContainsFunc(list, func(p v2.PermissionsDataItem) bool { return p.ID == created.ID }), "created permission must be listed")
+
+		updated, err := api.Update(ctx, id, name+"-updated", suiteControls(bucket, true))
+		require.NoError(t, err)
+		require.Equal(t, name+"-updated", string(updated.DisplayName.Value))
+		read, err = api.Read(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, name+"-updated", string(read.DisplayName.Value))
+		requireControls(t, suiteControls(bucket, true), read.BucketControls)
+
+		require.NoError(t, api.Delete(ctx, id))
+		_, err = api.Read(ctx, id)
+		requireStatus(t, err, http.StatusNotFound)
+		_, err = api.Update(ctx, id, name, suiteControls(bucket, false))
+		requireStatus(t, err, http.StatusNotFound)
+		list, err = api.List(ctx)
+		require.NoError(t, err)
+		require.False(t, slices.ContainsFunc(list, func(p v2.PermissionsDataItem) bool { return p.ID == created.ID }))
+
+		requireDeleted(t, api.Delete(ctx, id))
+	})
+
+	t.Run("create returns 404 for missing bucket", func(t *testing.T) {
+		api, _ := newAPI(t)
+		_, err := api.Create(ctx, s.name(), suiteControls(s.name(), false))
+		requireStatus(t, err, http.StatusNotFound)
+	})
+
+	t.Run("access keys", func(t *testing.T) {
+		api, bucket := newAPI(t)
+		_, id := create(t, api, s.name(), suiteControls(bucket, false))
+
+		key, err := api.CreateAccessKey(ctx, id)
+		require.NoError(t, err)
+		keyId := string(key.ID.Value)
+		t.Cleanup(func() { cleanup(t, "access key "+keyId, api.DeleteAccessKey(context.Background(), id, keyId)) })
+		require.NotEmpty(t, keyId)
+		// シークレットは作成時のみ返される
+		require.NotEmpty(t, key.Secret.Value)
+
+		read, err := api.ReadAccessKey(ctx, id, keyId)
+		require.NoError(t, err)
+		require.Equal(t, key.ID, read.ID)
+		require.Empty(t, read.Secret.Value, "secret must only be returned on create")
+
+		keys, err := api.ListAccessKeys(ctx, id)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		require.Equal(t, key.ID, keys[0].ID)
+		require.Empty(t, keys[0].Secret.Value, "secret must only be returned on create")
+
+		require.NoError(t, api.DeleteAccessKey(ctx, id, keyId))
+		_, err = api.ReadAccessKey(ctx, id, keyId)
+		requireStatus(t, err, http.StatusNotFound)
+		keys, err = api.ListAccessKeys(ctx, id)
+		require.NoError(t, err)
+		require.Empty(t, keys)
+
+		requireDeleted(t, api.DeleteAccessKey(ctx, id, keyId))
+	})
+}
+
+// RunSiteStatusAPI SiteStatusAPIの適合性テストを実行する
+func (s Suite) RunSiteStatusAPI(t *testing.T) {
+	ctx := context.Background()
+	newAPI := func(t *testing.T) objectstorage.SiteStatusAPI {
+		api, err := s.Factory(t).SiteStatus(s.siteId())
+		require.NoError(t, err)
+		return api
+	}
+
+	t.Run("read status and quota", func(t *testing.T) {
+		api := newAPI(t)
+		_, err := api.Read(ctx)
+		require.NoError(t, err)
+		quota, err := api.ReadQuota(ctx)
+		require.NoError(t, err)
+		require.Positive(t, quota.NumBuckets.Value)
+	})
+
+	t.Run("read bucket metering", func(t *testing.T) {
+		backend := s.Factory(t)
+		buckets, err := backend.Buckets(s.siteId())
+		require.NoError(t, err)
+		bucket := s.bucket(t, buckets, s.siteId())
+		api, err := backend.SiteStatus(s.siteId())
+		require.NoError(t, err)
+
+		to := time.Now()
+		_, err = api.ReadBucketMetering(ctx, bucket, to.AddDate(0, 0, -1), to)
+		require.NoError(t, err)
+	})
+}
+
+func (s Suite) siteId() string {
+	if s.SiteID == "" {
+		return "isk01"
+	}
+	return s.SiteID
+}
+
+// name 他のテストと衝突しない名前を返す。バケット名としても有効な形式となる
+func (s Suite) name() string {
+	if s.NewName != nil {
+		return s.NewName()
+	}
+	prefix := s.NamePrefix
+	if prefix == "" {
+		prefix = "objectstoragetest-"
+	}
+	return fmt.Sprintf("%s%016x", prefix, rand.Uint64()) //nolint:gosec
+}
+
+// bucket バケットを作成して名前を返す。削除はt.Cleanupで行う
+func (s Suite) bucket(t *testing.T, api objectstorage.BucketAPI, siteId string) string {
+	t.Helper()
+	name := s.name()
+	_, err := api.Create(context.Background(), &objectstorage.BucketCreateParams{SiteId: siteId, Bucket: name})
+	require.NoError(t, err)
+	t.Cleanup(func() { cleanup(t, "bucket "+name, api.Delete(context.Background(), name)) })
+	return name
+}
+
+func suiteControls(bucket string, canWrite bool) v2.BucketControls {
+	return v2.BucketControls{{
+		BucketName: v2.NewOptBucketName(v2.BucketName(bucket)),
+		CanRead:    v2.NewOptCanRead(true),
+		CanWrite:   v2.NewOptCanWrite(v2.CanWrite(canWrite)),
+	}}
+}
+
+// requireControls 作成日時を除いてBucketControlsを比較する
+func requireControls(t *testing.T, expected, actual v2.BucketControls) {
+	t.Helper()
+	strip := func(controls v2.BucketControls) v2.BucketControls {
+		res := slices.Clone(controls)
+		for i := range res {
+			res[i].CreatedAt = v2.OptCreatedAt{}
+		}
+		return res
+	}
+	require.Equal(t, strip(expected), strip(actual))
+}
+
+func bucketNames(t *testing.T, api objectstorage.BucketAPI) []string {
+	t.Helper()
+	list, err := api.List(context.Background())
+	require.NoError(t, err)
+	var names []string
+	for _, b := range list {
+		names = append(names, string(b.Name))
+	}
+	return names
+}
+
+func countOf(values []string, v string) int {
+	n := 0
+	for _, value := range values {
+		if value == v {
+			n++
+		}
+	}
+	return n
+}
+
+func requireStatus(t *testing.T, err error, code int) {
+	t.Helper()
+	require.Error(t, err)
+	require.Equal(t, code, objectstorage.StatusCode(err), "unexpected error: %s", err)
+}
+
+// requireDeleted 削除済みのリソースの削除は成功するか404となる
+func requireDeleted(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		require.True(t, saclient.IsNotFoundError(err), "deleting a deleted resource must succeed or return 404: %s", err)
+	}
+}
+
+// cleanup 後片付けの失敗はテストを失敗させずにログに記録する
+func cleanup(t *testing.T, resource string, err error) {
+	if err != nil && !saclient.IsNotFoundError(err) {
+		t.Logf("failed to clean up %s: %s", resource, err)
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstoragetest
+
+import (
+	"context"
+	"testing"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuite_Fake(t *testing.T) {
+	Suite{
+		Factory:           func(t *testing.T) objectstorage.Backend { return NewFake() },
+		ReplicationSiteID: "tky01",
+		KMSKeyID:          "113600000000",
+	}.Run(t)
+}
+
+// interceptedBackend BucketAPIのみをデコレートするBackend
+type interceptedBackend struct {
+	objectstorage.Backend
+	calls *[]string
+}
+
+func (b interceptedBackend) Buckets(siteId string) (objectstorage.BucketAPI, error) {
+	api, err := b.Backend.Buckets(siteId)
+	if err != nil {
+		return nil, err
+	}
+	return objectstorage.InterceptBucketAPI(api, siteId, func(ctx context.Context, call *objectstorage.Call, invoke func(ctx context.Context) error) error {
+		*b.calls = append(*b.calls, call.Method)
+		return invoke(ctx)
+	}), nil
+}
+
+func TestSuite_Decorator(t *testing.T) {
+	var calls []string
+	RunBucketAPISuite(t, func(t *testing.T) objectstorage.Backend {
+		return interceptedBackend{Backend: NewFake(), calls: &calls}
+	})
+	require.Contains(t, calls, "Buckets.Create")
+}
@@ -0,0 +1,138 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstorage
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+)
+
+// Operation APIのオペレーションとHTTPメソッド/パスの対応
+type Operation struct {
+	Name   v2.OperationName
+	Method string
+	// Path "/buckets/{name}/encryption"のようなパスのテンプレート
+	Path string
+}
+
+// ReadOnly 参照のみのオペレーションか
+func (o *Operation) ReadOnly() bool {
+	return o.Method == http.MethodGet
+}
+
+// Operations APIの全オペレーション
+var Operations = []*Operation{
+	{Name: v2.CreateAccountOperation, Method: http.MethodPost, Path: "/account"},
+	{Name: v2.CreateAccountKeyOperation, Method: http.MethodPost, Path: "/account/keys"},
+	{Name: v2.CreateBucketOperation, Method: http.MethodPut, Path: "/buckets/{name}"},
+	{Name: v2.CreatePermissionOperation, Method: http.MethodPost, Path: "/permissions"},
+	{Name: v2.CreatePermissionKeyOperation, Method: http.MethodPost, Path: "/permissions/{id}/keys"},
+	{Name: v2.DeleteAccountOperation, Method: http.MethodDelete, Path: "/account"},
+	{Name: v2.DeleteAccountKeyOperation, Method: http.MethodDelete, Path: "/account/keys/{id}"},
+	{Name: v2.DeleteBucketOperation, Method: http.MethodDelete, Path: "/buckets/{name}"},
+	{Name: v2.DeleteBucketEncryptionOperation, Method: http.MethodDelete, Path: "/buckets/{name}/encryption"},
+	{Name: v2.DeleteBucketReplicationOperation, Method: http.MethodDelete, Path: "/buckets/{name}/replication"},
+	{Name: v2.DeletePermissionOperation, Method: http.MethodDelete, Path: "/permissions/{id}"},
+	{Name: v2.DeletePermissionKeyOperation, Method: http.MethodDelete, Path: "/permissions/{id}/keys/{key_id}"},
+	{Name: v2.GetAccountOperation, Method: http.MethodGet, Path: "/account"},
+	{Name: v2.GetAccountKeyOperation, Method: http.MethodGet, Path: "/account/keys/{id}"},
+	{Name: v2.GetAccountKeysOperation, Method: http.MethodGet, Path: "/account/keys"},
+	{Name: v2.GetBucketEncryptionOperation, Method: http.MethodGet, Path: "/buckets/{name}/encryption"},
+	{Name: v2.GetBucketMeteringOperation, Method: http.MethodGet, Path: "/metering/buckets/{name}"},
+	{Name: v2.GetBucketPenaltyOperation, Method: http.MethodGet, Path: "/buckets/{name}/penalty"},
+	{Name: v2.GetBucketPlanOperation, Method: http.MethodGet, Path: "/buckets/{name}/plan"},
+	{Name: v2.GetBucketQuotaOperation, Method: http.MethodGet, Path: "/buckets/{name}/quota"},
+	{Name: v2.GetBucketReplicableTargetsOperation, Method: http.MethodGet, Path: "/buckets/{name}/replicable-targets"},
+	{Name: v2.GetBucketReplicationOperation, Method: http.MethodGet, Path: "/buckets/{name}/replication"},
+	{Name: v2.GetBucketUsageOperation, Method: http.MethodGet, Path: "/buckets/{name}/usage"},
+	{Name: v2.GetClusterOperation, Method: http.MethodGet, Path: "/clusters/{id}"},
+	{Name: v2.GetClustersOperation, Method: http.MethodGet, Path: "/clusters"},
+	{Name: v2.GetPermissionOperation, Method: http.MethodGet, Path: "/permissions/{id}"},
+	{Name: v2.GetPermissionKeyOperation, Method: http.MethodGet, Path: "/permissions/{id}/keys/{key_id}"},
+	{Name: v2.GetPermissionKeysOperation, Method: http.MethodGet, Path: "/permissions/{id}/keys"},
+	{Name: v2.GetPermissionsOperation, Method: http.MethodGet, Path: "/permissions"},
+	{Name: v2.GetPlansOperation, Method: http.MethodGet, Path: "/plans"},
+	{Name: v2.GetQuotaOperation, Method: http.MethodGet, Path: "/quota"},
+	{Name: v2.GetStatusOperation, Method: http.MethodGet, Path: "/status"},
+	{Name: v2.ListBucketsOperation, Method: http.MethodGet, Path: "/buckets"},
+	{Name: v2.PostBucketReplicationOperation, Method: http.MethodPost, Path: "/buckets/{name}/replication"},
+	{Name: v2.PutBucketEncryptionOperation, Method: http.MethodPut, Path: "/buckets/{name}/encryption"},
+	{Name: v2.PutBucketPlanOperation, Method: http.MethodPut, Path: "/buckets/{name}/plan"},
+	{Name: v2.UpdatePermissionOperation, Method: http.MethodPut, Path: "/permissions/{id}"},
+}
+
+// OperationByName 名前からOperationを返す
+func OperationByName(name v2.OperationName) (*Operation, bool) {
+	for _, op := range Operations {
+		if op.Name == name {
+			return op, true
+		}
+	}
+	return nil, false
+}
+
+// RoutedRequest ResolveOperationでHTTPリクエストを解決した結果
+type RoutedRequest struct {
+	Operation *Operation
+	// SiteID サイトのAPI(/{siteId}/v2)へのリクエストの場合のサイトID。フェデレーションAPIの場合は空
+	SiteID string
+	// Params パスパラメータ。"name"(バケット名)、"id"、"key_id"
+	Params map[string]string
+}
+
+// Bucket パスパラメータのバケット名
+func (r *RoutedRequest) Bucket() string {
+	return r.Params["name"]
+}
+
+// ResolveOperation HTTPメソッドとURLからリクエストされたOperationを解決する
+//
+// URLはDefaultAPIRootURLのようなAPIルートに続けて"/fed/v1"もしくは"/{siteId}/v2"を含むものを想定している
+func ResolveOperation(method string, u *url.URL) (*RoutedRequest, bool) {
+	segments := strings.Split(strings.Trim(u.EscapedPath(), "/"), "/")
+	for i := 1; i < len(segments); i++ {
+		var siteId string
+		switch {
+		case segments[i] == "v1" && segments[i-1] == "fed":
+		case segments[i] == "v2":
+			siteId = segments[i-1]
+		default:
+			continue
+		}
+		for _, op := range Operations {
+			if op.Method != method {
+				continue
+			}
+			if params, ok := matchPath(op.Path, segments[i+1:]); ok {
+				return &RoutedRequest{Operation: op, SiteID: siteId, Params: params}, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func matchPath(template string, segments []string) (map[string]string, bool) {
+	parts := strings.Split(strings.TrimPrefix(template, "/"), "/")
+	if len(parts) != len(segments) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, part := range parts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			v, err := url.PathUnescape(segments[i])
+			if err != nil {
+				return nil, false
+			}
+			params[part[1:len(part)-1]] = v
+			continue
+		}
+		if part != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
@@ -0,0 +1,814 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package policy
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Expr コンパイル済みの式
+//
+// 式はリソースの項目とvarsを参照でき、値はnull、真偽値、数値、文字列、リストおよびマップのいずれかとなる。
+// 以下の演算子と関数を利用できる
+//
+//   - 論理: && || !
+//   - 比較: == != < <= > >=(数値同士もしくは文字列同士)
+//   - 算術: + - * / %(+は文字列の連結にも用いる)
+//   - 包含: x in y(yがリストの場合は要素、文字列の場合は部分文字列、マップの場合はキー)
+//   - 参照: a.b a[i] a["b"](nullの項目の参照はnull)
+//   - 関数: len(x) lower(s) upper(s) contains(x, y) startsWith(s, p) endsWith(s, p) matches(s, re)
+//
+// startsWith、endsWithおよびmatchesはpやreにリストを指定すると、いずれかに一致するかを返す
+type Expr struct {
+	src  string
+	root node
+	// vars 式が参照する変数の名前
+	vars []string
+}
+
+// ExprError 式の構文エラー
+type ExprError struct {
+	Expr string
+	// Pos エラーを検出した位置(バイト単位)
+	Pos int
+	Msg string
+}
+
+func (e *ExprError) Error() string {
+	return fmt.Sprintf("%s at position %d in %q", e.Msg, e.Pos, e.Expr)
+}
+
+// CompileExpr 式をコンパイルする
+func CompileExpr(src string) (*Expr, error) {
+	p := &parser{lexer: lexer{src: src}}
+	p.next()
+	root, err := p.parseExpr()
+	if err == nil && p.tok.kind != tokEOF {
+		err = p.errorf("unexpected %s", p.tok)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Expr{src: src, root: root, vars: p.vars}, nil
+}
+
+// String 式のソースを返す
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval envの変数を参照して式を評価する
+func (e *Expr) Eval(env map[string]any) (any, error) {
+	v, err := e.root.eval(env)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", e.src, err)
+	}
+	return v, nil
+}
+
+// EvalBool 式を評価し、結果が真偽値でない場合はエラーを返す
+func (e *Expr) EvalBool(env map[string]any) (bool, error) {
+	v, err := e.Eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("%q: result is %s, not bool", e.src, typeName(v))
+	}
+	return b, nil
+}
+
+// lexer
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+	// value 数値もしくは文字列のリテラルの値
+	value any
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+type lexer struct {
+	src string
+	pos int
+}
+
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "!", "<", ">", "+", "-", "*", "/", "%", "(", ")", "[", "]", ",", "."}
+
+func (l *lexer) scan() (token, error) {
+	for l.pos < len(l.src) {
+		r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		l.pos += size
+	}
+	start := l.pos
+	if start >= len(l.src) {
+		return token{kind: tokEOF, pos: start}, nil
+	}
+	rest := l.src[start:]
+	c := rest[0]
+	switch {
+	case c >= '0' && c <= '9':
+		end := start
+		for end < len(l.src) && (l.src[end] >= '0' && l.src[end] <= '9' || l.src[end] == '.') {
+			end++
+		}
+		text := l.src[start:end]
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return token{}, &ExprError{Expr: l.src, Pos: start, Msg: fmt.Sprintf("invalid number %q", text)}
+		}
+		l.pos = end
+		return token{kind: tokNumber, text: text, pos: start, value: f}, nil
+	case c == '"' || c == '\'':
+		return l.scanString(c)
+	case c == '_' || unicode.IsLetter(rune(c)):
+		end := start
+		for end < len(l.src) {
+			r, size := utf8.DecodeRuneInString(l.src[end:])
+			if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				break
+			}
+			end += size
+		}
+		l.pos = end
+		return token{kind: tokIdent, text: l.src[start:end], pos: start}, nil
+	}
+	for _, op := range operators {
+		if strings.HasPrefix(rest, op) {
+			l.pos += len(op)
+			return token{kind: tokOp, text: op, pos: start}, nil
+		}
+	}
+	return token{}, &ExprError{Expr: l.src, Pos: start, Msg: fmt.Sprintf("unexpected character %q", rest[0])}
+}
+
+// scanString 引用符で囲まれた文字列を読み込む。\と引用符のみエスケープできる
+func (l *lexer) scanString(quote byte) (token, error) {
+	start := l.pos
+	var sb strings.Builder
+	for i := start + 1; i < len(l.src); i++ {
+		switch c := l.src[i]; c {
+		case '\\':
+			if i+1 < len(l.src) && (l.src[i+1] == '\\' || l.src[i+1] == quote) {
+				i++
+				sb.WriteByte(l.src[i])
+				continue
+			}
+			sb.WriteByte(c)
+		case quote:
+			l.pos = i + 1
+			return token{kind: tokString, text: l.src[start:l.pos], pos: start, value: sb.String()}, nil
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return token{}, &ExprError{Expr: l.src, Pos: start, Msg: "unterminated string"}
+}
+
+// parser
+
+type parser struct {
+	lexer lexer
+	tok   token
+	err   error
+	vars  []string
+}
+
+func (p *parser) next() {
+	if p.err != nil {
+		return
+	}
+	p.tok, p.err = p.lexer.scan()
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	if p.err != nil {
+		return p.err
+	}
+	return &ExprError{Expr: p.lexer.src, Pos: p.tok.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) isOp(ops ...string) bool {
+	return p.err == nil && p.tok.kind == tokOp && slices.Contains(ops, p.tok.text)
+}
+
+func (p *parser) expect(op string) error {
+	if !p.isOp(op) {
+		return p.errorf("expected %q but got %s", op, p.tok)
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) parseExpr() (node, error) {
+	return p.parseBinary(0)
+}
+
+// precedences 二項演算子の優先順位。後ろほど強く結合する
+var precedences = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">=", "in"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+// comparisonLevel precedencesでの比較演算子の位置
+const comparisonLevel = 2
+
+func (p *parser) parseBinary(level int) (node, error) {
+	if level == len(precedences) {
+		return p.parseUnary()
+	}
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.binaryOp(level)
+		if !ok {
+			return left, p.err
+		}
+		p.next()
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+		// 比較演算子は連結しない
+		if level == comparisonLevel {
+			return left, nil
+		}
+	}
+}
+
+// binaryOp 現在のトークンがlevelの二項演算子の場合はそれを返す。inは識別子として読み込まれる
+func (p *parser) binaryOp(level int) (string, bool) {
+	if p.err != nil || p.tok.kind != tokOp && (p.tok.kind != tokIdent || p.tok.text != "in") {
+		return "", false
+	}
+	return p.tok.text, slices.Contains(precedences[level], p.tok.text)
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.isOp("!", "-") {
+		op := p.tok.text
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op, operand: operand}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *parser) parsePostfix() (node, error) {
+	n, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.isOp("."):
+			p.next()
+			if p.tok.kind != tokIdent {
+				return nil, p.errorf("expected a field name but got %s", p.tok)
+			}
+			n = &indexNode{target: n, index: &literalNode{value: p.tok.text}}
+			p.next()
+		case p.isOp("["):
+			p.next()
+			index, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			n = &indexNode{target: n, index: index}
+		default:
+			return n, p.err
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	tok := p.tok
+	switch tok.kind {
+	case tokNumber, tokString:
+		p.next()
+		return &literalNode{value: tok.value}, nil
+	case tokIdent:
+		p.next()
+		switch tok.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		}
+		if !p.isOp("(") {
+			if !slices.Contains(p.vars, tok.text) {
+				p.vars = append(p.vars, tok.text)
+			}
+			return &varNode{name: tok.text}, nil
+		}
+		fn, ok := functions[tok.text]
+		if !ok {
+			return nil, &ExprError{Expr: p.lexer.src, Pos: tok.pos, Msg: fmt.Sprintf("unknown function %q", tok.text)}
+		}
+		p.next()
+		var args []node
+		for !p.isOp(")") {
+			if len(args) > 0 {
+				if err := p.expect(","); err != nil {
+					return nil, err
+				}
+			}
+			arg, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+		}
+		p.next()
+		if len(args) != fn.arity {
+			return nil, &ExprError{Expr: p.lexer.src, Pos: tok.pos, Msg: fmt.Sprintf("%s takes %d arguments but got %d", tok.text, fn.arity, len(args))}
+		}
+		return &callNode{name: tok.text, fn: fn.call, args: args}, nil
+	case tokOp:
+		switch tok.text {
+		case "(":
+			p.next()
+			n, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+		case "[":
+			p.next()
+			list := &listNode{}
+			for !p.isOp("]") {
+				if len(list.elems) > 0 {
+					if err := p.expect(","); err != nil {
+						return nil, err
+					}
+				}
+				elem, err := p.parseExpr()
+				if err != nil {
+					return nil, err
+				}
+				list.elems = append(list.elems, elem)
+			}
+			p.next()
+			return list, p.err
+		}
+	}
+	return nil, p.errorf("unexpected %s", tok)
+}
+
+// evaluation
+
+type node interface {
+	eval(env map[string]any) (any, error)
+}
+
+type literalNode struct {
+	value any
+}
+
+func (n *literalNode) eval(map[string]any) (any, error) {
+	return n.value, nil
+}
+
+type varNode struct {
+	name string
+}
+
+func (n *varNode) eval(env map[string]any) (any, error) {
+	v, ok := env[n.name]
+	if !ok {
+		return nil, fmt.Errorf("undefined variable %q", n.name)
+	}
+	return normalize(v), nil
+}
+
+type listNode struct {
+	elems []node
+}
+
+func (n *listNode) eval(env map[string]any) (any, error) {
+	res := make([]any, len(n.elems))
+	for i, elem := range n.elems {
+		v, err := elem.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = v
+	}
+	return res, nil
+}
+
+type indexNode struct {
+	target node
+	index  node
+}
+
+func (n *indexNode) eval(env map[string]any) (any, error) {
+	target, err := n.target.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	index, err := n.index.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch target := target.(type) {
+	case nil:
+		return nil, nil
+	case map[string]any:
+		key, ok := index.(string)
+		if !ok {
+			return nil, fmt.Errorf("map index must be string, not %s", typeName(index))
+		}
+		return target[key], nil
+	case []any:
+		f, ok := index.(float64)
+		if !ok || f != math.Trunc(f) {
+			return nil, fmt.Errorf("list index must be integer, not %v", index)
+		}
+		// 大きな値や無限大はintに変換できないため、範囲の確認は変換前に行う
+		if f < 0 || f >= float64(len(target)) {
+			return nil, nil
+		}
+		return target[int(f)], nil
+	}
+	return nil, fmt.Errorf("cannot index %s", typeName(target))
+}
+
+type unaryNode struct {
+	op      string
+	operand node
+}
+
+func (n *unaryNode) eval(env map[string]any) (any, error) {
+	v, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operand of ! must be bool, not %s", typeName(v))
+		}
+		return !b, nil
+	}
+	f, ok := v.(float64)
+	if !ok {
+		return nil, fmt.Errorf("operand of - must be number, not %s", typeName(v))
+	}
+	return -f, nil
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+func (n *binaryNode) eval(env map[string]any) (any, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "&&" || n.op == "||" {
+		l, ok := left.(bool)
+		if !ok {
+			return nil, fmt.Errorf("left operand of %s must be bool, not %s", n.op, typeName(left))
+		}
+		if l == (n.op == "||") {
+			return l, nil
+		}
+		right, err := n.right.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		r, ok := right.(bool)
+		if !ok {
+			return nil, fmt.Errorf("right operand of %s must be bool, not %s", n.op, typeName(right))
+		}
+		return r, nil
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return reflect.DeepEqual(left, right), nil
+	case "!=":
+		return !reflect.DeepEqual(left, right), nil
+	case "in":
+		return contains(right, left)
+	}
+	if l, ok := left.(string); ok {
+		r, ok := right.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot apply %s to string and %s", n.op, typeName(right))
+		}
+		switch n.op {
+		case "+":
+			return l + r, nil
+		case "<":
+			return l < r, nil
+		case "<=":
+			return l <= r, nil
+		case ">":
+			return l > r, nil
+		case ">=":
+			return l >= r, nil
+		}
+		return nil, fmt.Errorf("cannot apply %s to strings", n.op)
+	}
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("cannot apply %s to %s and %s", n.op, typeName(left), typeName(right))
+	}
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Mod(l, r), nil
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	default: // ">="
+		return l >= r, nil
+	}
+}
+
+type callNode struct {
+	name string
+	fn   func(args []any) (any, error)
+	args []node
+}
+
+func (n *callNode) eval(env map[string]any) (any, error) {
+	args := make([]any, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	v, err := n.fn(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.name, err)
+	}
+	return v, nil
+}
+
+type function struct {
+	arity int
+	call  func(args []any) (any, error)
+}
+
+var functions = map[string]function{
+	"len": {1, func(args []any) (any, error) {
+		switch v := args[0].(type) {
+		case string:
+			return float64(utf8.RuneCountInString(v)), nil
+		case []any:
+			return float64(len(v)), nil
+		case map[string]any:
+			return float64(len(v)), nil
+		case nil:
+			return float64(0), nil
+		}
+		return nil, fmt.Errorf("cannot take length of %s", typeName(args[0]))
+	}},
+	"lower": {1, stringFunc(strings.ToLower)},
+	"upper": {1, stringFunc(strings.ToUpper)},
+	"contains": {2, func(args []any) (any, error) {
+		return contains(args[0], args[1])
+	}},
+	"startsWith": {2, matchFunc(func(s, prefix string) (bool, error) { return strings.HasPrefix(s, prefix), nil })},
+	"endsWith":   {2, matchFunc(func(s, suffix string) (bool, error) { return strings.HasSuffix(s, suffix), nil })},
+	"matches":    {2, matchFunc(matchRegexp)},
+}
+
+func stringFunc(f func(string) string) func(args []any) (any, error) {
+	return func(args []any) (any, error) {
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("argument must be string, not %s", typeName(args[0]))
+		}
+		return f(s), nil
+	}
+}
+
+// matchFunc 第2引数が文字列のリストの場合はいずれかに一致するかを返す関数を作成する
+func matchFunc(match func(s, pattern string) (bool, error)) func(args []any) (any, error) {
+	return func(args []any) (any, error) {
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("first argument must be string, not %s", typeName(args[0]))
+		}
+		patterns, ok := args[1].([]any)
+		if !ok {
+			patterns = []any{args[1]}
+		}
+		for _, pattern := range patterns {
+			p, ok := pattern.(string)
+			if !ok {
+				return nil, fmt.Errorf("second argument must be string or list of strings, not %s", typeName(pattern))
+			}
+			matched, err := match(s, p)
+			if err != nil || matched {
+				return matched, err
+			}
+		}
+		return false, nil
+	}
+}
+
+var (
+	regexpMu    sync.Mutex
+	regexpCache = map[string]*regexp.Regexp{}
+)
+
+// matchRegexp sがpatternの正規表現に一致するかを返す。コンパイルした正規表現はキャッシュする
+func matchRegexp(s, pattern string) (bool, error) {
+	regexpMu.Lock()
+	re, ok := regexpCache[pattern]
+	if !ok {
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			regexpMu.Unlock()
+			return false, err
+		}
+		regexpCache[pattern] = re
+	}
+	regexpMu.Unlock()
+	return re.MatchString(s), nil
+}
+
+// contains containerがvを含むかを返す
+func contains(container, v any) (any, error) {
+	switch c := container.(type) {
+	case []any:
+		return slices.ContainsFunc(c, func(e any) bool { return reflect.DeepEqual(e, v) }), nil
+	case string:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot search %s in string", typeName(v))
+		}
+		return strings.Contains(c, s), nil
+	case map[string]any:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("map key must be string, not %s", typeName(v))
+		}
+		_, found := c[s]
+		return found, nil
+	case nil:
+		return false, nil
+	}
+	return nil, fmt.Errorf("cannot search in %s", typeName(container))
+}
+
+// normalize Goの値を式で扱う値に変換する。数値はfloat64、リストは[]any、マップはmap[string]anyとなる
+func normalize(v any) any {
+	switch v := v.(type) {
+	case nil, bool, float64, string:
+		return v
+	case []any:
+		res := make([]any, len(v))
+		for i, e := range v {
+			res[i] = normalize(e)
+		}
+		return res
+	case map[string]any:
+		res := make(map[string]any, len(v))
+		for k, e := range v {
+			res[k] = normalize(e)
+		}
+		return res
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case []string:
+		res := make([]any, len(v))
+		for i, s := range v {
+			res[i] = s
+		}
+		return res
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return nil
+		}
+		return normalize(rv.Elem().Interface())
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.Slice, reflect.Array:
+		res := make([]any, rv.Len())
+		for i := range res {
+			res[i] = normalize(rv.Index(i).Interface())
+		}
+		return res
+	case reflect.Map:
+		res := make(map[string]any, rv.Len())
+		for iter := rv.MapRange(); iter.Next(); {
+			res[fmt.Sprint(iter.Key().Interface())] = normalize(iter.Value().Interface())
+		}
+		return res
+	}
+	return v
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "list"
+	case map[string]any:
+		return "map"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,104 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpr(t *testing.T) {
+	env := map[string]any{
+		"name":    "web-prod-1",
+		"count":   3,
+		"missing": nil,
+		"tags":    []string{"prod", "web"},
+		"vars":    map[string]any{"prefixes": []any{"web-", "data-"}, "max": 5},
+		"nested":  map[string]any{"site": "isk01"},
+	}
+	for src, want := range map[string]any{
+		`1 + 2 * 3`:                              float64(7),
+		`(1 + 2) * 3`:                            float64(9),
+		`-count + 10 % 4`:                        float64(-1),
+		`count <= vars.max && !(count > 3)`:      true,
+		`name == "web-prod-1" || missing.x`:      true,
+		`'it\'s' + " " + name`:                   "it's web-prod-1",
+		`"prod" in tags`:                         true,
+		`"prod" in name`:                         true,
+		`"site" in nested`:                       true,
+		`missing != null && missing.site`:        false,
+		`missing.site == null`:                   true,
+		`nested.site`:                            "isk01",
+		`nested["site"]`:                         "isk01",
+		`tags[1]`:                                "web",
+		`tags[5]`:                                nil,
+		`tags[99999999999999999999]`:             nil,
+		`tags[-99999999999999999999]`:            nil,
+		`len(tags) + len(name) + len(missing)`:   float64(12),
+		`upper(lower("AbC"))`:                    "ABC",
+		`startsWith(name, vars.prefixes)`:        true,
+		`endsWith(name, ["-2", "-3"])`:           false,
+		`matches(name, "^web-[a-z]+-[0-9]+$")`:   true,
+		`contains(tags, "web")`:                  true,
+		`[1, "a", null] == [1, "a", null]`:       true,
+		`"b" > "a" && 2 >= 2 && 1 != 2 && 1 < 2`: true,
+	} {
+		expr, err := CompileExpr(src)
+		require.NoError(t, err, src)
+		got, err := expr.Eval(env)
+		require.NoError(t, err, src)
+		require.Equal(t, want, got, src)
+	}
+
+	// 無限大の添字
+	big := strings.Repeat("9", 200)
+	expr, err := CompileExpr(`tags[` + big + ` * ` + big + `]`)
+	require.NoError(t, err)
+	got, err := expr.Eval(env)
+	require.NoError(t, err)
+	require.Nil(t, got)
+}
+
+func TestExpr_Errors(t *testing.T) {
+	for src, pos := range map[string]int{
+		`1 +`:             3,
+		`(1`:              2,
+		`"abc`:            0,
+		`a == b == c`:     7,
+		`unknown(1)`:      0,
+		`len(1, 2)`:       0,
+		`a.`:              2,
+		`a # b`:           2,
+		`[1, 2`:           5,
+		`startsWith(a b)`: 13,
+	} {
+		_, err := CompileExpr(src)
+		var e *ExprError
+		require.ErrorAs(t, err, &e, src)
+		require.Equal(t, pos, e.Pos, src)
+	}
+
+	for _, src := range []string{
+		`undefined`,
+		`1 + "a"`,
+		`!1`,
+		`1 && true`,
+		`1 / 0`,
+		`len(true)`,
+		`matches("a", "(")`,
+		`true.x`,
+	} {
+		expr, err := CompileExpr(src)
+		require.NoError(t, err, src)
+		_, err = expr.Eval(map[string]any{})
+		require.Error(t, err, src)
+	}
+
+	expr, err := CompileExpr(`1 + 1`)
+	require.NoError(t, err)
+	_, err = expr.EvalBool(nil)
+	require.ErrorContains(t, err, "not bool")
+}
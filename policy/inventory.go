@@ -0,0 +1,378 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/sacloud/saclient-go"
+)
+
+// Inventory ポリシーを評価する対象のリソースの一覧
+type Inventory struct {
+	// CollectedAt 収集した日時。アクセスキーの経過日数の基準となる
+	CollectedAt time.Time    `json:"collected_at"`
+	Buckets     []Bucket     `json:"buckets"`
+	Permissions []Permission `json:"permissions"`
+	Keys        []Key        `json:"keys"`
+}
+
+// Bucket バケットとその設定
+//
+// 取得に失敗した項目はnilとなり、その項目を参照するルールの評価はエラーとなる
+type Bucket struct {
+	SiteID string `json:"site_id"`
+	Name   string `json:"name"`
+	Plan   string `json:"plan,omitempty"`
+	// Encryption 暗号化の設定
+	Encryption *Encryption `json:"encryption,omitempty"`
+	// Replication レプリケーションの設定
+	Replication *Replication `json:"replication,omitempty"`
+	Usage       *Usage       `json:"usage,omitempty"`
+	Quota       *Usage       `json:"quota,omitempty"`
+}
+
+// Encryption バケットの暗号化の設定
+type Encryption struct {
+	// KMSKeyID 暗号化に用いるKMSキーのID。暗号化されていない場合は空
+	KMSKeyID string `json:"kms_key_id,omitempty"`
+}
+
+// Replication バケットのレプリケーションの設定
+type Replication struct {
+	// Configured レプリケーションが設定されているか。falseの場合は他の項目は空
+	Configured bool   `json:"configured"`
+	SiteID     string `json:"site_id,omitempty"`
+	Bucket     string `json:"bucket,omitempty"`
+	Status     string `json:"status,omitempty"`
+}
+
+// Usage バケットの使用量もしくは上限
+type Usage struct {
+	Objects int     `json:"objects"`
+	GiB     float64 `json:"gib"`
+}
+
+// Permission パーミッションとバケットごとの権限
+type Permission struct {
+	SiteID  string             `json:"site_id"`
+	ID      string             `json:"id"`
+	Name    string             `json:"name"`
+	Buckets []PermissionBucket `json:"buckets"`
+}
+
+// PermissionBucket パーミッションが権限を持つバケット
+type PermissionBucket struct {
+	Name  string `json:"name"`
+	Read  bool   `json:"read"`
+	Write bool   `json:"write"`
+}
+
+// KeyKind アクセスキーの種別
+type KeyKind string
+
+const (
+	// KeyKindAccount サイトアカウントのアクセスキー
+	KeyKindAccount KeyKind = "account"
+	// KeyKindPermission パーミッションのアクセスキー
+	KeyKindPermission KeyKind = "permission"
+)
+
+// Key アクセスキー。シークレットは含まない
+type Key struct {
+	SiteID string  `json:"site_id"`
+	Kind   KeyKind `json:"kind"`
+	ID     string  `json:"id"`
+	// PermissionID パーミッションのアクセスキーの場合のパーミッションのID
+	PermissionID string    `json:"permission_id,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// CollectOptions Collectのオプション
+type CollectOptions struct {
+	// SiteIDs 対象のサイトのID。空の場合はSiteAPI.Listで取得した全てのサイト
+	SiteIDs []string
+	// Now 現在時刻を返す関数。nilの場合はtime.Now
+	Now func() time.Time
+}
+
+// Collect バケット、パーミッション、アクセスキーを収集する
+//
+// 一部の取得に失敗した場合は、取得できた範囲のInventoryとともにエラーを返す
+func Collect(ctx context.Context, backend objectstorage.Backend, opts *CollectOptions) (*Inventory, error) {
+	if opts == nil {
+		opts = &CollectOptions{}
+	}
+	now := opts.Now
+	if now == nil {
+		now = time.Now
+	}
+	siteIds := opts.SiteIDs
+	if len(siteIds) == 0 {
+		var err error
+		if siteIds, err = objectstorage.ListSiteIDs(ctx, backend); err != nil {
+			return nil, err
+		}
+	}
+
+	inv := &Inventory{CollectedAt: now().UTC()}
+	var errs []error
+	details, err := objectstorage.ListDetailed(ctx, backend, &objectstorage.ListDetailedOptions{
+		SiteIDs: siteIds,
+		Fields:  []objectstorage.BucketDetailField{objectstorage.BucketDetailEncryption, objectstorage.BucketDetailReplication, objectstorage.BucketDetailUsage, objectstorage.BucketDetailQuota},
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, detail := range details {
+		inv.Buckets = append(inv.Buckets, newBucket(detail))
+		for field, err := range detail.Errors {
+			errs = append(errs, objectstorage.NewError(fmt.Sprintf("failed to read %s of bucket %s in site %s", field, detail.Bucket.Name, detail.SiteID), err))
+		}
+	}
+
+	for _, siteId := range siteIds {
+		if err := inv.collectSite(ctx, backend, siteId); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return inv, errors.Join(errs...)
+}
+
+func newBucket(detail objectstorage.BucketDetail) Bucket {
+	bucket := Bucket{
+		SiteID: detail.SiteID,
+		Name:   string(detail.Bucket.Name),
+		Plan:   string(detail.Bucket.Plan.Value.Type.Value),
+	}
+	if detail.Encryption != nil {
+		bucket.Encryption = &Encryption{KMSKeyID: string(detail.Encryption.KmsKeyID.Value)}
+	}
+	if _, failed := detail.Errors[objectstorage.BucketDetailReplication]; !failed {
+		bucket.Replication = &Replication{}
+		if r := detail.Replication; r != nil {
+			bucket.Replication = &Replication{
+				Configured: true,
+				SiteID:     r.DestBucket.ClusterID.Value,
+				Bucket:     r.DestBucket.Name.Value,
+				Status:     string(r.ConfigStatus),
+			}
+		}
+	}
+	if detail.Usage != nil {
+		bucket.Usage = &Usage{Objects: detail.Usage.NumObjectsPerBucket.Value, GiB: float64(detail.Usage.AmountGibPerBucket.Value)}
+	}
+	if detail.Quota != nil {
+		bucket.Quota = &Usage{Objects: detail.Quota.NumObjectsPerBucket.Value, GiB: float64(detail.Quota.AmountGibPerBucket.Value)}
+	}
+	return bucket
+}
+
+// collectSite サイトのパーミッションとアクセスキーを収集する
+func (inv *Inventory) collectSite(ctx context.Context, backend objectstorage.Backend, siteId string) error {
+	var errs []error
+	accounts, err := backend.Accounts(siteId)
+	if err == nil {
+		var keys []v2.AccountKeysDataItem
+		keys, err = accounts.ListAccessKeys(ctx)
+		for _, key := range keys {
+			inv.Keys = append(inv.Keys, Key{SiteID: siteId, Kind: KeyKindAccount, ID: string(key.ID.Value), CreatedAt: time.Time(key.CreatedAt.Value)})
+		}
+	}
+	// サイトアカウントが存在しない
+	if err != nil && !saclient.IsNotFoundError(err) {
+		errs = append(errs, objectstorage.NewError(fmt.Sprintf("failed to list account keys in site %s", siteId), err))
+	}
+
+	permissions, err := backend.Permissions(siteId)
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	list, err := permissions.List(ctx)
+	if err != nil {
+		errs = append(errs, objectstorage.NewError(fmt.Sprintf("failed to list permissions in site %s", siteId), err))
+	}
+	for _, item := range list {
+		permission := Permission{
+			SiteID:  siteId,
+			ID:      fmt.Sprint(item.ID.Value),
+			Name:    string(item.DisplayName.Value),
+			Buckets: []PermissionBucket{},
+		}
+		for _, control := range item.BucketControls {
+			permission.Buckets = append(permission.Buckets, PermissionBucket{
+				Name:  string(control.BucketName.Value),
+				Read:  bool(control.CanRead.Value),
+				Write: bool(control.CanWrite.Value),
+			})
+		}
+		inv.Permissions = append(inv.Permissions, permission)
+
+		keys, err := permissions.ListAccessKeys(ctx, permission.ID)
+		if err != nil {
+			errs = append(errs, objectstorage.NewError(fmt.Sprintf("failed to list keys of permission %s in site %s", permission.ID, siteId), err))
+			continue
+		}
+		for _, key := range keys {
+			inv.Keys = append(inv.Keys, Key{
+				SiteID:       siteId,
+				Kind:         KeyKindPermission,
+				ID:           string(key.ID.Value),
+				PermissionID: permission.ID,
+				CreatedAt:    time.Time(key.CreatedAt.Value),
+			})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ResourceKind ルールの対象とするリソースの種別
+type ResourceKind string
+
+const (
+	ResourceBuckets     ResourceKind = "buckets"
+	ResourcePermissions ResourceKind = "permissions"
+	ResourceKeys        ResourceKind = "keys"
+)
+
+// ResourceKinds 全てのリソースの種別
+var ResourceKinds = []ResourceKind{ResourceBuckets, ResourcePermissions, ResourceKeys}
+
+// Resource 評価の対象となったリソース
+type Resource struct {
+	Kind   ResourceKind `json:"kind"`
+	SiteID string       `json:"site_id"`
+	// ID "sites/{site}/buckets/{name}"のようなリソースの識別子
+	ID string `json:"id"`
+}
+
+// resources kindのリソースとそれぞれの式の変数を返す
+func (inv *Inventory) resources(kind ResourceKind) ([]Resource, []map[string]any) {
+	var resources []Resource
+	var envs []map[string]any
+	add := func(siteId, id string, env map[string]any) {
+		env["site"] = siteId
+		resources = append(resources, Resource{Kind: kind, SiteID: siteId, ID: fmt.Sprintf("sites/%s/%s", siteId, id)})
+		envs = append(envs, env)
+	}
+	switch kind {
+	case ResourceBuckets:
+		for _, b := range inv.Buckets {
+			add(b.SiteID, "buckets/"+b.Name, b.env())
+		}
+	case ResourcePermissions:
+		for _, p := range inv.Permissions {
+			add(p.SiteID, "permissions/"+p.ID, p.env())
+		}
+	case ResourceKeys:
+		for _, k := range inv.Keys {
+			id := "account/keys/" + k.ID
+			if k.Kind == KeyKindPermission {
+				id = fmt.Sprintf("permissions/%s/keys/%s", k.PermissionID, k.ID)
+			}
+			add(k.SiteID, id, k.env(inv.CollectedAt))
+		}
+	}
+	return resources, envs
+}
+
+// env 式から参照できるバケットの項目
+//
+// 取得に失敗した項目はnullとなる
+func (b *Bucket) env() map[string]any {
+	env := map[string]any{
+		"name":        b.Name,
+		"plan":        b.Plan,
+		"encrypted":   nil,
+		"kms_key_id":  nil,
+		"replicated":  nil,
+		"replication": nil,
+		"usage":       nil,
+		"quota":       nil,
+	}
+	if b.Encryption != nil {
+		env["encrypted"] = b.Encryption.KMSKeyID != ""
+		env["kms_key_id"] = b.Encryption.KMSKeyID
+	}
+	if b.Replication != nil {
+		env["replicated"] = b.Replication.Configured
+		if b.Replication.Configured {
+			env["replication"] = map[string]any{"site": b.Replication.SiteID, "bucket": b.Replication.Bucket, "status": b.Replication.Status}
+		}
+	}
+	if b.Usage != nil {
+		env["usage"] = b.Usage.env()
+	}
+	if b.Quota != nil {
+		env["quota"] = b.Quota.env()
+	}
+	return env
+}
+
+func (u *Usage) env() map[string]any {
+	return map[string]any{"objects": float64(u.Objects), "gib": u.GiB}
+}
+
+// env 式から参照できるパーミッションの項目
+func (p *Permission) env() map[string]any {
+	buckets := make([]any, 0, len(p.Buckets))
+	readBuckets, writeBuckets := []any{}, []any{}
+	for _, b := range p.Buckets {
+		buckets = append(buckets, map[string]any{"name": b.Name, "read": b.Read, "write": b.Write})
+		if b.Read {
+			readBuckets = append(readBuckets, b.Name)
+		}
+		if b.Write {
+			writeBuckets = append(writeBuckets, b.Name)
+		}
+	}
+	return map[string]any{
+		"id":            p.ID,
+		"name":          p.Name,
+		"buckets":       buckets,
+		"read_buckets":  readBuckets,
+		"write_buckets": writeBuckets,
+	}
+}
+
+// env 式から参照できるアクセスキーの項目。age_daysはnowまでの経過日数(切り捨て)
+func (k *Key) env(now time.Time) map[string]any {
+	env := map[string]any{
+		"kind":          string(k.Kind),
+		"id":            k.ID,
+		"permission_id": nil,
+		"created_at":    k.CreatedAt.UTC().Format(time.RFC3339),
+		"age_days":      math.Floor(now.Sub(k.CreatedAt).Hours() / 24),
+	}
+	if k.Kind == KeyKindPermission {
+		env["permission_id"] = k.PermissionID
+	}
+	return env
+}
+
+// resourceFields kindのリソースの式から参照できる項目の名前
+func resourceFields(kind ResourceKind) []string {
+	var env map[string]any
+	switch kind {
+	case ResourceBuckets:
+		env = (&Bucket{}).env()
+	case ResourcePermissions:
+		env = (&Permission{}).env()
+	case ResourceKeys:
+		env = (&Key{}).env(time.Time{})
+	}
+	fields := []string{"site"}
+	for name := range env {
+		fields = append(fields, name)
+	}
+	slices.Sort(fields)
+	return fields
+}
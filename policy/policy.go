@@ -0,0 +1,367 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package policy バケット、パーミッション、アクセスキーの設定を組織のルールに照らして評価するポリシーエンジン
+//
+// ルールはYAMLで宣言し、Collectで収集したInventoryの各リソースに対して式(Expr)で条件を記述する。
+// 評価の結果は違反の重大度と対処方法を含むReportとなり、JUnitやSARIFの形式でCIに出力できる
+//
+//	vars:
+//	  max_write_buckets: 3
+//	rules:
+//	  - id: bucket-encrypted
+//	    resource: buckets
+//	    assert: encrypted
+//	    severity: high
+//	    message: "bucket {{ name }} is not encrypted"
+//	    remediation: Enable server-side encryption with a KMS key.
+//	  - id: permission-write-scope
+//	    resource: permissions
+//	    assert: len(write_buckets) <= vars.max_write_buckets
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Severity 違反の重大度
+type Severity string
+
+const (
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
+
+// Severities 全ての重大度。後ろほど重大
+var Severities = []Severity{SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+
+// AtLeast sがmin以上の重大度かを返す
+func (s Severity) AtLeast(min Severity) bool {
+	return slices.Index(Severities, s) >= slices.Index(Severities, min)
+}
+
+// ParseSeverity 重大度の名前を解釈する
+func ParseSeverity(s string) (Severity, error) {
+	severity := Severity(strings.ToLower(s))
+	if !slices.Contains(Severities, severity) {
+		return "", fmt.Errorf("unknown severity %q", s)
+	}
+	return severity, nil
+}
+
+// Policy ルールの一覧
+type Policy struct {
+	// Vars 全てのルールの式からvarsとして参照できる値
+	Vars  map[string]any `yaml:"vars"`
+	Rules []*Rule        `yaml:"rules"`
+
+	// Source ポリシーを読み込んだファイルのパス。SARIFでルールの位置として出力する
+	Source string `yaml:"-"`
+}
+
+// Rule リソースの種別ごとのルール
+type Rule struct {
+	ID          string       `yaml:"id"`
+	Description string       `yaml:"description"`
+	Resource    ResourceKind `yaml:"resource"`
+	// When 評価の対象とするリソースの条件。空の場合は全てのリソース
+	When string `yaml:"when"`
+	// Assert リソースが満たすべき条件。falseとなったリソースが違反となる
+	Assert string `yaml:"assert"`
+	// Severity 違反の重大度。空の場合はSeverityMedium
+	Severity Severity `yaml:"severity"`
+	// Message 違反のメッセージ。{{ 式 }}はリソースに対する式の値に置き換えられる。空の場合はDescription
+	Message string `yaml:"message"`
+	// Remediation 違反への対処方法
+	Remediation string `yaml:"remediation"`
+
+	// Line ポリシーのファイルでルールが定義された行。不明な場合は0
+	Line int `yaml:"-"`
+
+	when, assert *Expr
+	message      []messagePart
+}
+
+// messagePart メッセージの固定の文字列もしくは式
+type messagePart struct {
+	text string
+	expr *Expr
+}
+
+// LoadPolicy ファイルからポリシーを読み込む
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := ParsePolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	p.Source = path
+	return p, nil
+}
+
+// ParsePolicy YAMLのポリシーを読み込み、式をコンパイルする
+func ParsePolicy(data []byte) (*Policy, error) {
+	var policy Policy
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&policy); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+
+	// ルールの行番号はデコードした構造体からは得られないためノードから読み込む
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err == nil && len(doc.Content) > 0 {
+		root := doc.Content[0]
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if root.Content[i].Value == "rules" {
+				for j, rule := range root.Content[i+1].Content {
+					if j < len(policy.Rules) && policy.Rules[j] != nil {
+						policy.Rules[j].Line = rule.Line
+					}
+				}
+			}
+		}
+	}
+
+	if err := policy.Compile(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Compile ルールを検証して式をコンパイルする。コードでPolicyを組み立てた場合はEvaluateの前に呼び出すこと
+func (p *Policy) Compile() error {
+	var errs []error
+	ids := map[string]bool{}
+	for i, rule := range p.Rules {
+		if rule == nil {
+			errs = append(errs, fmt.Errorf("rules[%d]: rule is empty", i))
+			continue
+		}
+		name := fmt.Sprintf("rules[%d]", i)
+		if rule.ID == "" {
+			errs = append(errs, fmt.Errorf("%s: id is required", name))
+		} else {
+			name = "rule " + rule.ID
+			if ids[rule.ID] {
+				errs = append(errs, fmt.Errorf("%s: duplicated id", name))
+			}
+			ids[rule.ID] = true
+		}
+		if err := rule.compile(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Rule) compile() error {
+	if !slices.Contains(ResourceKinds, r.Resource) {
+		return fmt.Errorf("unknown resource %q", r.Resource)
+	}
+	if r.Severity == "" {
+		r.Severity = SeverityMedium
+	}
+	if !slices.Contains(Severities, r.Severity) {
+		return fmt.Errorf("unknown severity %q", r.Severity)
+	}
+	if r.Assert == "" {
+		return errors.New("assert is required")
+	}
+
+	fields := resourceFields(r.Resource)
+	compile := func(src string) (*Expr, error) {
+		expr, err := CompileExpr(src)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range expr.vars {
+			if name != "vars" && !slices.Contains(fields, name) {
+				return nil, fmt.Errorf("unknown field %q of %s in %q", name, r.Resource, src)
+			}
+		}
+		return expr, nil
+	}
+	var err error
+	if r.When != "" {
+		if r.when, err = compile(r.When); err != nil {
+			return err
+		}
+	}
+	if r.assert, err = compile(r.Assert); err != nil {
+		return err
+	}
+
+	message := r.Message
+	if message == "" {
+		message = r.Description
+	}
+	r.message = nil
+	for message != "" {
+		start := strings.Index(message, "{{")
+		if start < 0 {
+			r.message = append(r.message, messagePart{text: message})
+			break
+		}
+		end := strings.Index(message[start:], "}}")
+		if end < 0 {
+			return fmt.Errorf("unterminated {{ in message %q", r.Message)
+		}
+		expr, err := compile(strings.TrimSpace(message[start+2 : start+end]))
+		if err != nil {
+			return err
+		}
+		r.message = append(r.message, messagePart{text: message[:start]}, messagePart{expr: expr})
+		message = message[start+end+2:]
+	}
+	return nil
+}
+
+// Violation ルールに違反したリソース
+type Violation struct {
+	RuleID      string   `json:"rule_id"`
+	Severity    Severity `json:"severity"`
+	Resource    Resource `json:"resource"`
+	Message     string   `json:"message"`
+	Remediation string   `json:"remediation,omitempty"`
+}
+
+// Result ルールとリソースの組ごとの評価の結果
+type Result struct {
+	Rule     *Rule
+	Resource Resource
+	// Violation 違反した場合の内容。満たしている場合はnil
+	Violation *Violation
+	// Err 式の評価に失敗した場合のエラー
+	Err error
+}
+
+// Report ポリシーの評価の結果
+type Report struct {
+	Policy      *Policy
+	EvaluatedAt time.Time
+	// Results 評価の対象となったルールとリソースの組ごとの結果。ルールの順に並ぶ
+	Results []Result
+}
+
+// Evaluate インベントリの全てのリソースをポリシーのルールで評価する
+func (p *Policy) Evaluate(inv *Inventory) *Report {
+	report := &Report{Policy: p, EvaluatedAt: inv.CollectedAt}
+	vars := normalize(p.Vars)
+	for _, rule := range p.Rules {
+		resources, envs := inv.resources(rule.Resource)
+		for i, resource := range resources {
+			env := envs[i]
+			env["vars"] = vars
+			if result, ok := rule.evaluate(resource, env); ok {
+				report.Results = append(report.Results, result)
+			}
+		}
+	}
+	return report
+}
+
+// evaluate ルールでリソースを評価する。whenを満たさない場合はfalseを返す
+func (r *Rule) evaluate(resource Resource, env map[string]any) (Result, bool) {
+	result := Result{Rule: r, Resource: resource}
+	if r.assert == nil {
+		result.Err = fmt.Errorf("rule %s is not compiled", r.ID)
+		return result, true
+	}
+	if r.when != nil {
+		matched, err := r.when.EvalBool(env)
+		if err != nil {
+			result.Err = err
+			return result, true
+		}
+		if !matched {
+			return result, false
+		}
+	}
+	passed, err := r.assert.EvalBool(env)
+	if err != nil || passed {
+		result.Err = err
+		return result, true
+	}
+
+	var sb strings.Builder
+	for _, part := range r.message {
+		sb.WriteString(part.text)
+		if part.expr != nil {
+			v, err := part.expr.Eval(env)
+			if err != nil {
+				v = "<" + err.Error() + ">"
+			}
+			fmt.Fprint(&sb, formatValue(v))
+		}
+	}
+	message := sb.String()
+	if message == "" {
+		message = fmt.Sprintf("%s violates %s", resource.ID, r.ID)
+	}
+	result.Violation = &Violation{
+		RuleID:      r.ID,
+		Severity:    r.Severity,
+		Resource:    resource,
+		Message:     message,
+		Remediation: r.Remediation,
+	}
+	return result, true
+}
+
+// formatValue メッセージに埋め込む値の表記
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case []any:
+		values := make([]string, len(v))
+		for i, e := range v {
+			values[i] = formatValue(e)
+		}
+		return strings.Join(values, ", ")
+	}
+	return fmt.Sprint(v)
+}
+
+// Violations 全ての違反を返す
+func (r *Report) Violations() []Violation {
+	var res []Violation
+	for _, result := range r.Results {
+		if result.Violation != nil {
+			res = append(res, *result.Violation)
+		}
+	}
+	return res
+}
+
+// Errors 評価に失敗したルールとリソースの組を返す
+func (r *Report) Errors() []Result {
+	var res []Result
+	for _, result := range r.Results {
+		if result.Err != nil {
+			res = append(res, result)
+		}
+	}
+	return res
+}
+
+// Failed min以上の重大度の違反、もしくは評価に失敗したルールがあるかを返す
+func (r *Report) Failed(min Severity) bool {
+	return slices.ContainsFunc(r.Results, func(result Result) bool {
+		return result.Err != nil || result.Violation != nil && result.Violation.Severity.AtLeast(min)
+	})
+}
@@ -0,0 +1,279 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package policy_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/sacloud/object-storage-api-go/objectstoragetest"
+	"github.com/sacloud/object-storage-api-go/policy"
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicy = `
+vars:
+  team_prefixes: [web-, prod-]
+  max_write_buckets: 1
+  max_key_age_days: 90
+rules:
+  - id: bucket-encrypted
+    description: Every bucket must be encrypted
+    resource: buckets
+    assert: encrypted
+    severity: high
+    message: "bucket {{ name }} is not encrypted"
+    remediation: Enable server-side encryption with a KMS key.
+  - id: production-replicated
+    resource: buckets
+    when: startsWith(name, "prod-")
+    assert: replicated && replication.site != site
+    severity: critical
+  - id: permission-write-scope
+    resource: permissions
+    assert: len(write_buckets) <= vars.max_write_buckets
+    message: "permission {{ name }} can write {{ len(write_buckets) }} buckets: {{ write_buckets }}"
+  - id: bucket-team-prefix
+    resource: buckets
+    assert: startsWith(name, vars.team_prefixes)
+    severity: low
+  - id: key-age
+    resource: keys
+    assert: age_days <= vars.max_key_age_days
+    message: "{{ kind }} key {{ id }} is {{ age_days }} days old"
+`
+
+// setupFake ポリシーの各ルールに違反するリソースを含むフェイクを作成する
+func setupFake(t *testing.T) (*objectstoragetest.Fake, func() time.Time) {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	fake := objectstoragetest.NewFake(objectstoragetest.WithClock(clock))
+
+	for site, names := range map[string][]string{"isk01": {"prod-a", "prod-b", "tmp"}, "tky01": {"prod-a-replica"}} {
+		api, err := fake.Buckets(site)
+		require.NoError(t, err)
+		for _, name := range names {
+			_, err := api.Create(ctx, &objectstorage.BucketCreateParams{Bucket: name})
+			require.NoError(t, err)
+		}
+	}
+	extra, err := fake.BucketExtra("isk01", "prod-a")
+	require.NoError(t, err)
+	require.NoError(t, extra.EnableEncryption(ctx, "kms-1"))
+	_, err = extra.EnableReplication(ctx, "prod-a-replica")
+	require.NoError(t, err)
+
+	permissions, err := fake.Permissions("isk01")
+	require.NoError(t, err)
+	permission, err := permissions.Create(ctx, "deployer", v2.BucketControls{
+		{BucketName: v2.NewOptBucketName("prod-a"), CanRead: v2.NewOptCanRead(true), CanWrite: v2.NewOptCanWrite(true)},
+		{BucketName: v2.NewOptBucketName("prod-b"), CanRead: v2.NewOptCanRead(true), CanWrite: v2.NewOptCanWrite(true)},
+	})
+	require.NoError(t, err)
+	_, err = permissions.CreateAccessKey(ctx, strconv.FormatInt(int64(permission.ID.Value), 10))
+	require.NoError(t, err)
+
+	now = now.Add(100 * 24 * time.Hour)
+	accounts, err := fake.Accounts("isk01")
+	require.NoError(t, err)
+	_, err = accounts.CreateAccessKey(ctx)
+	require.NoError(t, err)
+	return fake, clock
+}
+
+func TestPolicy_Evaluate(t *testing.T) {
+	fake, clock := setupFake(t)
+	p, err := policy.ParsePolicy([]byte(testPolicy))
+	require.NoError(t, err)
+	require.Equal(t, 7, p.Rules[0].Line)
+
+	inv, err := policy.Collect(context.Background(), fake, &policy.CollectOptions{Now: clock})
+	require.NoError(t, err)
+	require.Len(t, inv.Buckets, 4)
+	require.Len(t, inv.Permissions, 1)
+	require.Len(t, inv.Keys, 2)
+
+	permissionId := inv.Permissions[0].ID
+	i := slices.IndexFunc(inv.Keys, func(k policy.Key) bool { return k.Kind == policy.KeyKindPermission })
+	require.GreaterOrEqual(t, i, 0)
+	keyId := inv.Keys[i].ID
+
+	report := p.Evaluate(inv)
+	require.Empty(t, report.Errors())
+	var got []string
+	for _, v := range report.Violations() {
+		got = append(got, v.RuleID+" "+v.Resource.ID+": "+v.Message)
+	}
+	require.ElementsMatch(t, []string{
+		"bucket-encrypted sites/isk01/buckets/prod-b: bucket prod-b is not encrypted",
+		"bucket-encrypted sites/isk01/buckets/tmp: bucket tmp is not encrypted",
+		"bucket-encrypted sites/tky01/buckets/prod-a-replica: bucket prod-a-replica is not encrypted",
+		"production-replicated sites/isk01/buckets/prod-b: sites/isk01/buckets/prod-b violates production-replicated",
+		"production-replicated sites/tky01/buckets/prod-a-replica: sites/tky01/buckets/prod-a-replica violates production-replicated",
+		"permission-write-scope sites/isk01/permissions/" + permissionId + ": permission deployer can write 2 buckets: prod-a, prod-b",
+		"bucket-team-prefix sites/isk01/buckets/tmp: sites/isk01/buckets/tmp violates bucket-team-prefix",
+		"key-age sites/isk01/permissions/" + permissionId + "/keys/" + keyId + ": permission key " + keyId + " is 100 days old",
+	}, got)
+
+	require.True(t, report.Failed(policy.SeverityCritical))
+	require.Equal(t, policy.Severity("high"), report.Violations()[0].Severity)
+	require.Equal(t, "Enable server-side encryption with a KMS key.", report.Violations()[0].Remediation)
+}
+
+func TestPolicy_EvaluateError(t *testing.T) {
+	ctx := context.Background()
+	fake := objectstoragetest.NewFake()
+	api, err := fake.Buckets("isk01")
+	require.NoError(t, err)
+	_, err = api.Create(ctx, &objectstorage.BucketCreateParams{Bucket: "bucket1"})
+	require.NoError(t, err)
+
+	// 取得に失敗した項目を参照するルールは評価に失敗する
+	fake.FailOn("BucketExtra.ReadEncryption", errors.New("unavailable"))
+	inv, err := policy.Collect(ctx, fake, &policy.CollectOptions{SiteIDs: []string{"isk01"}})
+	require.Error(t, err)
+	p, err := policy.ParsePolicy([]byte(testPolicy))
+	require.NoError(t, err)
+	report := p.Evaluate(inv)
+	errs := report.Errors()
+	require.Len(t, errs, 1)
+	require.Equal(t, "bucket-encrypted", errs[0].Rule.ID)
+	require.True(t, report.Failed(policy.SeverityCritical))
+}
+
+func TestParsePolicy_Invalid(t *testing.T) {
+	for name, src := range map[string]string{
+		"unknown key":      "rules:\n  - id: a\n    resource: buckets\n    assert: true\n    unknown: 1\n",
+		"missing id":       "rules:\n  - resource: buckets\n    assert: true\n",
+		"duplicated id":    "rules:\n  - {id: a, resource: buckets, assert: 'true'}\n  - {id: a, resource: buckets, assert: 'true'}\n",
+		"unknown resource": "rules:\n  - {id: a, resource: sites, assert: 'true'}\n",
+		"unknown severity": "rules:\n  - {id: a, resource: buckets, assert: 'true', severity: fatal}\n",
+		"missing assert":   "rules:\n  - {id: a, resource: buckets}\n",
+		"syntax error":     "rules:\n  - {id: a, resource: buckets, assert: 'encrypted &&'}\n",
+		"unknown field":    "rules:\n  - {id: a, resource: keys, assert: encrypted}\n",
+		"invalid message":  "rules:\n  - {id: a, resource: buckets, assert: 'true', message: '{{ name'}\n",
+	} {
+		_, err := policy.ParsePolicy([]byte(src))
+		require.Error(t, err, name)
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	fake, clock := setupFake(t)
+	p, err := policy.ParsePolicy([]byte(testPolicy))
+	require.NoError(t, err)
+	inv, err := policy.Collect(context.Background(), fake, &policy.CollectOptions{Now: clock})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, policy.WriteJUnit(&buf, p.Evaluate(inv)))
+	var suites struct {
+		Tests    int `xml:"tests,attr"`
+		Failures int `xml:"failures,attr"`
+		Suites   []struct {
+			Name     string `xml:"name,attr"`
+			Tests    int    `xml:"tests,attr"`
+			Failures int    `xml:"failures,attr"`
+			Cases    []struct {
+				Name    string `xml:"name,attr"`
+				Failure *struct {
+					Type string `xml:"type,attr"`
+				} `xml:"failure"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+	// バケット4つ*3ルール(replicatedはprod-*の3つのみ)、パーミッション1つ、アクセスキー2つ
+	require.Equal(t, 4+3+1+4+2, suites.Tests)
+	require.Equal(t, 8, suites.Failures)
+	require.Len(t, suites.Suites, 5)
+	require.Equal(t, "bucket-encrypted", suites.Suites[0].Name)
+	require.Equal(t, 3, suites.Suites[0].Failures)
+	for _, c := range suites.Suites[0].Cases {
+		if c.Failure != nil {
+			require.Equal(t, "high", c.Failure.Type)
+		}
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	fake, clock := setupFake(t)
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testPolicy), 0o600))
+	p, err := policy.LoadPolicy(path)
+	require.NoError(t, err)
+	inv, err := policy.Collect(context.Background(), fake, &policy.CollectOptions{Now: clock})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, policy.WriteSARIF(&buf, p.Evaluate(inv)))
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string `json:"name"`
+					Rules []struct {
+						ID                   string `json:"id"`
+						DefaultConfiguration struct {
+							Level string `json:"level"`
+						} `json:"defaultConfiguration"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Invocations []struct {
+				ExecutionSuccessful bool `json:"executionSuccessful"`
+			} `json:"invocations"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex int    `json:"ruleIndex"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine int `json:"startLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+					LogicalLocations []struct {
+						FullyQualifiedName string `json:"fullyQualifiedName"`
+					} `json:"logicalLocations"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	require.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	require.Equal(t, policy.ToolName, run.Tool.Driver.Name)
+	require.Len(t, run.Tool.Driver.Rules, 5)
+	require.Equal(t, "error", run.Tool.Driver.Rules[0].DefaultConfiguration.Level)
+	require.Equal(t, "note", run.Tool.Driver.Rules[3].DefaultConfiguration.Level)
+	require.True(t, run.Invocations[0].ExecutionSuccessful)
+	require.Len(t, run.Results, 8)
+
+	first := run.Results[0]
+	require.Equal(t, "bucket-encrypted", first.RuleID)
+	require.Equal(t, 0, first.RuleIndex)
+	require.Equal(t, "error", first.Level)
+	require.Equal(t, filepath.ToSlash(path), first.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	require.Equal(t, 7, first.Locations[0].PhysicalLocation.Region.StartLine)
+	require.Contains(t, first.Locations[0].LogicalLocations[0].FullyQualifiedName, "/buckets/")
+}
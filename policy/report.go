@@ -0,0 +1,287 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package policy
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// ToolName JUnitおよびSARIFに出力するツールの名前
+const ToolName = "object-storage-policy"
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure"`
+	Error     *junitMessage `xml:"error"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit 評価の結果をJUnitのXMLとして書き込む
+//
+// ルールごとにtestsuiteを、評価したリソースごとにtestcaseを出力する。
+// 違反はfailure、評価の失敗はerrorとなり、failureのtypeには重大度を出力する
+func WriteJUnit(w io.Writer, report *Report) error {
+	suites := junitTestSuites{Name: ToolName}
+	index := map[*Rule]int{}
+	for _, rule := range report.Policy.Rules {
+		index[rule] = len(suites.Suites)
+		suite := junitTestSuite{Name: rule.ID}
+		if !report.EvaluatedAt.IsZero() {
+			suite.Timestamp = report.EvaluatedAt.UTC().Format("2006-01-02T15:04:05")
+		}
+		suites.Suites = append(suites.Suites, suite)
+	}
+	for _, result := range report.Results {
+		i, ok := index[result.Rule]
+		if !ok {
+			continue
+		}
+		suite := &suites.Suites[i]
+		c := junitTestCase{Name: result.Resource.ID, ClassName: result.Rule.ID}
+		switch {
+		case result.Err != nil:
+			c.Error = &junitMessage{Message: result.Err.Error()}
+			suite.Errors++
+		case result.Violation != nil:
+			v := result.Violation
+			c.Failure = &junitMessage{Message: v.Message, Type: string(v.Severity), Text: v.Remediation}
+			suite.Failures++
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, c)
+	}
+	for _, suite := range suites.Suites {
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Errors += suite.Errors
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// SARIFのスキーマ(2.1.0)のうち出力する項目
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations"`
+	Results     []sarifResult     `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     *sarifMessage      `json:"shortDescription,omitempty"`
+	Help                 *sarifMessage      `json:"help,omitempty"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+	Properties           map[string]any     `json:"properties,omitempty"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	EndTimeUTC                 string              `json:"endTimeUtc,omitempty"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifNotification struct {
+	Level          string                    `json:"level"`
+	Message        sarifMessage              `json:"message"`
+	AssociatedRule *sarifDescriptorReference `json:"associatedRule,omitempty"`
+	Locations      []sarifResultLocation     `json:"locations,omitempty"`
+}
+
+type sarifDescriptorReference struct {
+	ID    string `json:"id"`
+	Index int    `json:"index"`
+}
+
+type sarifResult struct {
+	RuleID     string                `json:"ruleId"`
+	RuleIndex  int                   `json:"ruleIndex"`
+	Level      string                `json:"level"`
+	Message    sarifMessage          `json:"message"`
+	Locations  []sarifResultLocation `json:"locations"`
+	Properties map[string]any        `json:"properties,omitempty"`
+}
+
+type sarifResultLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// sarifLevel 重大度に対応するSARIFのlevel
+func sarifLevel(s Severity) string {
+	switch s {
+	case SeverityCritical, SeverityHigh:
+		return "error"
+	case SeverityMedium:
+		return "warning"
+	}
+	return "note"
+}
+
+// WriteSARIF 評価の結果をSARIF 2.1.0のJSONとして書き込む
+//
+// 違反はresultとして、リソースの識別子を論理的な位置に出力する。ポリシーをファイルから読み込んだ場合は
+// ルールを定義した行を物理的な位置として併せて出力する。評価の失敗はtoolExecutionNotificationsに出力する
+func WriteSARIF(w io.Writer, report *Report) error {
+	run := sarifRun{
+		Tool:    sarifTool{Driver: sarifDriver{Name: ToolName, Rules: []sarifRule{}}},
+		Results: []sarifResult{},
+	}
+	index := map[*Rule]int{}
+	for i, rule := range report.Policy.Rules {
+		index[rule] = i
+		r := sarifRule{
+			ID:                   rule.ID,
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevel(rule.Severity)},
+			Properties:           map[string]any{"severity": rule.Severity, "resource": rule.Resource},
+		}
+		if rule.Description != "" {
+			r.ShortDescription = &sarifMessage{Text: rule.Description}
+		}
+		if rule.Remediation != "" {
+			r.Help = &sarifMessage{Text: rule.Remediation}
+		}
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, r)
+	}
+
+	invocation := sarifInvocation{ExecutionSuccessful: true}
+	if !report.EvaluatedAt.IsZero() {
+		invocation.EndTimeUTC = report.EvaluatedAt.UTC().Format("2006-01-02T15:04:05Z")
+	}
+	for _, result := range report.Results {
+		i, ok := index[result.Rule]
+		if !ok {
+			continue
+		}
+		location := sarifResultLocation{
+			PhysicalLocation: sarifRuleLocation(report.Policy, result.Rule),
+			LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: result.Resource.ID, Kind: "resource"}},
+		}
+		switch {
+		case result.Err != nil:
+			invocation.ExecutionSuccessful = false
+			invocation.ToolExecutionNotifications = append(invocation.ToolExecutionNotifications, sarifNotification{
+				Level:          "error",
+				Message:        sarifMessage{Text: result.Err.Error()},
+				AssociatedRule: &sarifDescriptorReference{ID: result.Rule.ID, Index: i},
+				Locations:      []sarifResultLocation{location},
+			})
+		case result.Violation != nil:
+			v := result.Violation
+			message := v.Message
+			if v.Remediation != "" {
+				message += "\n" + v.Remediation
+			}
+			run.Results = append(run.Results, sarifResult{
+				RuleID:     v.RuleID,
+				RuleIndex:  i,
+				Level:      sarifLevel(v.Severity),
+				Message:    sarifMessage{Text: strings.TrimSpace(message)},
+				Locations:  []sarifResultLocation{location},
+				Properties: map[string]any{"severity": v.Severity, "site_id": v.Resource.SiteID},
+			})
+		}
+	}
+	run.Invocations = []sarifInvocation{invocation}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{Version: sarifVersion, Schema: sarifSchema, Runs: []sarifRun{run}})
+}
+
+// sarifRuleLocation ルールを定義したファイルと行。ファイルから読み込んでいない場合はnil
+func sarifRuleLocation(policy *Policy, rule *Rule) *sarifPhysicalLocation {
+	if policy.Source == "" {
+		return nil
+	}
+	location := &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(policy.Source)}}
+	if rule.Line > 0 {
+		location.Region = &sarifRegion{StartLine: rule.Line}
+	}
+	return location
+}
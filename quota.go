@@ -0,0 +1,364 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/sacloud/saclient-go"
+)
+
+// QuotaDimension サイトアカウントの制限値の項目。名前はQuotaDataのJSONのキーと同じ
+type QuotaDimension string
+
+const (
+	// QuotaRootKeys サイトアカウントのアクセスキーの数
+	QuotaRootKeys QuotaDimension = "num_root_keys"
+	// QuotaBuckets バケットの数
+	QuotaBuckets QuotaDimension = "num_buckets"
+	// QuotaPermissions パーミッションの数
+	QuotaPermissions QuotaDimension = "num_permissions"
+	// QuotaKeysPerPermission パーミッションごとのアクセスキーの数
+	QuotaKeysPerPermission QuotaDimension = "num_keys_per_permission"
+	// QuotaBucketsPerPermission パーミッションごとのバケットの数
+	QuotaBucketsPerPermission QuotaDimension = "num_buckets_per_permission"
+)
+
+// QuotaDimensions 事前確認とHeadroomの対象とする全ての項目
+var QuotaDimensions = []QuotaDimension{QuotaRootKeys, QuotaBuckets, QuotaPermissions, QuotaKeysPerPermission, QuotaBucketsPerPermission}
+
+// limit quotaでのdの制限値を返す。制限値がない場合はfalse
+func (d QuotaDimension) limit(quota *v2.QuotaData) (int, bool) {
+	switch d {
+	case QuotaRootKeys:
+		return quota.NumRootKeys.Get()
+	case QuotaBuckets:
+		return quota.NumBuckets.Get()
+	case QuotaPermissions:
+		return quota.NumPermissions.Get()
+	case QuotaKeysPerPermission:
+		return quota.NumKeysPerPermission.Get()
+	case QuotaBucketsPerPermission:
+		return quota.NumBucketsPerPermission.Get()
+	}
+	return 0, false
+}
+
+// ErrQuotaExceeded 作成によりサイトアカウントの制限値を超える
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaExceededError 事前確認で制限値を超えることが分かったため作成しなかった。errors.Is(err, ErrQuotaExceeded)で判定できる
+type QuotaExceededError struct {
+	// Operation 拒否した呼び出し。"Buckets.Create"など
+	Operation string
+	SiteID    string
+	Dimension QuotaDimension
+	// PermissionID パーミッションごとの制限値の場合の対象のパーミッションのID
+	PermissionID string
+	Limit        int
+	// Current 現在の数
+	Current int
+	// Requested 呼び出しにより増える数
+	Requested int
+}
+
+func (e *QuotaExceededError) Error() string {
+	target := "site " + e.SiteID
+	if e.PermissionID != "" {
+		target = fmt.Sprintf("permission %s in site %s", e.PermissionID, e.SiteID)
+	}
+	return fmt.Sprintf("%s: %s of %s would exceed the limit: current %d + requested %d > limit %d",
+		e.Operation, e.Dimension, target, e.Current, e.Requested, e.Limit)
+}
+
+func (e *QuotaExceededError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// WithQuotaPreflight Backendが返すBucketAPI、PermissionsAPI、AccountAPIの作成系のメソッドの前に、
+// SiteStatusAPI.ReadQuotaの制限値と現在の数を確認し、超える場合はAPIを呼び出さずにQuotaExceededErrorを返す
+//
+// 確認は呼び出しごとに行うため、並行して作成した場合は制限値を超える可能性がある。
+// WithCacheやWithStaleCacheと併用した場合も、制限値と現在の数はキャッシュを介さずに取得する。
+// WithDryRunと併用した場合はドライランより外側で動作し、ドライランでも制限値を確認する
+func WithQuotaPreflight() BackendOption {
+	return func(b *backend) { b.quotaPreflight = true }
+}
+
+// quotaPreflight 制限値の確認
+type quotaPreflight struct {
+	status SiteStatusAPI
+	siteId string
+}
+
+// readQuota サイトアカウントの制限値を取得する
+func (q *quotaPreflight) readQuota(ctx context.Context, operation string) (*v2.QuotaData, error) {
+	quota, err := q.status.ReadQuota(ctx)
+	if err != nil {
+		return nil, NewError(fmt.Sprintf("failed to read quota for %s", operation), err)
+	}
+	return quota, nil
+}
+
+// check quotaでのdimensionの制限値を、countが返す現在の数と呼び出しにより増える数で確認する。
+// 制限値がない場合はcountを呼び出さない
+func (q *quotaPreflight) check(ctx context.Context, quota *v2.QuotaData, operation string, dimension QuotaDimension, permissionId string, count func(ctx context.Context) (current, requested int, err error)) error {
+	limit, ok := dimension.limit(quota)
+	if !ok {
+		return nil
+	}
+	current, requested, err := count(ctx)
+	if err != nil {
+		return NewError(fmt.Sprintf("failed to count %s for %s", dimension, operation), err)
+	}
+	if requested > 0 && current+requested > limit {
+		return &QuotaExceededError{
+			Operation:    operation,
+			SiteID:       q.siteId,
+			Dimension:    dimension,
+			PermissionID: permissionId,
+			Limit:        limit,
+			Current:      current,
+			Requested:    requested,
+		}
+	}
+	return nil
+}
+
+// PreflightBucketAPI BucketAPIのCreateの前にstatusでバケットの数の制限値を確認するラッパーを返す
+//
+// params.SiteIdでsiteIdとは異なるサイトを指定した場合は確認しない
+func PreflightBucketAPI(api BucketAPI, status SiteStatusAPI, siteId string) BucketAPI {
+	return &preflightBucketAPI{BucketAPI: api, counts: api, quotaPreflight: quotaPreflight{status: status, siteId: siteId}}
+}
+
+type preflightBucketAPI struct {
+	BucketAPI
+	// counts 現在の数を取得するAPI
+	counts BucketAPI
+	quotaPreflight
+}
+
+func (a *preflightBucketAPI) Create(ctx context.Context, params *BucketCreateParams) (*v2.ModelBucket, error) {
+	if params.SiteId == "" || params.SiteId == a.siteId {
+		quota, err := a.readQuota(ctx, "Buckets.Create")
+		if err == nil {
+			err = a.check(ctx, quota, "Buckets.Create", QuotaBuckets, "", func(ctx context.Context) (int, int, error) {
+				buckets, err := a.counts.List(ctx)
+				return len(buckets), 1, err
+			})
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return a.BucketAPI.Create(ctx, params)
+}
+
+// PreflightPermissionsAPI PermissionsAPIのCreate、UpdateおよびCreateAccessKeyの前にstatusで
+// パーミッションの数、パーミッションごとのバケットの数およびアクセスキーの数の制限値を確認するラッパーを返す
+func PreflightPermissionsAPI(api PermissionsAPI, status SiteStatusAPI, siteId string) PermissionsAPI {
+	return &preflightPermissionsAPI{PermissionsAPI: api, counts: api, quotaPreflight: quotaPreflight{status: status, siteId: siteId}}
+}
+
+type preflightPermissionsAPI struct {
+	PermissionsAPI
+	// counts 現在の数を取得するAPI
+	counts PermissionsAPI
+	quotaPreflight
+}
+
+func (a *preflightPermissionsAPI) Create(ctx context.Context, displayName string, controls v2.BucketControls) (*v2.PermissionData, error) {
+	quota, err := a.readQuota(ctx, "Permissions.Create")
+	if err == nil {
+		err = a.check(ctx, quota, "Permissions.Create", QuotaBucketsPerPermission, "", func(ctx context.Context) (int, int, error) {
+			return 0, len(controls), nil
+		})
+	}
+	if err == nil {
+		err = a.check(ctx, quota, "Permissions.Create", QuotaPermissions, "", func(ctx context.Context) (int, int, error) {
+			permissions, err := a.counts.List(ctx)
+			return len(permissions), 1, err
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a.PermissionsAPI.Create(ctx, displayName, controls)
+}
+
+func (a *preflightPermissionsAPI) Update(ctx context.Context, permissionId, displayName string, controls v2.BucketControls) (*v2.PermissionData, error) {
+	quota, err := a.readQuota(ctx, "Permissions.Update")
+	if err == nil {
+		// バケットの一覧は置き換えられるため、現在の数との差を増える数とする
+		err = a.check(ctx, quota, "Permissions.Update", QuotaBucketsPerPermission, permissionId, func(ctx context.Context) (int, int, error) {
+			permission, err := a.counts.Read(ctx, permissionId)
+			if err != nil {
+				return 0, 0, err
+			}
+			return len(permission.BucketControls), len(controls) - len(permission.BucketControls), nil
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a.PermissionsAPI.Update(ctx, permissionId, displayName, controls)
+}
+
+func (a *preflightPermissionsAPI) CreateAccessKey(ctx context.Context, permissionId string) (*v2.PermissionKeyData, error) {
+	quota, err := a.readQuota(ctx, "Permissions.CreateAccessKey")
+	if err == nil {
+		err = a.check(ctx, quota, "Permissions.CreateAccessKey", QuotaKeysPerPermission, permissionId, func(ctx context.Context) (int, int, error) {
+			keys, err := a.counts.ListAccessKeys(ctx, permissionId)
+			return len(keys), 1, err
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a.PermissionsAPI.CreateAccessKey(ctx, permissionId)
+}
+
+// PreflightAccountAPI AccountAPIのCreateAccessKeyの前にstatusでサイトアカウントのアクセスキーの数の制限値を確認するラッパーを返す
+func PreflightAccountAPI(api AccountAPI, status SiteStatusAPI, siteId string) AccountAPI {
+	return &preflightAccountAPI{AccountAPI: api, counts: api, quotaPreflight: quotaPreflight{status: status, siteId: siteId}}
+}
+
+type preflightAccountAPI struct {
+	AccountAPI
+	// counts 現在の数を取得するAPI
+	counts AccountAPI
+	quotaPreflight
+}
+
+func (a *preflightAccountAPI) CreateAccessKey(ctx context.Context) (*v2.AccountKeyData, error) {
+	quota, err := a.readQuota(ctx, "Accounts.CreateAccessKey")
+	if err == nil {
+		err = a.check(ctx, quota, "Accounts.CreateAccessKey", QuotaRootKeys, "", func(ctx context.Context) (int, int, error) {
+			keys, err := a.counts.ListAccessKeys(ctx)
+			return len(keys), 1, err
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a.AccountAPI.CreateAccessKey(ctx)
+}
+
+// QuotaHeadroom 制限値の項目ごとの残り
+type QuotaHeadroom struct {
+	Dimension QuotaDimension `json:"dimension"`
+	Limit     int            `json:"limit"`
+	// Used 現在の数。パーミッションごとの項目の場合は最も多いパーミッションの数
+	Used int `json:"used"`
+	// Remaining 残りの数。超過している場合は負の値となる
+	Remaining int `json:"remaining"`
+	// PermissionID パーミッションごとの項目の場合のUsedのパーミッションのID。パーミッションがない場合は空
+	PermissionID string `json:"permission_id,omitempty"`
+}
+
+// SiteHeadroom サイトごとの制限値の残り
+type SiteHeadroom struct {
+	SiteID string `json:"site_id"`
+	// Quotas QuotaDimensionsの順の項目ごとの残り。制限値のない項目は含まない
+	Quotas []QuotaHeadroom `json:"quotas"`
+}
+
+// Headroom サイトごとに制限値の各項目の残りを取得する。siteIdsが空の場合はSiteAPI.Listで取得した全てのサイト
+//
+// 取得に失敗したサイトは結果に含めず、取得できたサイトの結果とともにエラーを返す
+func Headroom(ctx context.Context, backend Backend, siteIds ...string) ([]SiteHeadroom, error) {
+	if len(siteIds) == 0 {
+		var err error
+		if siteIds, err = ListSiteIDs(ctx, backend); err != nil {
+			return nil, err
+		}
+	}
+
+	var res []SiteHeadroom
+	var errs []error
+	for _, siteId := range siteIds {
+		headroom, err := siteHeadroom(ctx, backend, siteId)
+		if err != nil {
+			errs = append(errs, NewError(fmt.Sprintf("failed to read headroom of site %s", siteId), err))
+			continue
+		}
+		res = append(res, *headroom)
+	}
+	return res, errors.Join(errs...)
+}
+
+func siteHeadroom(ctx context.Context, backend Backend, siteId string) (*SiteHeadroom, error) {
+	status, err := backend.SiteStatus(siteId)
+	if err != nil {
+		return nil, err
+	}
+	quota, err := status.ReadQuota(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	used := map[QuotaDimension]QuotaHeadroom{}
+	accounts, err := backend.Accounts(siteId)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := accounts.ListAccessKeys(ctx)
+	// サイトアカウントが存在しない場合はアクセスキーがないものとする
+	if err != nil && !saclient.IsNotFoundError(err) {
+		return nil, err
+	}
+	used[QuotaRootKeys] = QuotaHeadroom{Used: len(keys)}
+
+	buckets, err := backend.Buckets(siteId)
+	if err != nil {
+		return nil, err
+	}
+	bucketList, err := buckets.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	used[QuotaBuckets] = QuotaHeadroom{Used: len(bucketList)}
+
+	permissions, err := backend.Permissions(siteId)
+	if err != nil {
+		return nil, err
+	}
+	permissionList, err := permissions.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	used[QuotaPermissions] = QuotaHeadroom{Used: len(permissionList)}
+	for _, permission := range permissionList {
+		id := fmt.Sprint(permission.ID.Value)
+		if u := used[QuotaBucketsPerPermission]; u.PermissionID == "" || len(permission.BucketControls) > u.Used {
+			used[QuotaBucketsPerPermission] = QuotaHeadroom{Used: len(permission.BucketControls), PermissionID: id}
+		}
+		if _, ok := quota.NumKeysPerPermission.Get(); !ok {
+			continue
+		}
+		keys, err := permissions.ListAccessKeys(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if u := used[QuotaKeysPerPermission]; u.PermissionID == "" || len(keys) > u.Used {
+			used[QuotaKeysPerPermission] = QuotaHeadroom{Used: len(keys), PermissionID: id}
+		}
+	}
+
+	res := &SiteHeadroom{SiteID: siteId, Quotas: []QuotaHeadroom{}}
+	for _, dimension := range QuotaDimensions {
+		limit, ok := dimension.limit(quota)
+		if !ok {
+			continue
+		}
+		h := used[dimension]
+		h.Dimension, h.Limit, h.Remaining = dimension, limit, limit-h.Used
+		res.Quotas = append(res.Quotas, h)
+	}
+	return res, nil
+}
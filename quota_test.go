@@ -0,0 +1,151 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstorage_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/sacloud/object-storage-api-go/objectstoragetest"
+	"github.com/stretchr/testify/require"
+)
+
+func newQuotaFake(t *testing.T) *objectstoragetest.Fake {
+	t.Helper()
+	fake := objectstoragetest.NewFake()
+	quota := objectstoragetest.DefaultSiteQuota
+	quota.NumBuckets = v2.NewOptInt(2)
+	quota.NumPermissions = v2.NewOptInt(1)
+	quota.NumKeysPerPermission = v2.NewOptInt(1)
+	quota.NumBucketsPerPermission = v2.NewOptInt(1)
+	quota.NumRootKeys = v2.NewOptInt(1)
+	require.NoError(t, fake.SetSiteQuota("isk01", quota))
+	return fake
+}
+
+func TestQuotaPreflight(t *testing.T) {
+	ctx := context.Background()
+	fake := newQuotaFake(t)
+	status, err := fake.SiteStatus("isk01")
+	require.NoError(t, err)
+
+	api, err := fake.Buckets("isk01")
+	require.NoError(t, err)
+	buckets := objectstorage.PreflightBucketAPI(api, status, "isk01")
+	for _, name := range []string{"bucket1", "bucket2"} {
+		_, err := buckets.Create(ctx, &objectstorage.BucketCreateParams{Bucket: name})
+		require.NoError(t, err)
+	}
+	fake.ResetCalls()
+	_, err = buckets.Create(ctx, &objectstorage.BucketCreateParams{Bucket: "bucket3"})
+	require.ErrorIs(t, err, objectstorage.ErrQuotaExceeded)
+	var e *objectstorage.QuotaExceededError
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, objectstorage.QuotaExceededError{
+		Operation: "Buckets.Create",
+		SiteID:    "isk01",
+		Dimension: objectstorage.QuotaBuckets,
+		Limit:     2,
+		Current:   2,
+		Requested: 1,
+	}, *e)
+	// APIは呼び出さない
+	require.Empty(t, fake.CallsTo("Buckets.Create"))
+
+	permissionsAPI, err := fake.Permissions("isk01")
+	require.NoError(t, err)
+	permissions := objectstorage.PreflightPermissionsAPI(permissionsAPI, status, "isk01")
+	controls := func(names ...string) v2.BucketControls {
+		var res v2.BucketControls
+		for _, name := range names {
+			res = append(res, v2.BucketControlsItem{BucketName: v2.NewOptBucketName(v2.BucketName(name)), CanRead: v2.NewOptCanRead(true)})
+		}
+		return res
+	}
+	_, err = permissions.Create(ctx, "perm1", controls("bucket1", "bucket2"))
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, objectstorage.QuotaBucketsPerPermission, e.Dimension)
+	require.Equal(t, 2, e.Requested)
+	permission, err := permissions.Create(ctx, "perm1", controls("bucket1"))
+	require.NoError(t, err)
+	permissionId := strconv.FormatInt(int64(permission.ID.Value), 10)
+	_, err = permissions.Create(ctx, "perm2", controls("bucket2"))
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, objectstorage.QuotaPermissions, e.Dimension)
+
+	_, err = permissions.Update(ctx, permissionId, "perm1", controls("bucket1", "bucket2"))
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, permissionId, e.PermissionID)
+	require.Equal(t, 1, e.Current)
+	require.Equal(t, 1, e.Requested)
+	_, err = permissions.Update(ctx, permissionId, "perm1", controls("bucket2"))
+	require.NoError(t, err)
+
+	_, err = permissions.CreateAccessKey(ctx, permissionId)
+	require.NoError(t, err)
+	_, err = permissions.CreateAccessKey(ctx, permissionId)
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, objectstorage.QuotaKeysPerPermission, e.Dimension)
+
+	accountsAPI, err := fake.Accounts("isk01")
+	require.NoError(t, err)
+	accounts := objectstorage.PreflightAccountAPI(accountsAPI, status, "isk01")
+	_, err = accounts.CreateAccessKey(ctx)
+	require.NoError(t, err)
+	_, err = accounts.CreateAccessKey(ctx)
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, objectstorage.QuotaRootKeys, e.Dimension)
+
+	// 制限値を取得できない場合は作成しない
+	fake.FailOn("SiteStatus.ReadQuota", errors.New("unavailable"))
+	_, err = buckets.Create(ctx, &objectstorage.BucketCreateParams{Bucket: "bucket3"})
+	require.Error(t, err)
+	require.NotErrorIs(t, err, objectstorage.ErrQuotaExceeded)
+}
+
+func TestHeadroom(t *testing.T) {
+	ctx := context.Background()
+	fake := newQuotaFake(t)
+	buckets, err := fake.Buckets("isk01")
+	require.NoError(t, err)
+	_, err = buckets.Create(ctx, &objectstorage.BucketCreateParams{Bucket: "bucket1"})
+	require.NoError(t, err)
+	permissions, err := fake.Permissions("isk01")
+	require.NoError(t, err)
+	permission, err := permissions.Create(ctx, "perm1", v2.BucketControls{{BucketName: v2.NewOptBucketName("bucket1")}})
+	require.NoError(t, err)
+	permissionId := strconv.FormatInt(int64(permission.ID.Value), 10)
+
+	headroom, err := objectstorage.Headroom(ctx, fake)
+	require.NoError(t, err)
+	require.Len(t, headroom, 2)
+	require.Equal(t, "isk01", headroom[0].SiteID)
+	require.Equal(t, []objectstorage.QuotaHeadroom{
+		{Dimension: objectstorage.QuotaRootKeys, Limit: 1, Used: 0, Remaining: 1},
+		{Dimension: objectstorage.QuotaBuckets, Limit: 2, Used: 1, Remaining: 1},
+		{Dimension: objectstorage.QuotaPermissions, Limit: 1, Used: 1, Remaining: 0},
+		{Dimension: objectstorage.QuotaKeysPerPermission, Limit: 1, Used: 0, Remaining: 1, PermissionID: permissionId},
+		{Dimension: objectstorage.QuotaBucketsPerPermission, Limit: 1, Used: 1, Remaining: 0, PermissionID: permissionId},
+	}, headroom[0].Quotas)
+	require.Equal(t, objectstorage.QuotaBuckets, headroom[1].Quotas[1].Dimension)
+	require.Equal(t, 0, headroom[1].Quotas[1].Used)
+
+	// サイトアカウントが存在しない場合はアクセスキーがないものとする
+	accounts, err := fake.Accounts("tky01")
+	require.NoError(t, err)
+	require.NoError(t, accounts.Delete(ctx))
+	headroom, err = objectstorage.Headroom(ctx, fake, "tky01")
+	require.NoError(t, err)
+	require.Len(t, headroom, 1)
+	require.Equal(t, objectstorage.QuotaHeadroom{Dimension: objectstorage.QuotaRootKeys, Limit: objectstoragetest.DefaultSiteQuota.NumRootKeys.Value, Remaining: objectstoragetest.DefaultSiteQuota.NumRootKeys.Value}, headroom[0].Quotas[0])
+
+	fake.FailOn("Permissions.List", errors.New("unavailable"))
+	headroom, err = objectstorage.Headroom(ctx, fake, "isk01")
+	require.Error(t, err)
+	require.Empty(t, headroom)
+}
@@ -0,0 +1,62 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstorage
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/sacloud/saclient-go"
+)
+
+// ErrReadOnly 読み取り専用モードで変更を伴うオペレーションを呼び出した
+var ErrReadOnly = errors.New("mutating operation is not allowed in read-only mode")
+
+// ReadOnlyError 読み取り専用モードで拒否したリクエスト。errors.Is(err, ErrReadOnly)で判定できる
+type ReadOnlyError struct {
+	// Operation 拒否したオペレーションの名前。オペレーションを解決できなかった場合は空
+	Operation string
+	Method    string
+	Path      string
+}
+
+func (e *ReadOnlyError) Error() string {
+	if e.Operation == "" {
+		return fmt.Sprintf("%s %s: %s", e.Method, e.Path, ErrReadOnly)
+	}
+	return fmt.Sprintf("%s: %s", e.Operation, ErrReadOnly)
+}
+
+func (e *ReadOnlyError) Unwrap() error {
+	return ErrReadOnly
+}
+
+// WithReadOnly 変更を伴う全てのオペレーションをHTTPリクエストの送信前にReadOnlyErrorで失敗させる
+//
+// 判定はラッパーではなくトランスポートでOperation.ReadOnlyにより行うため、ラッパーを経由しない呼び出しにも適用される
+func WithReadOnly() BackendOption {
+	return func(b *backend) { b.readOnly = true }
+}
+
+// ReadOnlyMiddleware 変更を伴うオペレーションのリクエストを送信せずにReadOnlyErrorを返すsaclientのミドルウェアを返す
+//
+// オペレーションを解決できないリクエストはGET/HEAD以外を拒否する
+func ReadOnlyMiddleware() saclient.Middleware {
+	return func(req *http.Request, pull func() (saclient.Middleware, bool)) (*http.Response, error) {
+		if routed, ok := ResolveOperation(req.Method, req.URL); ok {
+			if !routed.Operation.ReadOnly() {
+				return nil, &ReadOnlyError{Operation: routed.Operation.Name, Method: req.Method, Path: req.URL.Path}
+			}
+		} else if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			return nil, &ReadOnlyError{Method: req.Method, Path: req.URL.Path}
+		}
+
+		next, ok := pull()
+		if !ok {
+			return nil, NewError("no next middleware", nil)
+		}
+		return next(req, pull)
+	}
+}
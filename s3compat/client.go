@@ -0,0 +1,118 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package s3compat
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+)
+
+// NewClient サイトのS3互換APIにアクセスするためのminioクライアントを生成する
+//
+// エンドポイント/リージョンはサイトの情報から設定され、バケットへはパス形式でアクセスする。
+// transportがnilの場合はminio-goのデフォルトのトランスポートが用いられる
+func NewClient(site *v2.ModelCluster, creds Credentials, transport http.RoundTripper) (*minio.Client, error) {
+	if err := creds.validate(); err != nil {
+		return nil, err
+	}
+	endpoint, err := Endpoint(site)
+	if err != nil {
+		return nil, err
+	}
+	region, err := Region(site)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := minio.New(endpoint.Host, &minio.Options{
+		Creds:        credentials.NewStaticV4(creds.AccessKeyID, creds.SecretAccessKey, ""),
+		Region:       region,
+		Secure:       endpoint.Scheme == "https",
+		BucketLookup: minio.BucketLookupPath,
+		Transport:    transport,
+	})
+	if err != nil {
+		return nil, objectstorage.NewError("failed to create s3 client", err)
+	}
+	return client, nil
+}
+
+// ClientFactory サイトIDからS3互換APIのクライアントを生成する
+//
+// サイトの情報はほぼ変化しないため、一度参照したサイトの情報はキャッシュされる
+type ClientFactory struct {
+	siteAPI objectstorage.SiteAPI
+
+	// Transport S3互換APIへのリクエストに用いるhttp.RoundTripper。nilの場合はminio-goのデフォルト
+	Transport http.RoundTripper
+
+	mu    sync.Mutex
+	sites map[string]*v2.ModelCluster
+}
+
+// NewClientFactory ClientFactoryを生成する
+func NewClientFactory(siteAPI objectstorage.SiteAPI) *ClientFactory {
+	return &ClientFactory{siteAPI: siteAPI, sites: make(map[string]*v2.ModelCluster)}
+}
+
+// Site サイトの情報を返す。キャッシュに無い場合のみSiteAPI.Readを呼び出す
+func (f *ClientFactory) Site(ctx context.Context, siteId string) (*v2.ModelCluster, error) {
+	f.mu.Lock()
+	site, ok := f.sites[siteId]
+	f.mu.Unlock()
+	if ok {
+		return site, nil
+	}
+
+	site, err := f.siteAPI.Read(ctx, siteId)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if cached, ok := f.sites[siteId]; ok {
+		return cached, nil
+	}
+	f.sites[siteId] = site
+	return site, nil
+}
+
+// Forget キャッシュしているサイトの情報を破棄する。siteIdを省略した場合は全て破棄する
+func (f *ClientFactory) Forget(siteIds ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(siteIds) == 0 {
+		f.sites = make(map[string]*v2.ModelCluster)
+		return
+	}
+	for _, id := range siteIds {
+		delete(f.sites, id)
+	}
+}
+
+// New 指定サイトのS3互換APIにアクセスするためのminioクライアントを生成する
+func (f *ClientFactory) New(ctx context.Context, siteId string, creds Credentials) (*minio.Client, error) {
+	site, err := f.Site(ctx, siteId)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(site, creds, f.Transport)
+}
+
+// NewWithPermissionKey パーミッションキーを用いるminioクライアントを生成する
+func (f *ClientFactory) NewWithPermissionKey(ctx context.Context, siteId string, key *v2.PermissionKeyData) (*minio.Client, error) {
+	return f.New(ctx, siteId, CredentialsFromPermissionKey(key))
+}
+
+// NewWithAccountKey サイトアカウントのアクセスキーを用いるminioクライアントを生成する
+func (f *ClientFactory) NewWithAccountKey(ctx context.Context, siteId string, key *v2.AccountKeyData) (*minio.Client, error) {
+	return f.New(ctx, siteId, CredentialsFromAccountKey(key))
+}
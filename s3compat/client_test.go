@@ -0,0 +1,82 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package s3compat
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/sacloud/saclient-go"
+	"github.com/stretchr/testify/require"
+)
+
+type stubSiteAPI struct {
+	objectstorage.SiteAPI
+	sites map[string]*v2.ModelCluster
+	reads atomic.Int32
+}
+
+func (s *stubSiteAPI) Read(_ context.Context, id string) (*v2.ModelCluster, error) {
+	s.reads.Add(1)
+	site, ok := s.sites[id]
+	if !ok {
+		return nil, objectstorage.NewAPIError("Site.Read", http.StatusNotFound, nil)
+	}
+	return site, nil
+}
+
+func TestClientFactory(t *testing.T) {
+	var gotPath, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>` + //nolint:errcheck,gosec
+			`<ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Name>bucket1</Name><IsTruncated>false</IsTruncated>` +
+			`<Contents><Key>foo</Key><Size>3</Size></Contents></ListBucketResult>`))
+	}))
+	defer server.Close()
+
+	sites := &stubSiteAPI{sites: map[string]*v2.ModelCluster{
+		"isk01": {ID: v2.NewOptString("isk01"), Region: v2.NewOptString("jp-north-1"), S3Endpoint: v2.NewOptString(server.URL)},
+	}}
+	factory := NewClientFactory(sites)
+	ctx := context.Background()
+
+	for range 3 {
+		client, err := factory.NewWithPermissionKey(ctx, "isk01", &v2.PermissionKeyData{
+			ID:     v2.NewOptPermissionKeyID(v2.PermissionKeyID(testCredentials.AccessKeyID)),
+			Secret: v2.NewOptPermissionSecret(v2.PermissionSecret(testCredentials.SecretAccessKey)),
+		})
+		require.NoError(t, err)
+		require.False(t, client.EndpointURL().Scheme == "https")
+
+		var keys []string
+		for obj := range client.ListObjectsIter(ctx, "bucket1", minio.ListObjectsOptions{}) {
+			require.NoError(t, obj.Err)
+			keys = append(keys, obj.Key)
+		}
+		require.Equal(t, []string{"foo"}, keys)
+		require.Equal(t, "/bucket1/", gotPath, "bucket should be addressed in path-style")
+		require.True(t, strings.Contains(gotAuth, testCredentials.AccessKeyID+"/"), gotAuth)
+		require.True(t, strings.Contains(gotAuth, "/jp-north-1/s3/aws4_request"), gotAuth)
+	}
+	require.EqualValues(t, 1, sites.reads.Load(), "site lookups should be cached")
+
+	factory.Forget("isk01")
+	_, err := factory.New(ctx, "isk01", testCredentials)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, sites.reads.Load())
+
+	_, err = factory.New(ctx, "unknown", testCredentials)
+	require.Error(t, err)
+	require.True(t, saclient.IsNotFoundError(err))
+}
@@ -0,0 +1,310 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package s3compat
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+)
+
+// ConfigFormat 設定ファイルの形式
+type ConfigFormat string
+
+const (
+	// FormatAWSCredentials ~/.aws/credentials
+	FormatAWSCredentials ConfigFormat = "aws-credentials"
+	// FormatAWSConfig ~/.aws/config
+	FormatAWSConfig ConfigFormat = "aws-config"
+	// FormatRclone rclone.conf
+	FormatRclone ConfigFormat = "rclone"
+	// FormatS3cmd ~/.s3cfg
+	FormatS3cmd ConfigFormat = "s3cmd"
+	// FormatDotenv .envファイル
+	FormatDotenv ConfigFormat = "dotenv"
+)
+
+// ConfigFormats 対応している全ての形式
+var ConfigFormats = []ConfigFormat{FormatAWSCredentials, FormatAWSConfig, FormatRclone, FormatS3cmd, FormatDotenv}
+
+// ConfigProfile 設定ファイル生成のためのパラメータ
+type ConfigProfile struct {
+	// Name プロファイル名/リモート名/セクション名。空の場合は"default"
+	//
+	// s3cmdは[default]セクションのみを読み込むため、FormatS3cmdでは無視され常に"default"となる。
+	// 複数の設定を使い分ける場合はファイルを分けてs3cmdの-cオプションで指定する
+	Name        string
+	Site        *v2.ModelCluster
+	Credentials Credentials
+}
+
+func (p *ConfigProfile) name() string {
+	if p.Name == "" {
+		return "default"
+	}
+	return p.Name
+}
+
+// RenderConfig 指定の形式で設定ファイルの断片を生成する
+//
+// いずれの形式でもバケットはパス形式(path-style)でアクセスするように設定される
+func RenderConfig(format ConfigFormat, profile *ConfigProfile) (string, error) {
+	if err := profile.Credentials.validate(); err != nil {
+		return "", err
+	}
+	endpoint, err := Endpoint(profile.Site)
+	if err != nil {
+		return "", err
+	}
+	region, err := Region(profile.Site)
+	if err != nil {
+		return "", err
+	}
+	name := profile.name()
+	if strings.ContainsAny(name, "[]\r\n") {
+		return "", objectstorage.NewError("invalid profile name: "+name, nil)
+	}
+
+	var buf strings.Builder
+	switch format {
+	case FormatAWSCredentials:
+		fmt.Fprintf(&buf, "[%s]\n", name)
+		fmt.Fprintf(&buf, "aws_access_key_id = %s\n", profile.Credentials.AccessKeyID)
+		fmt.Fprintf(&buf, "aws_secret_access_key = %s\n", profile.Credentials.SecretAccessKey)
+	case FormatAWSConfig:
+		if name == "default" {
+			buf.WriteString("[default]\n")
+		} else {
+			fmt.Fprintf(&buf, "[profile %s]\n", name)
+		}
+		fmt.Fprintf(&buf, "region = %s\n", region)
+		fmt.Fprintf(&buf, "endpoint_url = %s\n", endpoint)
+		buf.WriteString("s3 =\n")
+		buf.WriteString("    addressing_style = path\n")
+	case FormatRclone:
+		fmt.Fprintf(&buf, "[%s]\n", name)
+		buf.WriteString("type = s3\n")
+		buf.WriteString("provider = Other\n")
+		fmt.Fprintf(&buf, "access_key_id = %s\n", profile.Credentials.AccessKeyID)
+		fmt.Fprintf(&buf, "secret_access_key = %s\n", profile.Credentials.SecretAccessKey)
+		fmt.Fprintf(&buf, "region = %s\n", region)
+		fmt.Fprintf(&buf, "endpoint = %s\n", endpoint)
+		buf.WriteString("force_path_style = true\n")
+	case FormatS3cmd:
+		buf.WriteString("[default]\n")
+		fmt.Fprintf(&buf, "access_key = %s\n", profile.Credentials.AccessKeyID)
+		fmt.Fprintf(&buf, "secret_key = %s\n", profile.Credentials.SecretAccessKey)
+		fmt.Fprintf(&buf, "bucket_location = %s\n", region)
+		fmt.Fprintf(&buf, "host_base = %s\n", endpoint.Host)
+		// %(bucket)sを含めないことでパス形式でのアクセスとなる
+		fmt.Fprintf(&buf, "host_bucket = %s\n", endpoint.Host)
+		fmt.Fprintf(&buf, "use_https = %s\n", map[bool]string{true: "True", false: "False"}[endpoint.Scheme == "https"])
+		buf.WriteString("signature_v2 = False\n")
+	case FormatDotenv:
+		fmt.Fprintf(&buf, "AWS_ACCESS_KEY_ID=%s\n", dotenvQuote(profile.Credentials.AccessKeyID))
+		fmt.Fprintf(&buf, "AWS_SECRET_ACCESS_KEY=%s\n", dotenvQuote(profile.Credentials.SecretAccessKey))
+		fmt.Fprintf(&buf, "AWS_REGION=%s\n", dotenvQuote(region))
+		fmt.Fprintf(&buf, "AWS_DEFAULT_REGION=%s\n", dotenvQuote(region))
+		fmt.Fprintf(&buf, "AWS_ENDPOINT_URL_S3=%s\n", dotenvQuote(endpoint.String()))
+		// AWS SDKには対応する環境変数が無いため、この値を参照するツール向け
+		buf.WriteString("AWS_S3_FORCE_PATH_STYLE=true\n")
+	default:
+		return "", objectstorage.NewError("unsupported config format: "+string(format), nil)
+	}
+	return buf.String(), nil
+}
+
+func dotenvQuote(v string) string {
+	if v != "" && !strings.ContainsAny(v, " \t\"'#$\\=`") {
+		return v
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`").Replace(v) + `"`
+}
+
+// MergeConfig 既存の設定ファイルの内容にrenderedをマージする
+//
+// INI形式ではrenderedに含まれるセクションのみを置き換え(存在しなければ末尾に追加)、
+// dotenv形式ではrenderedに含まれるキーのみを置き換える。それ以外の内容はそのまま維持される
+func MergeConfig(format ConfigFormat, existing []byte, rendered string) ([]byte, error) {
+	switch format {
+	case FormatAWSCredentials, FormatAWSConfig, FormatRclone, FormatS3cmd:
+		return mergeINI(existing, rendered), nil
+	case FormatDotenv:
+		return mergeDotenv(existing, rendered), nil
+	default:
+		return nil, objectstorage.NewError("unsupported config format: "+string(format), nil)
+	}
+}
+
+// MergeConfigFile pathの設定ファイルにrenderedをマージして書き込む
+//
+// ファイルが存在しない場合は新規に作成する。シークレットを含むためパーミッションは0600となる
+func MergeConfigFile(format ConfigFormat, path string, rendered string) error {
+	existing, err := os.ReadFile(path) //nolint:gosec
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return objectstorage.NewError("failed to read config file", err)
+	}
+	merged, err := MergeConfig(format, existing, rendered)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return objectstorage.NewError("failed to create config directory", err)
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return objectstorage.NewError("failed to create temporary file", err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+	if _, err := tmp.Write(merged); err != nil {
+		tmp.Close() //nolint:errcheck,gosec
+		return objectstorage.NewError("failed to write config file", err)
+	}
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close() //nolint:errcheck,gosec
+		return objectstorage.NewError("failed to write config file", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return objectstorage.NewError("failed to write config file", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return objectstorage.NewError("failed to write config file", err)
+	}
+	return nil
+}
+
+type iniSection struct {
+	name  string // ヘッダ行が無い先頭部分は空
+	lines []string
+}
+
+func parseINI(data string) []*iniSection {
+	sections := []*iniSection{{}}
+	for _, line := range splitLines(data) {
+		if name, ok := iniHeader(line); ok {
+			sections = append(sections, &iniSection{name: name})
+		}
+		cur := sections[len(sections)-1]
+		cur.lines = append(cur.lines, line)
+	}
+	return sections
+}
+
+func iniHeader(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if len(trimmed) < 2 || trimmed[0] != '[' || trimmed[len(trimmed)-1] != ']' {
+		return "", false
+	}
+	return strings.TrimSpace(trimmed[1 : len(trimmed)-1]), true
+}
+
+func mergeINI(existing []byte, rendered string) []byte {
+	sections := parseINI(string(existing))
+	for _, sec := range parseINI(rendered)[1:] {
+		replaced := false
+		for i, cur := range sections {
+			if cur.name != "" && cur.name == sec.name {
+				// 後続セクションとの区切りの空行は維持する
+				sections[i] = &iniSection{name: sec.name, lines: append(sec.lines, trailingBlankLines(cur.lines)...)}
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			last := sections[len(sections)-1]
+			if len(last.lines) > 0 && strings.TrimSpace(last.lines[len(last.lines)-1]) != "" {
+				last.lines = append(last.lines, "")
+			}
+			sections = append(sections, sec)
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, sec := range sections {
+		for _, line := range sec.lines {
+			buf.WriteString(line)
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes()
+}
+
+func trailingBlankLines(lines []string) []string {
+	i := len(lines)
+	for i > 0 && strings.TrimSpace(lines[i-1]) == "" {
+		i--
+	}
+	return lines[i:]
+}
+
+func dotenvKey(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return "", false
+	}
+	trimmed = strings.TrimPrefix(trimmed, "export ")
+	key, _, ok := strings.Cut(trimmed, "=")
+	if !ok {
+		return "", false
+	}
+	return strings.TrimSpace(key), true
+}
+
+func mergeDotenv(existing []byte, rendered string) []byte {
+	values := map[string]string{}
+	var order []string
+	for _, line := range splitLines(rendered) {
+		if key, ok := dotenvKey(line); ok {
+			if _, dup := values[key]; !dup {
+				order = append(order, key)
+			}
+			values[key] = line
+		}
+	}
+
+	written := map[string]bool{}
+	var buf bytes.Buffer
+	for _, line := range splitLines(string(existing)) {
+		if key, ok := dotenvKey(line); ok {
+			if newLine, found := values[key]; found {
+				if written[key] {
+					continue // 重複していた古い定義は削除
+				}
+				// exportの有無は既存の行に合わせる
+				if strings.HasPrefix(strings.TrimSpace(line), "export ") {
+					newLine = "export " + strings.TrimPrefix(newLine, "export ")
+				}
+				line = newLine
+				written[key] = true
+			}
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	for _, key := range order {
+		if !written[key] {
+			buf.WriteString(values[key])
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes()
+}
+
+func splitLines(data string) []string {
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	data = strings.TrimSuffix(data, "\n")
+	if data == "" {
+		return nil
+	}
+	return strings.Split(data, "\n")
+}
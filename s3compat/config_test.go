@@ -0,0 +1,180 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package s3compat
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/stretchr/testify/require"
+)
+
+var testSite = &v2.ModelCluster{
+	ID:         v2.NewOptString("isk01"),
+	Region:     v2.NewOptString("jp-north-1"),
+	S3Endpoint: v2.NewOptString("s3.isk01.sakurastorage.jp"),
+}
+
+var testCredentials = Credentials{AccessKeyID: "XPJK4SC9883N91RHR253", SecretAccessKey: "jqRaUo5l+EiEYqP8wos9exbmFfq4/vG8CLPYI2XN"}
+
+func TestRenderConfig(t *testing.T) {
+	profile := &ConfigProfile{Name: "isk01", Site: testSite, Credentials: testCredentials}
+
+	tests := []struct {
+		format ConfigFormat
+		want   string
+	}{
+		{
+			format: FormatAWSCredentials,
+			want: `[isk01]
+aws_access_key_id = XPJK4SC9883N91RHR253
+aws_secret_access_key = jqRaUo5l+EiEYqP8wos9exbmFfq4/vG8CLPYI2XN
+`,
+		},
+		{
+			format: FormatAWSConfig,
+			want: `[profile isk01]
+region = jp-north-1
+endpoint_url = https://s3.isk01.sakurastorage.jp
+s3 =
+    addressing_style = path
+`,
+		},
+		{
+			format: FormatRclone,
+			want: `[isk01]
+type = s3
+provider = Other
+access_key_id = XPJK4SC9883N91RHR253
+secret_access_key = jqRaUo5l+EiEYqP8wos9exbmFfq4/vG8CLPYI2XN
+region = jp-north-1
+endpoint = https://s3.isk01.sakurastorage.jp
+force_path_style = true
+`,
+		},
+		{
+			format: FormatS3cmd,
+			want: `[default]
+access_key = XPJK4SC9883N91RHR253
+secret_key = jqRaUo5l+EiEYqP8wos9exbmFfq4/vG8CLPYI2XN
+bucket_location = jp-north-1
+host_base = s3.isk01.sakurastorage.jp
+host_bucket = s3.isk01.sakurastorage.jp
+use_https = True
+signature_v2 = False
+`,
+		},
+		{
+			format: FormatDotenv,
+			want: `AWS_ACCESS_KEY_ID=XPJK4SC9883N91RHR253
+AWS_SECRET_ACCESS_KEY=jqRaUo5l+EiEYqP8wos9exbmFfq4/vG8CLPYI2XN
+AWS_REGION=jp-north-1
+AWS_DEFAULT_REGION=jp-north-1
+AWS_ENDPOINT_URL_S3=https://s3.isk01.sakurastorage.jp
+AWS_S3_FORCE_PATH_STYLE=true
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			got, err := RenderConfig(tt.format, profile)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("default profile", func(t *testing.T) {
+		got, err := RenderConfig(FormatAWSConfig, &ConfigProfile{Site: testSite, Credentials: testCredentials})
+		require.NoError(t, err)
+		require.Contains(t, got, "[default]\n")
+	})
+
+	t.Run("missing endpoint", func(t *testing.T) {
+		_, err := RenderConfig(FormatRclone, &ConfigProfile{Site: &v2.ModelCluster{Region: v2.NewOptString("jp-north-1")}, Credentials: testCredentials})
+		require.Error(t, err)
+	})
+
+	t.Run("missing secret", func(t *testing.T) {
+		_, err := RenderConfig(FormatRclone, &ConfigProfile{Site: testSite, Credentials: Credentials{AccessKeyID: "foo"}})
+		require.Error(t, err)
+	})
+}
+
+func TestMergeConfig(t *testing.T) {
+	t.Run("ini replaces only the same section", func(t *testing.T) {
+		existing := `# managed by hand
+[default]
+aws_access_key_id = AAA
+aws_secret_access_key = aaa
+
+[isk01]
+aws_access_key_id = OLD
+aws_secret_access_key = old
+
+[other]
+aws_access_key_id = BBB
+aws_secret_access_key = bbb
+`
+		rendered, err := RenderConfig(FormatAWSCredentials, &ConfigProfile{Name: "isk01", Site: testSite, Credentials: testCredentials})
+		require.NoError(t, err)
+
+		merged, err := MergeConfig(FormatAWSCredentials, []byte(existing), rendered)
+		require.NoError(t, err)
+		require.Equal(t, `# managed by hand
+[default]
+aws_access_key_id = AAA
+aws_secret_access_key = aaa
+
+[isk01]
+aws_access_key_id = XPJK4SC9883N91RHR253
+aws_secret_access_key = jqRaUo5l+EiEYqP8wos9exbmFfq4/vG8CLPYI2XN
+
+[other]
+aws_access_key_id = BBB
+aws_secret_access_key = bbb
+`, string(merged))
+	})
+
+	t.Run("ini appends a new section", func(t *testing.T) {
+		existing := "[default]\nregion = us-east-1\n"
+		rendered, err := RenderConfig(FormatAWSConfig, &ConfigProfile{Name: "isk01", Site: testSite, Credentials: testCredentials})
+		require.NoError(t, err)
+
+		merged, err := MergeConfig(FormatAWSConfig, []byte(existing), rendered)
+		require.NoError(t, err)
+		require.Equal(t, existing+"\n"+rendered, string(merged))
+	})
+
+	t.Run("dotenv replaces only rendered keys", func(t *testing.T) {
+		existing := "APP_ENV=production\nexport AWS_ACCESS_KEY_ID=OLD\nAWS_REGION=us-east-1\nAWS_REGION=dup\n"
+		merged, err := MergeConfig(FormatDotenv, []byte(existing), "AWS_ACCESS_KEY_ID=NEW\nAWS_REGION=jp-north-1\nAWS_ENDPOINT_URL_S3=https://example.com\n")
+		require.NoError(t, err)
+		require.Equal(t, "APP_ENV=production\nexport AWS_ACCESS_KEY_ID=NEW\nAWS_REGION=jp-north-1\nAWS_ENDPOINT_URL_S3=https://example.com\n", string(merged))
+	})
+}
+
+func TestMergeConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rclone.conf")
+
+	for _, name := range []string{"first", "second", "first"} {
+		rendered, err := RenderConfig(FormatRclone, &ConfigProfile{Name: name, Site: testSite, Credentials: testCredentials})
+		require.NoError(t, err)
+		require.NoError(t, MergeConfigFile(FormatRclone, path, rendered))
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec
+	require.NoError(t, err)
+	sections := parseINI(string(data))
+	require.Len(t, sections, 3)
+	require.Equal(t, "first", sections[1].name)
+	require.Equal(t, "second", sections[2].name)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	if filepath.Separator == '/' {
+		require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package s3compat サイトのS3互換APIを利用するためのヘルパー群
+package s3compat
+
+import (
+	"net/url"
+	"strings"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+)
+
+// Credentials S3互換APIへのアクセスに用いるアクセスキー
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// CredentialsFromPermissionKey パーミッションキーからCredentialsを組み立てる
+//
+// Secretはキー作成時の戻り値でのみ参照可能なため、PermissionsAPI.CreateAccessKeyの戻り値を渡すこと
+func CredentialsFromPermissionKey(key *v2.PermissionKeyData) Credentials {
+	return Credentials{
+		AccessKeyID:     string(key.ID.Value),
+		SecretAccessKey: string(key.Secret.Value),
+	}
+}
+
+// CredentialsFromAccountKey サイトアカウントのアクセスキーからCredentialsを組み立てる
+//
+// Secretはキー作成時の戻り値でのみ参照可能なため、AccountAPI.CreateAccessKeyの戻り値を渡すこと
+func CredentialsFromAccountKey(key *v2.AccountKeyData) Credentials {
+	return Credentials{
+		AccessKeyID:     string(key.ID.Value),
+		SecretAccessKey: string(key.Secret.Value),
+	}
+}
+
+func (c Credentials) validate() error {
+	if c.AccessKeyID == "" {
+		return objectstorage.NewError("access key id is empty", nil)
+	}
+	if c.SecretAccessKey == "" {
+		return objectstorage.NewError("secret access key is empty", nil)
+	}
+	return nil
+}
+
+// Endpoint サイトのS3互換APIのエンドポイントURLを返す
+//
+// S3Endpointはスキーム無しのホスト名で返されるため、スキームが無い場合はhttpsとみなす
+func Endpoint(site *v2.ModelCluster) (*url.URL, error) {
+	if site == nil {
+		return nil, objectstorage.NewError("site is nil", nil)
+	}
+	endpoint, ok := site.S3Endpoint.Get()
+	if !ok || endpoint == "" {
+		return nil, objectstorage.NewError("site has no s3 endpoint: "+site.ID.Value, nil)
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, objectstorage.NewError("invalid s3 endpoint", err)
+	}
+	if u.Host == "" {
+		return nil, objectstorage.NewError("invalid s3 endpoint: "+endpoint, nil)
+	}
+	u.Path = ""
+	return u, nil
+}
+
+// Region サイトのリージョンを返す
+func Region(site *v2.ModelCluster) (string, error) {
+	if site == nil {
+		return "", objectstorage.NewError("site is nil", nil)
+	}
+	region, ok := site.Region.Get()
+	if !ok || region == "" {
+		return "", objectstorage.NewError("site has no region: "+site.ID.Value, nil)
+	}
+	return region, nil
+}
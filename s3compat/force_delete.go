@@ -0,0 +1,352 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package s3compat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+)
+
+const (
+	// DefaultForceDeleteConcurrency ForceDeleteで同時に実行する一括削除リクエスト数のデフォルト値
+	DefaultForceDeleteConcurrency = 4
+	// DefaultKeyReadyTimeout 一時的に作成したキーが利用可能になるまでの待ち時間のデフォルト値
+	DefaultKeyReadyTimeout = 30 * time.Second
+
+	forceDeleteBatchSize = 1000
+)
+
+// ForceDeleteOptions ForceDeleteのオプション
+type ForceDeleteOptions struct {
+	// Credentials バケットを操作するためのキー。nilの場合は対象バケットのみに権限を持つ一時的なパーミッションとキーを作成し、終了時に削除する
+	Credentials *Credentials
+	// Concurrency 同時に実行する一括削除リクエスト数。0以下の場合はDefaultForceDeleteConcurrency
+	Concurrency int
+	// DryRun trueの場合はオブジェクトの数え上げのみを行い、削除は行わない。
+	// 一時的なパーミッションとキーの作成も変更となるため、Credentialsの指定が必要
+	DryRun bool
+	// Progress 進捗の通知先。複数のgoroutineから呼ばれることは無い
+	Progress func(ForceDeleteProgress)
+	// Transport S3互換APIへのリクエストに用いるhttp.RoundTripper。nilの場合はデフォルト
+	Transport http.RoundTripper
+	// KeyReadyTimeout 一時的に作成したキーが利用可能になるまで待つ時間。0の場合はDefaultKeyReadyTimeout
+	KeyReadyTimeout time.Duration
+}
+
+// ForceDeletePhase ForceDeleteの処理段階
+type ForceDeletePhase string
+
+const (
+	ForceDeletePhaseUploads ForceDeletePhase = "uploads"
+	ForceDeletePhaseObjects ForceDeletePhase = "objects"
+	ForceDeletePhaseBucket  ForceDeletePhase = "bucket"
+)
+
+// ForceDeleteProgress ForceDeleteの進捗
+type ForceDeleteProgress struct {
+	Bucket string
+	Phase  ForceDeletePhase
+	// Uploads 見つかった未完了のマルチパートアップロードの数
+	Uploads int
+	// Aborted 中断したマルチパートアップロードの数
+	Aborted int
+	// Objects 見つかったオブジェクト(バージョン、削除マーカーを含む)の数
+	Objects int
+	// Deleted 削除したオブジェクトの数
+	Deleted int
+}
+
+// ForceDeleteResult ForceDeleteの結果
+type ForceDeleteResult struct {
+	ForceDeleteProgress
+	DryRun bool
+	// BucketDeleted バケット自体を削除したか
+	BucketDeleted bool
+}
+
+// ForceDelete バケット内の全てのオブジェクトを削除した上でバケットを削除する
+//
+// オブジェクトの全てのバージョン/削除マーカー、未完了のマルチパートアップロードも削除対象となる。
+// オブジェクトはサイトのS3互換APIを通じて一括削除し、その後BucketAPI.Deleteでバケットを削除する
+func ForceDelete(ctx context.Context, site *v2.ModelCluster, buckets objectstorage.BucketAPI, permissions objectstorage.PermissionsAPI, bucket string, opts *ForceDeleteOptions) (result *ForceDeleteResult, err error) {
+	if opts == nil {
+		opts = &ForceDeleteOptions{}
+	}
+	if opts.DryRun && opts.Credentials == nil {
+		return nil, objectstorage.NewError("credentials are required for dry run: creating a temporary permission and key would modify the account", nil)
+	}
+	fd := &forceDeleter{
+		bucket:   bucket,
+		opts:     opts,
+		progress: ForceDeleteProgress{Bucket: bucket},
+	}
+
+	creds, cleanup, err := fd.credentials(ctx, site, permissions)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cerr := cleanup(); cerr != nil {
+			err = errors.Join(err, cerr)
+		}
+	}()
+
+	fd.client, err = NewClient(site, creds, opts.Transport)
+	if err != nil {
+		return nil, err
+	}
+	fd.core = &minio.Core{Client: fd.client}
+
+	if err := fd.abortUploads(ctx); err != nil {
+		return nil, err
+	}
+	if err := fd.deleteObjects(ctx); err != nil {
+		return nil, err
+	}
+
+	result = &ForceDeleteResult{ForceDeleteProgress: fd.progress, DryRun: opts.DryRun}
+	if opts.DryRun {
+		return result, nil
+	}
+
+	fd.report(func(p *ForceDeleteProgress) { p.Phase = ForceDeletePhaseBucket })
+	if err := buckets.Delete(ctx, bucket); err != nil {
+		return nil, err
+	}
+	result.BucketDeleted = true
+	return result, nil
+}
+
+type forceDeleter struct {
+	bucket string
+	opts   *ForceDeleteOptions
+	client *minio.Client
+	core   *minio.Core
+
+	mu       sync.Mutex
+	progress ForceDeleteProgress
+}
+
+func (fd *forceDeleter) report(update func(p *ForceDeleteProgress)) {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	update(&fd.progress)
+	if fd.opts.Progress != nil {
+		fd.opts.Progress(fd.progress)
+	}
+}
+
+// credentials オプションで指定されたキー、もしくは一時的に作成したキーを返す
+func (fd *forceDeleter) credentials(ctx context.Context, site *v2.ModelCluster, permissions objectstorage.PermissionsAPI) (Credentials, func() error, error) {
+	noop := func() error { return nil }
+	if fd.opts.Credentials != nil {
+		return *fd.opts.Credentials, noop, nil
+	}
+
+	permission, err := permissions.Create(ctx, "force-delete-"+fd.bucket, v2.BucketControls{
+		{
+			BucketName: v2.NewOptBucketName(v2.BucketName(fd.bucket)),
+			CanRead:    v2.NewOptCanRead(true),
+			CanWrite:   v2.NewOptCanWrite(true),
+		},
+	})
+	if err != nil {
+		return Credentials{}, nil, err
+	}
+	permissionId := strconv.FormatInt(int64(permission.ID.Value), 10)
+	// 呼び出し元のctxがキャンセルされていても後始末は行う
+	cleanupCtx := context.WithoutCancel(ctx)
+	deletePermission := func() error { return permissions.Delete(cleanupCtx, permissionId) }
+
+	key, err := permissions.CreateAccessKey(ctx, permissionId)
+	if err != nil {
+		return Credentials{}, nil, errors.Join(err, deletePermission())
+	}
+	creds := CredentialsFromPermissionKey(key)
+	cleanup := func() error {
+		return errors.Join(
+			permissions.DeleteAccessKey(cleanupCtx, permissionId, string(key.ID.Value)),
+			deletePermission(),
+		)
+	}
+
+	if err := fd.waitForKey(ctx, site, creds); err != nil {
+		return Credentials{}, nil, errors.Join(err, cleanup())
+	}
+	return creds, cleanup, nil
+}
+
+// waitForKey 作成直後のキーが反映されるまで待つ
+func (fd *forceDeleter) waitForKey(ctx context.Context, site *v2.ModelCluster, creds Credentials) error {
+	signer, err := NewSigner(site, creds)
+	if err != nil {
+		return err
+	}
+	timeout := fd.opts.KeyReadyTimeout
+	if timeout <= 0 {
+		timeout = DefaultKeyReadyTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	client := &http.Client{Transport: fd.opts.Transport}
+
+	for {
+		ok, err := probe(ctx, client, signer, http.MethodGet, fd.bucket, "", map[string][]string{"list-type": {"2"}, "max-keys": {"0"}})
+		var s3err *S3Error
+		switch {
+		case ok:
+			return nil
+		case err != nil && !(errors.As(err, &s3err) && s3err.Code == "InvalidAccessKeyId"):
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return objectstorage.NewError("temporary access key did not become ready", ctx.Err())
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (fd *forceDeleter) abortUploads(ctx context.Context) error {
+	fd.report(func(p *ForceDeleteProgress) { p.Phase = ForceDeletePhaseUploads })
+
+	var keyMarker, uploadIdMarker string
+	for {
+		res, err := fd.core.ListMultipartUploads(ctx, fd.bucket, "", keyMarker, uploadIdMarker, "", forceDeleteBatchSize)
+		if err != nil {
+			if minio.ToErrorResponse(err).Code == "NotImplemented" {
+				return nil
+			}
+			return objectstorage.NewError("failed to list multipart uploads", err)
+		}
+		for _, upload := range res.Uploads {
+			fd.report(func(p *ForceDeleteProgress) { p.Uploads++ })
+			if fd.opts.DryRun {
+				continue
+			}
+			if err := fd.core.AbortMultipartUpload(ctx, fd.bucket, upload.Key, upload.UploadID); err != nil {
+				return objectstorage.NewError("failed to abort multipart upload", err)
+			}
+			fd.report(func(p *ForceDeleteProgress) { p.Aborted++ })
+		}
+		if !res.IsTruncated {
+			return nil
+		}
+		keyMarker, uploadIdMarker = res.NextKeyMarker, res.NextUploadIDMarker
+	}
+}
+
+func (fd *forceDeleter) deleteObjects(ctx context.Context) error {
+	fd.report(func(p *ForceDeleteProgress) { p.Phase = ForceDeletePhaseObjects })
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	batches := make(chan []minio.ObjectInfo)
+	var (
+		wg       sync.WaitGroup
+		errMu    sync.Mutex
+		errs     []error
+		addError = func(err error) {
+			errMu.Lock()
+			defer errMu.Unlock()
+			errs = append(errs, err)
+			cancel()
+		}
+	)
+	concurrency := fd.opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultForceDeleteConcurrency
+	}
+	for range concurrency {
+		wg.Go(func() {
+			for batch := range batches {
+				if err := fd.deleteBatch(ctx, batch); err != nil {
+					addError(err)
+				}
+			}
+		})
+	}
+
+	listErr := fd.listObjects(ctx, func(batch []minio.ObjectInfo) bool {
+		fd.report(func(p *ForceDeleteProgress) { p.Objects += len(batch) })
+		if fd.opts.DryRun {
+			return true
+		}
+		select {
+		case batches <- batch:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+	close(batches)
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return objectstorage.NewError("failed to delete objects", errors.Join(errs...))
+	}
+	if listErr != nil {
+		return objectstorage.NewError("failed to list objects", listErr)
+	}
+	return nil
+}
+
+// listObjects オブジェクトを全バージョン含めて列挙する。バージョン一覧に未対応の場合は通常の一覧にフォールバックする
+func (fd *forceDeleter) listObjects(ctx context.Context, yield func([]minio.ObjectInfo) bool) error {
+	list := func(withVersions bool) (listed bool, err error) {
+		batch := make([]minio.ObjectInfo, 0, forceDeleteBatchSize)
+		for obj := range fd.client.ListObjectsIter(ctx, fd.bucket, minio.ListObjectsOptions{Recursive: true, WithVersions: withVersions}) {
+			if obj.Err != nil {
+				return listed, obj.Err
+			}
+			listed = true
+			batch = append(batch, obj)
+			if len(batch) == forceDeleteBatchSize {
+				if !yield(batch) {
+					return listed, ctx.Err()
+				}
+				batch = make([]minio.ObjectInfo, 0, forceDeleteBatchSize)
+			}
+		}
+		if len(batch) > 0 && !yield(batch) {
+			return listed, ctx.Err()
+		}
+		return listed, nil
+	}
+
+	listed, err := list(true)
+	if err != nil && !listed && minio.ToErrorResponse(err).Code == "NotImplemented" {
+		_, err = list(false)
+	}
+	return err
+}
+
+func (fd *forceDeleter) deleteBatch(ctx context.Context, batch []minio.ObjectInfo) error {
+	objects := make(chan minio.ObjectInfo, len(batch))
+	for _, obj := range batch {
+		objects <- obj
+	}
+	close(objects)
+
+	var errs []error
+	deleted := 0
+	for res := range fd.client.RemoveObjectsWithResult(ctx, fd.bucket, objects, minio.RemoveObjectsOptions{}) {
+		if res.Err != nil {
+			errs = append(errs, res.Err)
+			continue
+		}
+		deleted++
+	}
+	fd.report(func(p *ForceDeleteProgress) { p.Deleted += deleted })
+	return errors.Join(errs...)
+}
@@ -0,0 +1,167 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package s3compat
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/stretchr/testify/require"
+)
+
+type stubBucketAPI struct {
+	objectstorage.BucketAPI
+	deleted []string
+}
+
+func (s *stubBucketAPI) Delete(_ context.Context, bucketName string) error {
+	s.deleted = append(s.deleted, bucketName)
+	return nil
+}
+
+// stubPermissionsAPI パーミッション/キーの作成時にfakeS3へ権限を付与する
+type stubPermissionsAPI struct {
+	objectstorage.PermissionsAPI
+	s3          *fakeS3
+	controls    map[string]v2.BucketControls
+	keys        map[string]string
+	nextID      int
+	deletedKeys []string
+}
+
+func newStubPermissionsAPI(s3 *fakeS3) *stubPermissionsAPI {
+	return &stubPermissionsAPI{s3: s3, controls: map[string]v2.BucketControls{}, keys: map[string]string{}}
+}
+
+func (s *stubPermissionsAPI) Create(_ context.Context, displayName string, controls v2.BucketControls) (*v2.PermissionData, error) {
+	s.nextID++
+	s.controls[strconv.Itoa(s.nextID)] = controls
+	return &v2.PermissionData{
+		ID:          v2.NewOptPermissionID(v2.PermissionID(s.nextID)),
+		DisplayName: v2.NewOptDisplayName(v2.DisplayName(displayName)),
+	}, nil
+}
+
+func (s *stubPermissionsAPI) Delete(_ context.Context, permissionId string) error {
+	delete(s.controls, permissionId)
+	return nil
+}
+
+func (s *stubPermissionsAPI) CreateAccessKey(_ context.Context, permissionId string) (*v2.PermissionKeyData, error) {
+	ak := fmt.Sprintf("TEMPORARY%s", permissionId)
+	s.keys[ak] = permissionId
+	for _, c := range s.controls[permissionId] {
+		s.s3.grant(ak, string(c.BucketName.Value), bool(c.CanRead.Value), bool(c.CanWrite.Value))
+	}
+	return &v2.PermissionKeyData{
+		ID:     v2.NewOptPermissionKeyID(v2.PermissionKeyID(ak)),
+		Secret: v2.NewOptPermissionSecret("secret"),
+	}, nil
+}
+
+func (s *stubPermissionsAPI) DeleteAccessKey(_ context.Context, _ string, accessKeyId string) error {
+	delete(s.keys, accessKeyId)
+	s.deletedKeys = append(s.deletedKeys, accessKeyId)
+	return nil
+}
+
+func TestForceDelete(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("with temporary permission", func(t *testing.T) {
+		s3 := newFakeS3(t)
+		s3.createBucket("bucket1", "a", "b", "c")
+		s3.addVersion("bucket1", "a", "v1", false)
+		s3.addVersion("bucket1", "b", "dm1", true)
+		s3.addUpload("bucket1", "large", "upload1")
+		buckets := &stubBucketAPI{}
+		permissions := newStubPermissionsAPI(s3)
+
+		var progress []ForceDeleteProgress
+		result, err := ForceDelete(ctx, s3.site(), buckets, permissions, "bucket1", &ForceDeleteOptions{
+			Progress: func(p ForceDeleteProgress) { progress = append(progress, p) },
+		})
+		require.NoError(t, err)
+		require.Equal(t, 5, result.Objects)
+		require.Equal(t, 5, result.Deleted)
+		require.Equal(t, 1, result.Uploads)
+		require.Equal(t, 1, result.Aborted)
+		require.True(t, result.BucketDeleted)
+		require.Equal(t, 0, s3.remaining("bucket1"))
+		require.Equal(t, []string{"bucket1"}, buckets.deleted)
+
+		// 一時的なパーミッションとキーは削除されている
+		require.Empty(t, permissions.controls)
+		require.Empty(t, permissions.keys)
+		require.Equal(t, []string{"TEMPORARY1"}, permissions.deletedKeys)
+
+		require.Equal(t, ForceDeletePhaseBucket, progress[len(progress)-1].Phase)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		s3 := newFakeS3(t)
+		s3.createBucket("bucket1", "a", "b")
+		s3.addUpload("bucket1", "large", "upload1")
+		s3.grant(testCredentials.AccessKeyID, "bucket1", true, true)
+		buckets := &stubBucketAPI{}
+
+		result, err := ForceDelete(ctx, s3.site(), buckets, nil, "bucket1", &ForceDeleteOptions{
+			Credentials: &testCredentials,
+			DryRun:      true,
+		})
+		require.NoError(t, err)
+		require.True(t, result.DryRun)
+		require.Equal(t, 2, result.Objects)
+		require.Equal(t, 0, result.Deleted)
+		require.Equal(t, 1, result.Uploads)
+		require.Equal(t, 0, result.Aborted)
+		require.False(t, result.BucketDeleted)
+		require.Equal(t, 3, s3.remaining("bucket1"))
+		require.Empty(t, buckets.deleted)
+
+		// 一時的なパーミッションとキーは作成しない
+		permissions := newStubPermissionsAPI(s3)
+		_, err = ForceDelete(ctx, s3.site(), buckets, permissions, "bucket1", &ForceDeleteOptions{DryRun: true})
+		require.Error(t, err)
+		require.Empty(t, permissions.controls)
+	})
+
+	t.Run("fallback without versioning and batching", func(t *testing.T) {
+		s3 := newFakeS3(t)
+		s3.noVersions = true
+		keys := make([]string, 2500)
+		for i := range keys {
+			keys[i] = fmt.Sprintf("obj-%04d", i)
+		}
+		s3.createBucket("bucket1", keys...)
+		s3.grant(testCredentials.AccessKeyID, "bucket1", true, true)
+		buckets := &stubBucketAPI{}
+
+		result, err := ForceDelete(ctx, s3.site(), buckets, nil, "bucket1", &ForceDeleteOptions{
+			Credentials: &testCredentials,
+			Concurrency: 2,
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2500, result.Objects)
+		require.Equal(t, 2500, result.Deleted)
+		require.True(t, result.BucketDeleted)
+		require.Equal(t, 0, s3.remaining("bucket1"))
+	})
+
+	t.Run("access denied", func(t *testing.T) {
+		s3 := newFakeS3(t)
+		s3.createBucket("bucket1", "a")
+		s3.grant(testCredentials.AccessKeyID, "bucket1", true, false)
+		buckets := &stubBucketAPI{}
+
+		_, err := ForceDelete(ctx, s3.site(), buckets, nil, "bucket1", &ForceDeleteOptions{Credentials: &testCredentials})
+		require.Error(t, err)
+		require.Empty(t, buckets.deleted, "bucket must not be deleted when objects remain")
+		require.Equal(t, []string{"a"}, s3.objectKeys("bucket1"))
+	})
+}
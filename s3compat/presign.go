@@ -0,0 +1,340 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package s3compat
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+)
+
+const (
+	signV4Algorithm = "AWS4-HMAC-SHA256"
+	iso8601Format   = "20060102T150405Z"
+	yyyymmdd        = "20060102"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+
+	// MaxPresignExpires 署名付きURLの有効期限の上限
+	MaxPresignExpires = 7 * 24 * time.Hour
+)
+
+// Signer オフラインでSigV4署名付きURLやPOSTポリシーを生成する
+type Signer struct {
+	Credentials Credentials
+	Endpoint    *url.URL
+	Region      string
+
+	// VirtualHostedStyle trueの場合はバケットをホスト名に含める(デフォルトはパス形式)
+	VirtualHostedStyle bool
+	// Now 署名時刻の取得に用いる関数。nilの場合はtime.Now
+	Now func() time.Time
+}
+
+// NewSigner サイトの情報からSignerを生成する
+func NewSigner(site *v2.ModelCluster, creds Credentials) (*Signer, error) {
+	if err := creds.validate(); err != nil {
+		return nil, err
+	}
+	endpoint, err := Endpoint(site)
+	if err != nil {
+		return nil, err
+	}
+	region, err := Region(site)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{Credentials: creds, Endpoint: endpoint, Region: region}, nil
+}
+
+func (s *Signer) now() time.Time {
+	if s.Now != nil {
+		return s.Now().UTC()
+	}
+	return time.Now().UTC()
+}
+
+func (s *Signer) objectURL(bucket, key string) (*url.URL, error) {
+	if s.Endpoint == nil {
+		return nil, objectstorage.NewError("signer has no endpoint", nil)
+	}
+	if bucket == "" {
+		return nil, objectstorage.NewError("bucket name is empty", nil)
+	}
+	u := *s.Endpoint
+	if s.VirtualHostedStyle {
+		u.Host = bucket + "." + u.Host
+		u.Path = "/" + key
+	} else {
+		u.Path = "/" + bucket
+		if key != "" {
+			u.Path += "/" + key
+		}
+	}
+	u.RawPath = ""
+	u.RawQuery = ""
+	return &u, nil
+}
+
+func (s *Signer) scope(t time.Time) string {
+	return strings.Join([]string{t.Format(yyyymmdd), s.Region, "s3", "aws4_request"}, "/")
+}
+
+func (s *Signer) credential(t time.Time) string {
+	return s.Credentials.AccessKeyID + "/" + s.scope(t)
+}
+
+func (s *Signer) signature(t time.Time, stringToSign string) string {
+	key := signingKey(s.Credentials.SecretAccessKey, t, s.Region, "s3")
+	return hex.EncodeToString(hmacSHA256(key, []byte(stringToSign)))
+}
+
+// PresignGetObject オブジェクト取得用の署名付きURLを生成する
+func (s *Signer) PresignGetObject(bucket, key string, expires time.Duration) (*url.URL, error) {
+	return s.Presign(http.MethodGet, bucket, key, nil, nil, expires)
+}
+
+// PresignPutObject オブジェクトアップロード用の署名付きURLを生成する
+//
+// headersを指定した場合はそのヘッダも署名対象となり、アップロード時に同じ値を送信する必要がある
+func (s *Signer) PresignPutObject(bucket, key string, headers http.Header, expires time.Duration) (*url.URL, error) {
+	return s.Presign(http.MethodPut, bucket, key, nil, headers, expires)
+}
+
+// Presign 任意のリクエストに対する署名付きURLを生成する
+func (s *Signer) Presign(method, bucket, key string, query url.Values, headers http.Header, expires time.Duration) (*url.URL, error) {
+	if err := s.Credentials.validate(); err != nil {
+		return nil, err
+	}
+	if expires <= 0 || expires > MaxPresignExpires {
+		return nil, objectstorage.NewError("expires must be between 1s and 7 days", nil)
+	}
+	u, err := s.objectURL(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	t := s.now()
+
+	signed := http.Header{}
+	for k, vs := range headers {
+		signed[http.CanonicalHeaderKey(k)] = vs
+	}
+	signed.Set("Host", u.Host)
+	signedHeaders, canonicalHeaders := canonicalizeHeaders(signed)
+
+	q := url.Values{}
+	for k, vs := range query {
+		q[k] = append([]string(nil), vs...)
+	}
+	q.Set("X-Amz-Algorithm", signV4Algorithm)
+	q.Set("X-Amz-Credential", s.credential(t))
+	q.Set("X-Amz-Date", t.Format(iso8601Format))
+	q.Set("X-Amz-Expires", strconv.FormatInt(int64(expires/time.Second), 10))
+	q.Set("X-Amz-SignedHeaders", signedHeaders)
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		uriEncode(u.Path, false),
+		canonicalQuery(q),
+		canonicalHeaders,
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+	stringToSign := strings.Join([]string{
+		signV4Algorithm,
+		t.Format(iso8601Format),
+		s.scope(t),
+		hexSHA256([]byte(canonicalRequest)),
+	}, "\n")
+
+	q.Set("X-Amz-Signature", s.signature(t, stringToSign))
+	u.RawPath = uriEncode(u.Path, false)
+	u.RawQuery = canonicalQuery(q)
+	return u, nil
+}
+
+// PostPolicy ブラウザからのPOSTアップロード用ポリシー
+type PostPolicy struct {
+	Bucket string
+	// Key アップロード先のキー。KeyPrefixと同時には指定できない
+	Key string
+	// KeyPrefix アップロード先のキーのプレフィックス。空文字の場合は任意のキーを許可する
+	KeyPrefix string
+	// ContentLengthMin/ContentLengthMax アップロード可能なサイズの範囲(バイト)。ContentLengthMaxが0の場合は制限しない
+	ContentLengthMin int64
+	ContentLengthMax int64
+	// ContentType 指定した場合はContent-Typeを一致させる必要がある
+	ContentType string
+	// Expires ポリシーの有効期間
+	Expires time.Duration
+	// Conditions 追加の条件。値はそのままポリシーのconditionsに含まれる
+	Conditions []any
+}
+
+// PostForm POSTアップロードに必要なURLとフォームフィールド
+type PostForm struct {
+	URL string
+	// Fields フォームに含めるフィールド。fileフィールドの前に送信する必要がある
+	Fields map[string]string
+	// Expiration ポリシーの有効期限
+	Expiration time.Time
+}
+
+// PresignPostPolicy ブラウザからのPOSTアップロード用のポリシーと署名を生成する
+func (s *Signer) PresignPostPolicy(policy *PostPolicy) (*PostForm, error) {
+	if err := s.Credentials.validate(); err != nil {
+		return nil, err
+	}
+	if policy.Key != "" && policy.KeyPrefix != "" {
+		return nil, objectstorage.NewError("both Key and KeyPrefix are specified", nil)
+	}
+	if policy.Expires <= 0 || policy.Expires > MaxPresignExpires {
+		return nil, objectstorage.NewError("expires must be between 1s and 7 days", nil)
+	}
+	if policy.ContentLengthMin < 0 || (policy.ContentLengthMax > 0 && policy.ContentLengthMin > policy.ContentLengthMax) {
+		return nil, objectstorage.NewError("invalid content length range", nil)
+	}
+	u, err := s.objectURL(policy.Bucket, "")
+	if err != nil {
+		return nil, err
+	}
+	if s.VirtualHostedStyle {
+		u.Path = "/"
+	}
+	t := s.now()
+	expiration := t.Add(policy.Expires)
+
+	fields := map[string]string{
+		"x-amz-algorithm":  signV4Algorithm,
+		"x-amz-credential": s.credential(t),
+		"x-amz-date":       t.Format(iso8601Format),
+	}
+	conditions := []any{map[string]string{"bucket": policy.Bucket}}
+	switch {
+	case policy.Key != "":
+		conditions = append(conditions, map[string]string{"key": policy.Key})
+		fields["key"] = policy.Key
+	default:
+		conditions = append(conditions, []any{"starts-with", "$key", policy.KeyPrefix})
+		fields["key"] = policy.KeyPrefix + "${filename}"
+	}
+	if policy.ContentLengthMax > 0 {
+		conditions = append(conditions, []any{"content-length-range", policy.ContentLengthMin, policy.ContentLengthMax})
+	}
+	if policy.ContentType != "" {
+		conditions = append(conditions, map[string]string{"Content-Type": policy.ContentType})
+		fields["Content-Type"] = policy.ContentType
+	}
+	conditions = append(conditions, policy.Conditions...)
+	conditions = append(conditions,
+		map[string]string{"x-amz-algorithm": fields["x-amz-algorithm"]},
+		map[string]string{"x-amz-credential": fields["x-amz-credential"]},
+		map[string]string{"x-amz-date": fields["x-amz-date"]},
+	)
+
+	doc, err := json.Marshal(map[string]any{
+		"expiration": expiration.Format("2006-01-02T15:04:05.000Z"),
+		"conditions": conditions,
+	})
+	if err != nil {
+		return nil, objectstorage.NewError("failed to encode post policy", err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(doc)
+	fields["policy"] = encoded
+	fields["x-amz-signature"] = s.signature(t, encoded)
+
+	return &PostForm{URL: u.String(), Fields: fields, Expiration: expiration}, nil
+}
+
+func signingKey(secret string, t time.Time, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), []byte(t.Format(yyyymmdd)))
+	key = hmacSHA256(key, []byte(region))
+	key = hmacSHA256(key, []byte(service))
+	return hmacSHA256(key, []byte("aws4_request"))
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func canonicalizeHeaders(headers http.Header) (signed string, canonical string) {
+	keys := make([]string, 0, len(headers))
+	values := map[string]string{}
+	for k, vs := range headers {
+		lk := strings.ToLower(k)
+		trimmed := make([]string, len(vs))
+		for i, v := range vs {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		keys = append(keys, lk)
+		values[lk] = strings.Join(trimmed, ",")
+	}
+	sort.Strings(keys)
+
+	var buf strings.Builder
+	for _, k := range keys {
+		buf.WriteString(k)
+		buf.WriteByte(':')
+		buf.WriteString(values[k])
+		buf.WriteByte('\n')
+	}
+	return strings.Join(keys, ";"), buf.String()
+}
+
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, k := range keys {
+		vs := append([]string(nil), q[k]...)
+		sort.Strings(vs)
+		for _, v := range vs {
+			pairs = append(pairs, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode SigV4の仕様に従ってURIエンコードする
+//
+// encodeSlashがfalseの場合は'/'をエンコードしない(パスのエンコード用)
+func uriEncode(s string, encodeSlash bool) string {
+	const hexChars = "0123456789ABCDEF"
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			buf.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			buf.WriteByte(c)
+		default:
+			buf.WriteByte('%')
+			buf.WriteByte(hexChars[c>>4])
+			buf.WriteByte(hexChars[c&15])
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,291 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package s3compat
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+)
+
+// fakeS3 テスト用の最小限のS3互換サーバ
+type fakeS3 struct {
+	*httptest.Server
+
+	mu sync.Mutex
+	// grants アクセスキーID -> バケット名 -> 権限
+	grants  map[string]map[string]fakeGrant
+	buckets map[string]map[string][]byte
+	// versions バケット名 -> 最新でないバージョンと削除マーカー
+	versions map[string][]fakeVersion
+	// uploads バケット名 -> アップロードID -> キー
+	uploads map[string]map[string]string
+	// noVersions trueの場合はバージョン一覧をNotImplementedとする
+	noVersions bool
+}
+
+type fakeVersion struct {
+	key, id      string
+	deleteMarker bool
+}
+
+type fakeGrant struct {
+	read, write bool
+}
+
+func newFakeS3(t *testing.T) *fakeS3 {
+	t.Helper()
+	f := &fakeS3{
+		grants:   map[string]map[string]fakeGrant{},
+		buckets:  map[string]map[string][]byte{},
+		versions: map[string][]fakeVersion{},
+		uploads:  map[string]map[string]string{},
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeS3) site() *v2.ModelCluster {
+	return &v2.ModelCluster{ID: v2.NewOptString("isk01"), Region: v2.NewOptString("jp-north-1"), S3Endpoint: v2.NewOptString(f.URL)}
+}
+
+func (f *fakeS3) grant(accessKey, bucket string, read, write bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.grants[accessKey] == nil {
+		f.grants[accessKey] = map[string]fakeGrant{}
+	}
+	f.grants[accessKey][bucket] = fakeGrant{read: read, write: write}
+}
+
+func (f *fakeS3) createBucket(name string, keys ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	objects := map[string][]byte{}
+	for _, k := range keys {
+		objects[k] = []byte(k)
+	}
+	f.buckets[name] = objects
+}
+
+func (f *fakeS3) addVersion(bucket, key, id string, deleteMarker bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.versions[bucket] = append(f.versions[bucket], fakeVersion{key: key, id: id, deleteMarker: deleteMarker})
+}
+
+func (f *fakeS3) addUpload(bucket, key, uploadId string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.uploads[bucket] == nil {
+		f.uploads[bucket] = map[string]string{}
+	}
+	f.uploads[bucket][uploadId] = key
+}
+
+// remaining バケットに残っているオブジェクト、バージョン、アップロードの合計数
+func (f *fakeS3) remaining(bucket string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.buckets[bucket]) + len(f.versions[bucket]) + len(f.uploads[bucket])
+}
+
+func (f *fakeS3) objectKeys(bucket string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var keys []string
+	for k := range f.buckets[bucket] {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var credentialPattern = regexp.MustCompile(`Credential=([^/]+)/`)
+
+func (f *fakeS3) accessKey(r *http.Request) string {
+	if c := r.URL.Query().Get("X-Amz-Credential"); c != "" {
+		ak, _, _ := strings.Cut(c, "/")
+		return ak
+	}
+	if m := credentialPattern.FindStringSubmatch(r.Header.Get("Authorization")); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+func writeS3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	xml.NewEncoder(w).Encode(struct { //nolint:errcheck,gosec
+		XMLName xml.Name `xml:"Error"`
+		Code    string   `xml:"Code"`
+		Message string   `xml:"Message"`
+	}{Code: code, Message: code})
+}
+
+func writeXML(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write([]byte(xml.Header)) //nolint:errcheck,gosec
+	xml.NewEncoder(w).Encode(v) //nolint:errcheck,gosec
+}
+
+func (f *fakeS3) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	grants, ok := f.grants[f.accessKey(r)]
+	if !ok {
+		writeS3Error(w, http.StatusForbidden, "InvalidAccessKeyId")
+		return
+	}
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	objects, ok := f.buckets[bucket]
+	if !ok {
+		writeS3Error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	grant := grants[bucket]
+	needWrite := r.Method != http.MethodGet && r.Method != http.MethodHead
+	if (needWrite && !grant.write) || (!needWrite && !grant.read) {
+		writeS3Error(w, http.StatusForbidden, "AccessDenied")
+		return
+	}
+
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodGet && key == "" && query.Has("versions"):
+		if f.noVersions {
+			writeS3Error(w, http.StatusNotImplemented, "NotImplemented")
+			return
+		}
+		f.listVersions(w, bucket, objects)
+	case r.Method == http.MethodGet && key == "" && query.Has("uploads"):
+		f.listUploads(w, bucket)
+	case r.Method == http.MethodPost && key == "" && query.Has("delete"):
+		f.deleteObjects(w, r, bucket, objects)
+	case r.Method == http.MethodDelete && key != "" && query.Has("uploadId"):
+		if _, ok := f.uploads[bucket][query.Get("uploadId")]; !ok {
+			writeS3Error(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		delete(f.uploads[bucket], query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && key == "":
+		type content struct {
+			Key  string `xml:"Key"`
+			Size int    `xml:"Size"`
+		}
+		res := struct {
+			XMLName     xml.Name  `xml:"ListBucketResult"`
+			Name        string    `xml:"Name"`
+			KeyCount    int       `xml:"KeyCount"`
+			IsTruncated bool      `xml:"IsTruncated"`
+			Contents    []content `xml:"Contents"`
+		}{Name: bucket}
+		if r.URL.Query().Get("max-keys") != "0" {
+			for k, v := range objects {
+				res.Contents = append(res.Contents, content{Key: k, Size: len(v)})
+			}
+			sort.Slice(res.Contents, func(i, j int) bool { return res.Contents[i].Key < res.Contents[j].Key })
+		}
+		res.KeyCount = len(res.Contents)
+		writeXML(w, res)
+	case r.Method == http.MethodPut && key != "":
+		objects[key] = nil
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodDelete && key != "":
+		delete(objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeS3Error(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+var fakeLastModified = "2026-01-01T00:00:00.000Z"
+
+func (f *fakeS3) listVersions(w http.ResponseWriter, bucket string, objects map[string][]byte) {
+	type version struct {
+		XMLName      xml.Name
+		Key          string `xml:"Key"`
+		VersionID    string `xml:"VersionId"`
+		IsLatest     bool   `xml:"IsLatest"`
+		LastModified string `xml:"LastModified"`
+	}
+	res := struct {
+		XMLName     xml.Name  `xml:"ListVersionsResult"`
+		Name        string    `xml:"Name"`
+		IsTruncated bool      `xml:"IsTruncated"`
+		Versions    []version `xml:",any"`
+	}{Name: bucket}
+	for k := range objects {
+		res.Versions = append(res.Versions, version{XMLName: xml.Name{Local: "Version"}, Key: k, VersionID: "null", IsLatest: true, LastModified: fakeLastModified})
+	}
+	for _, v := range f.versions[bucket] {
+		name := "Version"
+		if v.deleteMarker {
+			name = "DeleteMarker"
+		}
+		res.Versions = append(res.Versions, version{XMLName: xml.Name{Local: name}, Key: v.key, VersionID: v.id, LastModified: fakeLastModified})
+	}
+	sort.SliceStable(res.Versions, func(i, j int) bool { return res.Versions[i].Key < res.Versions[j].Key })
+	writeXML(w, res)
+}
+
+func (f *fakeS3) listUploads(w http.ResponseWriter, bucket string) {
+	type upload struct {
+		Key       string `xml:"Key"`
+		UploadID  string `xml:"UploadId"`
+		Initiated string `xml:"Initiated"`
+	}
+	res := struct {
+		XMLName     xml.Name `xml:"ListMultipartUploadsResult"`
+		Bucket      string   `xml:"Bucket"`
+		IsTruncated bool     `xml:"IsTruncated"`
+		Uploads     []upload `xml:"Upload"`
+	}{Bucket: bucket}
+	for id, key := range f.uploads[bucket] {
+		res.Uploads = append(res.Uploads, upload{Key: key, UploadID: id, Initiated: fakeLastModified})
+	}
+	sort.Slice(res.Uploads, func(i, j int) bool { return res.Uploads[i].UploadID < res.Uploads[j].UploadID })
+	writeXML(w, res)
+}
+
+func (f *fakeS3) deleteObjects(w http.ResponseWriter, r *http.Request, bucket string, objects map[string][]byte) {
+	type object struct {
+		Key       string `xml:"Key"`
+		VersionID string `xml:"VersionId,omitempty"`
+	}
+	var req struct {
+		Objects []object `xml:"Object"`
+	}
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeS3Error(w, http.StatusBadRequest, "MalformedXML")
+		return
+	}
+	res := struct {
+		XMLName xml.Name `xml:"DeleteResult"`
+		Deleted []object `xml:"Deleted"`
+	}{}
+	for _, o := range req.Objects {
+		if o.VersionID == "" || o.VersionID == "null" {
+			delete(objects, o.Key)
+		} else {
+			f.versions[bucket] = slices.DeleteFunc(f.versions[bucket], func(v fakeVersion) bool {
+				return v.key == o.Key && v.id == o.VersionID
+			})
+		}
+		res.Deleted = append(res.Deleted, o)
+	}
+	writeXML(w, res)
+}
@@ -0,0 +1,173 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package s3compat
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+)
+
+// DefaultProbeKeyPrefix 書き込み確認に用いるオブジェクトのキーのプレフィックス
+const DefaultProbeKeyPrefix = ".object-storage-api-go-probe-"
+
+// VerifyOptions Verifyのオプション
+type VerifyOptions struct {
+	// ProbeWrite trueの場合、書き込み可能なはずのバケットにプローブ用オブジェクトをPUT/DELETEして確認する
+	ProbeWrite bool
+	// ProbeKey プローブ用オブジェクトのキー。空の場合はDefaultProbeKeyPrefixにランダムな文字列を付与したもの
+	ProbeKey string
+	// HTTPClient S3互換APIへのリクエストに用いるクライアント。nilの場合はhttp.DefaultClient
+	HTTPClient *http.Client
+	// Now 署名時刻の取得に用いる関数。nilの場合はtime.Now
+	Now func() time.Time
+}
+
+// BucketVerification バケットごとの確認結果
+type BucketVerification struct {
+	Bucket string
+	// ExpectRead/ExpectWrite パーミッションで設定されている権限
+	ExpectRead  bool
+	ExpectWrite bool
+	// CanRead 実際に読み込み(一覧取得)できたか
+	CanRead bool
+	// CanWrite 実際に書き込みできたか。WriteProbedがfalseの場合は不明
+	CanWrite    bool
+	WriteProbed bool
+	// Err アクセス拒否以外の理由で確認できなかった場合のエラー
+	Err error
+}
+
+// Matches 観測した権限がパーミッションの設定と一致しているか
+func (v *BucketVerification) Matches() bool {
+	if v.Err != nil || v.CanRead != v.ExpectRead {
+		return false
+	}
+	return !v.WriteProbed || v.CanWrite == v.ExpectWrite
+}
+
+// VerifyResult Verifyの結果
+type VerifyResult struct {
+	Buckets []*BucketVerification
+}
+
+// OK 全てのバケットで権限が一致しているか
+func (r *VerifyResult) OK() bool {
+	for _, b := range r.Buckets {
+		if !b.Matches() {
+			return false
+		}
+	}
+	return true
+}
+
+// Verify アクセスキーがパーミッションの設定通りにバケットへアクセスできるかを確認する
+//
+// バケットごとにmax-keys=0での一覧取得を行い、ProbeWriteが指定されている場合は
+// 書き込み可能なはずのバケットに対してプローブ用オブジェクトのPUT/DELETEを行う。
+// キーのローテーション時に新しいキーが有効であることを確認してから古いキーを削除するといった用途を想定している
+func Verify(ctx context.Context, site *v2.ModelCluster, creds Credentials, controls v2.BucketControls, opts *VerifyOptions) (*VerifyResult, error) {
+	if opts == nil {
+		opts = &VerifyOptions{}
+	}
+	signer, err := NewSigner(site, creds)
+	if err != nil {
+		return nil, err
+	}
+	signer.Now = opts.Now
+	client := opts.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	probeKey := opts.ProbeKey
+	if probeKey == "" {
+		var buf [8]byte
+		if _, err := rand.Read(buf[:]); err != nil {
+			return nil, objectstorage.NewError("failed to generate probe key", err)
+		}
+		probeKey = DefaultProbeKeyPrefix + hex.EncodeToString(buf[:])
+	}
+
+	result := &VerifyResult{}
+	for _, control := range controls {
+		v := &BucketVerification{
+			Bucket:      string(control.BucketName.Value),
+			ExpectRead:  bool(control.CanRead.Value),
+			ExpectWrite: bool(control.CanWrite.Value),
+		}
+		result.Buckets = append(result.Buckets, v)
+
+		v.CanRead, v.Err = probe(ctx, client, signer, http.MethodGet, v.Bucket, "", url.Values{"list-type": {"2"}, "max-keys": {"0"}})
+		if v.Err != nil || !opts.ProbeWrite || !v.ExpectWrite {
+			continue
+		}
+
+		v.WriteProbed = true
+		v.CanWrite, v.Err = probe(ctx, client, signer, http.MethodPut, v.Bucket, probeKey, nil)
+		if v.Err == nil && v.CanWrite {
+			if _, err := probe(ctx, client, signer, http.MethodDelete, v.Bucket, probeKey, nil); err != nil {
+				v.Err = fmt.Errorf("failed to delete probe object %q: %w", probeKey, err)
+			}
+		}
+	}
+	return result, nil
+}
+
+// S3Error S3互換APIから返されたエラー
+type S3Error struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+	RequestID  string `xml:"RequestId"`
+}
+
+func (e *S3Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("s3: status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("s3: status %d: %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// probe 署名付きURLでリクエストを行い、成功したか(アクセスが拒否された場合はfalse)を返す
+func probe(ctx context.Context, client *http.Client, signer *Signer, method, bucket, key string, query url.Values) (bool, error) {
+	u, err := signer.Presign(method, bucket, key, query, nil, 5*time.Minute)
+	if err != nil {
+		return false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return false, err
+	}
+	if method == http.MethodPut {
+		req.ContentLength = 0
+		req.Body = http.NoBody
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close() //nolint:errcheck
+
+	if res.StatusCode/100 == 2 {
+		io.Copy(io.Discard, res.Body) //nolint:errcheck,gosec
+		return true, nil
+	}
+	s3err := &S3Error{StatusCode: res.StatusCode}
+	if body, err := io.ReadAll(io.LimitReader(res.Body, 1<<16)); err == nil {
+		xml.Unmarshal(body, s3err) //nolint:errcheck,gosec
+	}
+	if res.StatusCode == http.StatusForbidden && (s3err.Code == "" || s3err.Code == "AccessDenied") {
+		return false, nil
+	}
+	return false, s3err
+}
@@ -0,0 +1,84 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package s3compat
+
+import (
+	"context"
+	"testing"
+
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func bucketControl(name string, read, write bool) v2.BucketControlsItem {
+	return v2.BucketControlsItem{
+		BucketName: v2.NewOptBucketName(v2.BucketName(name)),
+		CanRead:    v2.NewOptCanRead(v2.CanRead(read)),
+		CanWrite:   v2.NewOptCanWrite(v2.CanWrite(write)),
+	}
+}
+
+func TestVerify(t *testing.T) {
+	s3 := newFakeS3(t)
+	s3.createBucket("rw", "existing")
+	s3.createBucket("ro")
+	s3.createBucket("drift")
+	s3.grant(testCredentials.AccessKeyID, "rw", true, true)
+	s3.grant(testCredentials.AccessKeyID, "ro", true, false)
+	// パーミッションでは読み書き可能だが実際には読み込みのみ
+	s3.grant(testCredentials.AccessKeyID, "drift", true, false)
+
+	ctx := context.Background()
+	controls := v2.BucketControls{
+		bucketControl("rw", true, true),
+		bucketControl("ro", true, false),
+		bucketControl("drift", true, true),
+	}
+
+	t.Run("read only probe", func(t *testing.T) {
+		result, err := Verify(ctx, s3.site(), testCredentials, controls, nil)
+		require.NoError(t, err)
+		require.True(t, result.OK())
+		for _, b := range result.Buckets {
+			require.True(t, b.CanRead)
+			require.False(t, b.WriteProbed)
+		}
+	})
+
+	t.Run("write probe", func(t *testing.T) {
+		result, err := Verify(ctx, s3.site(), testCredentials, controls, &VerifyOptions{ProbeWrite: true, ProbeKey: "probe"})
+		require.NoError(t, err)
+		require.False(t, result.OK())
+
+		require.Len(t, result.Buckets, 3)
+		rw, ro, drift := result.Buckets[0], result.Buckets[1], result.Buckets[2]
+		require.True(t, rw.Matches())
+		require.True(t, rw.WriteProbed)
+		require.True(t, rw.CanWrite)
+		require.True(t, ro.Matches())
+		require.False(t, ro.WriteProbed, "buckets without write permission must not be written")
+		require.False(t, drift.Matches())
+		require.True(t, drift.WriteProbed)
+		require.False(t, drift.CanWrite)
+		require.NoError(t, drift.Err)
+
+		// プローブ用オブジェクトは削除されている
+		require.Equal(t, []string{"existing"}, s3.objectKeys("rw"))
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		result, err := Verify(ctx, s3.site(), Credentials{AccessKeyID: "unknown", SecretAccessKey: "secret"}, controls[:1], nil)
+		require.NoError(t, err)
+		require.False(t, result.OK())
+		var s3err *S3Error
+		require.ErrorAs(t, result.Buckets[0].Err, &s3err)
+		require.Equal(t, "InvalidAccessKeyId", s3err.Code)
+	})
+
+	t.Run("missing bucket", func(t *testing.T) {
+		result, err := Verify(ctx, s3.site(), testCredentials, v2.BucketControls{bucketControl("missing", true, false)}, nil)
+		require.NoError(t, err)
+		require.Error(t, result.Buckets[0].Err)
+	})
+}
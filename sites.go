@@ -67,6 +67,9 @@ func (op *siteOp) Read(ctx context.Context, id string) (*v2.ModelCluster, error)
 }
 
 func (op *siteOp) ListPlans(ctx context.Context) ([]v2.PlanItem, error) {
+	if op.siteClient == nil {
+		return nil, NewError("Site.ListPlans", errors.New("site client is not specified"))
+	}
 	res, err := op.siteClient.client.GetPlans(ctx)
 	if err != nil {
 		return nil, NewAPIError("Site.ListPlans", 0, err)
@@ -0,0 +1,342 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstorage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+)
+
+// DefaultMaxStaleness StaleCacheで保存するメソッドと、保存した結果を返す期間のデフォルト値
+var DefaultMaxStaleness = map[string]time.Duration{
+	"Site.List":             7 * 24 * time.Hour,
+	"Site.Read":             7 * 24 * time.Hour,
+	"Site.ListPlans":        7 * 24 * time.Hour,
+	"Buckets.List":          24 * time.Hour,
+	"Permissions.List":      24 * time.Hour,
+	"Permissions.Read":      24 * time.Hour,
+	"BucketExtra.ReadUsage": time.Hour,
+	"BucketExtra.ReadQuota": time.Hour,
+	"SiteStatus.Read":       time.Hour,
+	"SiteStatus.ReadQuota":  time.Hour,
+}
+
+// StaleCacheOption NewStaleCacheのオプション
+type StaleCacheOption func(*StaleCache)
+
+// WithMaxStaleness メソッドの保存した結果を返す期間を指定する。0以下の場合はそのメソッドを保存しない
+//
+// 指定できるのはDefaultMaxStalenessに含まれるメソッドのみで、それ以外は無視される
+func WithMaxStaleness(method string, d time.Duration) StaleCacheOption {
+	return func(c *StaleCache) {
+		if _, ok := DefaultMaxStaleness[method]; ok {
+			c.maxStaleness[method] = d
+		}
+	}
+}
+
+// WithStaleClock 保存日時や期間の判定に用いる現在時刻を返す関数を指定する。デフォルトはtime.Now
+func WithStaleClock(now func() time.Time) StaleCacheOption {
+	return func(c *StaleCache) { c.now = now }
+}
+
+// WithStaleHandler 保存した結果を返した際に呼ばれる関数を指定する。ログやメトリクスの記録に用いる
+func WithStaleHandler(handler func(ctx context.Context, method string, since time.Time, err error)) StaleCacheOption {
+	return func(c *StaleCache) { c.handler = handler }
+}
+
+// StaleCache 参照系のメソッドの最後に成功した結果をディレクトリに保存し、APIの障害時に返す
+//
+// 呼び出しがネットワークのエラーまたは5xxで失敗した場合、保存した結果が期間内であればエラーの代わりにそれを返す。
+// 保存した結果を返したかどうかはTrackStaleで作成したStaleReportで確認できる。
+// 結果はキーごとのファイルに一時ファイルからのリネームで書き込むため、複数のプロセスで同じディレクトリを共有できる。
+// 保存の失敗は呼び出し元には返さない
+type StaleCache struct {
+	dir          string
+	maxStaleness map[string]time.Duration
+	now          func() time.Time
+	handler      func(ctx context.Context, method string, since time.Time, err error)
+}
+
+// NewStaleCache dirに結果を保存するStaleCacheを作成する。dirが存在しない場合は作成する
+func NewStaleCache(dir string, opts ...StaleCacheOption) (*StaleCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, NewError("failed to create stale cache directory", err)
+	}
+	c := &StaleCache{
+		dir:          dir,
+		maxStaleness: maps.Clone(DefaultMaxStaleness),
+		now:          time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+type staleEntry struct {
+	Method   string          `json:"method"`
+	SiteID   string          `json:"site_id,omitempty"`
+	Arg      string          `json:"arg,omitempty"`
+	StoredAt time.Time       `json:"stored_at"`
+	Value    json.RawMessage `json:"value"`
+}
+
+func (c *StaleCache) path(key cacheKey) string {
+	sum := sha256.Sum256([]byte(key.method + "\x00" + key.siteId + "\x00" + key.arg))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *StaleCache) store(key cacheKey, value any) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	data, err := json.Marshal(staleEntry{Method: key.method, SiteID: key.siteId, Arg: key.arg, StoredAt: c.now(), Value: raw})
+	if err != nil {
+		return
+	}
+	f, err := os.CreateTemp(c.dir, ".stale-*")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), c.path(key))
+	}
+	if err != nil {
+		os.Remove(f.Name()) //nolint:errcheck,gosec
+	}
+}
+
+func (c *StaleCache) load(key cacheKey, value any) (time.Time, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var entry staleEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return time.Time{}, false
+	}
+	if entry.Method != key.method || entry.SiteID != key.siteId || entry.Arg != key.arg {
+		return time.Time{}, false
+	}
+	if c.now().Sub(entry.StoredAt) > c.maxStaleness[key.method] {
+		return time.Time{}, false
+	}
+	if err := json.Unmarshal(entry.Value, value); err != nil {
+		return time.Time{}, false
+	}
+	return entry.StoredAt, true
+}
+
+// staleCall liveを呼び出し、成功した場合は結果を保存する。障害で失敗した場合は保存した結果をresに読み込む
+func staleCall[T any](ctx context.Context, c *StaleCache, key cacheKey, live func(ctx context.Context) (T, error)) (T, error) {
+	res, err := live(ctx)
+	if c.maxStaleness[key.method] <= 0 {
+		return res, err
+	}
+	if err == nil {
+		c.store(key, res)
+		return res, nil
+	}
+	if !IsUnavailable(err) {
+		return res, err
+	}
+	var stale T
+	since, ok := c.load(key, &stale)
+	if !ok {
+		return res, err
+	}
+	markStale(ctx, since, err)
+	if c.handler != nil {
+		c.handler(ctx, key.method, since, err)
+	}
+	return stale, nil
+}
+
+// IsUnavailable errがネットワークのエラーまたは5xxのレスポンスによるものかを返す
+//
+// 呼び出し元によるコンテキストのキャンセルは含まない
+func IsUnavailable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if code := StatusCode(err); code >= 500 && code <= 599 {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET)
+}
+
+type staleReportKey struct{}
+
+// StaleReport 保存した結果を返したかどうかの記録
+type StaleReport struct {
+	mu    sync.Mutex
+	since time.Time
+	err   error
+}
+
+// TrackStale ctxを用いた呼び出しで保存した結果を返したかを記録するStaleReportを作成する
+func TrackStale(ctx context.Context) (context.Context, *StaleReport) {
+	report := &StaleReport{}
+	return context.WithValue(ctx, staleReportKey{}, report), report
+}
+
+// Stale 保存した結果を返した場合にtrueを返す
+func (r *StaleReport) Stale() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err != nil
+}
+
+// Since 返した結果のうち最も古いものを保存した日時を返す。保存した結果を返していない場合はゼロ値
+func (r *StaleReport) Since() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.since
+}
+
+// Err 保存した結果を返す原因となったエラーを返す。保存した結果を返していない場合はnil
+func (r *StaleReport) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *StaleReport) mark(since time.Time, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.since.IsZero() || since.Before(r.since) {
+		r.since = since
+	}
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func markStale(ctx context.Context, since time.Time, err error) {
+	if report, ok := ctx.Value(staleReportKey{}).(*StaleReport); ok {
+		report.mark(since, err)
+	}
+}
+
+// SiteAPI SiteAPIの参照系のメソッドの結果を保存する。siteIdはListPlansのキーに用いる
+func (c *StaleCache) SiteAPI(api SiteAPI, siteId string) SiteAPI {
+	return &staleSiteAPI{SiteAPI: api, cache: c, siteId: siteId}
+}
+
+type staleSiteAPI struct {
+	SiteAPI
+	cache  *StaleCache
+	siteId string
+}
+
+func (a *staleSiteAPI) List(ctx context.Context) ([]v2.ModelCluster, error) {
+	return staleCall(ctx, a.cache, cacheKey{cacheScope: cacheScope{method: "Site.List"}}, a.SiteAPI.List)
+}
+
+func (a *staleSiteAPI) Read(ctx context.Context, siteId string) (*v2.ModelCluster, error) {
+	return staleCall(ctx, a.cache, cacheKey{cacheScope: cacheScope{method: "Site.Read"}, arg: siteId}, func(ctx context.Context) (*v2.ModelCluster, error) {
+		return a.SiteAPI.Read(ctx, siteId)
+	})
+}
+
+func (a *staleSiteAPI) ListPlans(ctx context.Context) ([]v2.PlanItem, error) {
+	return staleCall(ctx, a.cache, cacheKey{cacheScope: cacheScope{method: "Site.ListPlans", siteId: a.siteId}}, a.SiteAPI.ListPlans)
+}
+
+// BucketAPI BucketAPIの参照系のメソッドの結果を保存する
+func (c *StaleCache) BucketAPI(api BucketAPI, siteId string) BucketAPI {
+	return &staleBucketAPI{BucketAPI: api, cache: c, siteId: siteId}
+}
+
+type staleBucketAPI struct {
+	BucketAPI
+	cache  *StaleCache
+	siteId string
+}
+
+func (a *staleBucketAPI) List(ctx context.Context) ([]v2.BucketListDataItem, error) {
+	return staleCall(ctx, a.cache, cacheKey{cacheScope: cacheScope{method: "Buckets.List", siteId: a.siteId}}, a.BucketAPI.List)
+}
+
+// BucketExtraAPI BucketExtraAPIの使用量と制限値の結果を保存する
+func (c *StaleCache) BucketExtraAPI(api BucketExtraAPI, siteId, bucket string) BucketExtraAPI {
+	return &staleBucketExtraAPI{BucketExtraAPI: api, cache: c, siteId: siteId, bucket: bucket}
+}
+
+type staleBucketExtraAPI struct {
+	BucketExtraAPI
+	cache  *StaleCache
+	siteId string
+	bucket string
+}
+
+func (a *staleBucketExtraAPI) ReadUsage(ctx context.Context) (*v2.BucketUsageData, error) {
+	return staleCall(ctx, a.cache, cacheKey{cacheScope: cacheScope{method: "BucketExtra.ReadUsage", siteId: a.siteId}, arg: a.bucket}, a.BucketExtraAPI.ReadUsage)
+}
+
+func (a *staleBucketExtraAPI) ReadQuota(ctx context.Context) (*v2.BucketQuotaData, error) {
+	return staleCall(ctx, a.cache, cacheKey{cacheScope: cacheScope{method: "BucketExtra.ReadQuota", siteId: a.siteId}, arg: a.bucket}, a.BucketExtraAPI.ReadQuota)
+}
+
+// PermissionsAPI PermissionsAPIのパーミッションの参照の結果を保存する。アクセスキーは保存しない
+func (c *StaleCache) PermissionsAPI(api PermissionsAPI, siteId string) PermissionsAPI {
+	return &stalePermissionsAPI{PermissionsAPI: api, cache: c, siteId: siteId}
+}
+
+type stalePermissionsAPI struct {
+	PermissionsAPI
+	cache  *StaleCache
+	siteId string
+}
+
+func (a *stalePermissionsAPI) List(ctx context.Context) ([]v2.PermissionsDataItem, error) {
+	return staleCall(ctx, a.cache, cacheKey{cacheScope: cacheScope{method: "Permissions.List", siteId: a.siteId}}, a.PermissionsAPI.List)
+}
+
+func (a *stalePermissionsAPI) Read(ctx context.Context, permissionId string) (*v2.PermissionData, error) {
+	return staleCall(ctx, a.cache, cacheKey{cacheScope: cacheScope{method: "Permissions.Read", siteId: a.siteId}, arg: permissionId}, func(ctx context.Context) (*v2.PermissionData, error) {
+		return a.PermissionsAPI.Read(ctx, permissionId)
+	})
+}
+
+// SiteStatusAPI SiteStatusAPIのステータスと制限値の結果を保存する
+func (c *StaleCache) SiteStatusAPI(api SiteStatusAPI, siteId string) SiteStatusAPI {
+	return &staleSiteStatusAPI{SiteStatusAPI: api, cache: c, siteId: siteId}
+}
+
+type staleSiteStatusAPI struct {
+	SiteStatusAPI
+	cache  *StaleCache
+	siteId string
+}
+
+func (a *staleSiteStatusAPI) Read(ctx context.Context) (*v2.StatusData, error) {
+	return staleCall(ctx, a.cache, cacheKey{cacheScope: cacheScope{method: "SiteStatus.Read", siteId: a.siteId}}, a.SiteStatusAPI.Read)
+}
+
+func (a *staleSiteStatusAPI) ReadQuota(ctx context.Context) (*v2.QuotaData, error) {
+	return staleCall(ctx, a.cache, cacheKey{cacheScope: cacheScope{method: "SiteStatus.ReadQuota", siteId: a.siteId}}, a.SiteStatusAPI.ReadQuota)
+}
@@ -0,0 +1,146 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstorage_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/sacloud/object-storage-api-go/objectstoragetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaleCache(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := objectstoragetest.NewFake()
+
+	var handled []string
+	newCache := func(t *testing.T) *objectstorage.StaleCache {
+		cache, err := objectstorage.NewStaleCache(dir,
+			objectstorage.WithStaleClock(func() time.Time { return now }),
+			objectstorage.WithMaxStaleness("Permissions.List", 0),
+			objectstorage.WithStaleHandler(func(ctx context.Context, method string, since time.Time, err error) {
+				handled = append(handled, method)
+			}),
+		)
+		require.NoError(t, err)
+		return cache
+	}
+
+	api, err := fake.Buckets("isk01")
+	require.NoError(t, err)
+	_, err = api.Create(ctx, &objectstorage.BucketCreateParams{Bucket: "bucket1"})
+	require.NoError(t, err)
+	buckets := newCache(t).BucketAPI(api, "isk01")
+
+	storedAt := now
+	res, err := buckets.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+
+	t.Run("serves the last good response on outage", func(t *testing.T) {
+		now = storedAt.Add(time.Hour)
+		unavailable := objectstoragetest.APIError("Buckets.List", http.StatusServiceUnavailable)
+		fake.FailOn("Buckets.List", unavailable)
+		defer fake.ClearHooks()
+
+		// 別のプロセスを想定し、同じディレクトリを用いる別のStaleCacheから読み込む
+		buckets := newCache(t).BucketAPI(api, "isk01")
+		ctx, report := objectstorage.TrackStale(ctx)
+		res, err := buckets.List(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []v2.BucketListDataItem{{Name: "bucket1"}}, trimBuckets(res))
+		require.True(t, report.Stale())
+		require.True(t, storedAt.Equal(report.Since()))
+		require.ErrorIs(t, report.Err(), unavailable)
+		require.Equal(t, []string{"Buckets.List"}, handled)
+	})
+
+	t.Run("network errors", func(t *testing.T) {
+		fake.FailOn("Buckets.List", objectstorage.NewAPIError("Buckets.List", 0, &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}))
+		defer fake.ClearHooks()
+
+		ctx, report := objectstorage.TrackStale(ctx)
+		_, err := buckets.List(ctx)
+		require.NoError(t, err)
+		require.True(t, report.Stale())
+	})
+
+	t.Run("other errors are returned", func(t *testing.T) {
+		fake.FailOn("Buckets.List", objectstoragetest.APIError("Buckets.List", http.StatusUnauthorized))
+		defer fake.ClearHooks()
+
+		ctx, report := objectstorage.TrackStale(ctx)
+		_, err := buckets.List(ctx)
+		require.Error(t, err)
+		require.False(t, report.Stale())
+	})
+
+	t.Run("max staleness", func(t *testing.T) {
+		now = storedAt.Add(objectstorage.DefaultMaxStaleness["Buckets.List"] + time.Second)
+		fake.FailOn("Buckets.List", objectstoragetest.APIError("Buckets.List", http.StatusInternalServerError))
+		defer fake.ClearHooks()
+
+		_, err := buckets.List(ctx)
+		require.Error(t, err)
+
+		permissionsAPI, err := fake.Permissions("isk01")
+		require.NoError(t, err)
+		permissions := newCache(t).PermissionsAPI(permissionsAPI, "isk01")
+		_, err = permissions.List(ctx)
+		require.NoError(t, err)
+		fake.FailOn("Permissions.List", objectstoragetest.APIError("Permissions.List", http.StatusInternalServerError))
+		_, err = permissions.List(ctx)
+		require.Error(t, err, "methods with zero max staleness must not be stored")
+	})
+
+	t.Run("not cached by Cache", func(t *testing.T) {
+		now = storedAt
+		fake.ClearHooks()
+		sitesAPI, err := fake.Sites("isk01")
+		require.NoError(t, err)
+		sites := objectstorage.NewCache().SiteAPI(newCache(t).SiteAPI(sitesAPI, "isk01"), "isk01")
+		_, err = sites.List(ctx)
+		require.NoError(t, err)
+
+		now = storedAt.Add(time.Hour)
+		fake.FailOn("Site.List", objectstoragetest.APIError("Site.List", http.StatusBadGateway))
+		cache := objectstorage.NewCache()
+		sites = cache.SiteAPI(newCache(t).SiteAPI(sitesAPI, "isk01"), "isk01")
+		for range 2 {
+			ctx, report := objectstorage.TrackStale(ctx)
+			res, err := sites.List(ctx)
+			require.NoError(t, err)
+			require.Len(t, res, 2)
+			require.True(t, report.Stale())
+		}
+		require.Equal(t, uint64(2), cache.Stats()["Site.List"].Loads)
+	})
+}
+
+// trimBuckets 比較のためにバケット名以外を取り除く
+func trimBuckets(buckets []v2.BucketListDataItem) []v2.BucketListDataItem {
+	var res []v2.BucketListDataItem
+	for _, b := range buckets {
+		res = append(res, v2.BucketListDataItem{Name: b.Name})
+	}
+	return res
+}
+
+func TestIsUnavailable(t *testing.T) {
+	require.True(t, objectstorage.IsUnavailable(objectstorage.NewAPIError("Site.List", 503, nil)))
+	require.True(t, objectstorage.IsUnavailable(objectstorage.NewAPIError("Site.List", 0, context.DeadlineExceeded)))
+	require.False(t, objectstorage.IsUnavailable(objectstorage.NewAPIError("Site.List", 0, context.Canceled)))
+	require.False(t, objectstorage.IsUnavailable(objectstorage.NewAPIError("Site.List", 404, nil)))
+	require.False(t, objectstorage.IsUnavailable(nil))
+}
@@ -0,0 +1,219 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstorage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sacloud/saclient-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/sacloud/object-storage-api-go"
+
+// スパン/メトリクスに付与する属性のキー
+const (
+	AttrSiteID     = attribute.Key("objectstorage.site_id")
+	AttrMethod     = attribute.Key("objectstorage.method")
+	AttrOperation  = attribute.Key("objectstorage.operation")
+	AttrBucket     = attribute.Key("objectstorage.bucket")
+	AttrAPITraceID = attribute.Key("objectstorage.api.trace_id")
+	AttrHTTPMethod = attribute.Key("http.request.method")
+	AttrHTTPStatus = attribute.Key("http.response.status_code")
+	AttrErrorType  = attribute.Key("error.type")
+)
+
+const (
+	errorTypeOther  = "_OTHER"
+	maxErrorBodyLen = 1 << 16
+)
+
+// TelemetryOption NewTelemetryのオプション
+type TelemetryOption func(*telemetryConfig)
+
+type telemetryConfig struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+// WithTracerProvider スパンの作成に用いるTracerProviderを指定する。デフォルトはotel.GetTracerProvider()
+func WithTracerProvider(tp trace.TracerProvider) TelemetryOption {
+	return func(c *telemetryConfig) { c.tracerProvider = tp }
+}
+
+// WithMeterProvider メトリクスの記録に用いるMeterProviderを指定する。デフォルトはotel.GetMeterProvider()
+func WithMeterProvider(mp metric.MeterProvider) TelemetryOption {
+	return func(c *telemetryConfig) { c.meterProvider = mp }
+}
+
+// Telemetry OpenTelemetryによるトレース/メトリクスの計装
+//
+// Interceptor()をラッパーに、Middleware()をsaclientに適用することで、
+// ラッパーの呼び出しごとのスパンとその子としてHTTPリクエストごとのスパンが記録される
+type Telemetry struct {
+	tracer trace.Tracer
+
+	callDuration    metric.Float64Histogram
+	callErrors      metric.Int64Counter
+	requestDuration metric.Float64Histogram
+}
+
+// NewTelemetry Telemetryを作成する
+func NewTelemetry(opts ...TelemetryOption) (*Telemetry, error) {
+	c := &telemetryConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.tracerProvider == nil {
+		c.tracerProvider = otel.GetTracerProvider()
+	}
+	if c.meterProvider == nil {
+		c.meterProvider = otel.GetMeterProvider()
+	}
+
+	meter := c.meterProvider.Meter(instrumentationName, metric.WithInstrumentationVersion(Version))
+	t := &Telemetry{
+		tracer: c.tracerProvider.Tracer(instrumentationName, trace.WithInstrumentationVersion(Version)),
+	}
+	var errs []error
+	var err error
+	t.callDuration, err = meter.Float64Histogram("objectstorage.client.call.duration",
+		metric.WithDescription("Duration of object-storage API wrapper calls"), metric.WithUnit("s"))
+	errs = append(errs, err)
+	t.callErrors, err = meter.Int64Counter("objectstorage.client.call.errors",
+		metric.WithDescription("Number of failed object-storage API wrapper calls"), metric.WithUnit("{error}"))
+	errs = append(errs, err)
+	t.requestDuration, err = meter.Float64Histogram("objectstorage.client.request.duration",
+		metric.WithDescription("Duration of HTTP requests to the object-storage API"), metric.WithUnit("s"))
+	errs = append(errs, err)
+	if err := errors.Join(errs...); err != nil {
+		return nil, NewError("failed to create instruments", err)
+	}
+	return t, nil
+}
+
+// Interceptor ラッパーの呼び出しごとにスパンを作成し、所要時間とエラー数を記録するInterceptorを返す
+func (t *Telemetry) Interceptor() Interceptor {
+	return func(ctx context.Context, call *Call, invoke func(ctx context.Context) error) error {
+		attrs := []attribute.KeyValue{AttrMethod.String(call.Method)}
+		if call.SiteID != "" {
+			attrs = append(attrs, AttrSiteID.String(call.SiteID))
+		}
+		if call.Bucket != "" {
+			attrs = append(attrs, AttrBucket.String(call.Bucket))
+		}
+
+		ctx, span := t.tracer.Start(ctx, call.Method, trace.WithSpanKind(trace.SpanKindInternal), trace.WithAttributes(attrs...))
+		defer span.End()
+
+		start := time.Now()
+		err := invoke(ctx)
+		if err != nil {
+			attrs = append(attrs, AttrErrorType.String(errorType(err)))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			t.callErrors.Add(ctx, 1, metric.WithAttributes(attrs...))
+		}
+		t.callDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+		return err
+	}
+}
+
+// Middleware HTTPリクエストごとにスパンを作成し、所要時間を記録するsaclientのミドルウェアを返す
+//
+// エラー応答に含まれるAPIのトレースIDは、HTTPリクエストのスパンと親のスパンの両方に記録される
+func (t *Telemetry) Middleware() saclient.Middleware {
+	return func(req *http.Request, pull func() (saclient.Middleware, bool)) (*http.Response, error) {
+		name := "HTTP " + req.Method
+		attrs := []attribute.KeyValue{AttrHTTPMethod.String(req.Method)}
+		if routed, ok := ResolveOperation(req.Method, req.URL); ok {
+			name = routed.Operation.Name
+			attrs = append(attrs, AttrOperation.String(routed.Operation.Name))
+			if routed.SiteID != "" {
+				attrs = append(attrs, AttrSiteID.String(routed.SiteID))
+			}
+			if bucket := routed.Bucket(); bucket != "" {
+				attrs = append(attrs, AttrBucket.String(bucket))
+			}
+		}
+
+		parent := trace.SpanFromContext(req.Context())
+		ctx, span := t.tracer.Start(req.Context(), name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+		defer span.End()
+
+		start := time.Now()
+		next, ok := pull()
+		if !ok {
+			return nil, NewError("no next middleware", nil)
+		}
+		res, err := next(req.WithContext(ctx), pull)
+		if err != nil {
+			attrs = append(attrs, AttrErrorType.String(errorType(err)))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		} else {
+			attrs = append(attrs, AttrHTTPStatus.Int(res.StatusCode))
+			span.SetAttributes(AttrHTTPStatus.Int(res.StatusCode))
+			if res.StatusCode >= 400 {
+				attrs = append(attrs, AttrErrorType.String(strconv.Itoa(res.StatusCode)))
+				span.SetStatus(codes.Error, http.StatusText(res.StatusCode))
+				if traceId := peekAPITraceID(res); traceId != "" {
+					span.SetAttributes(AttrAPITraceID.String(traceId))
+					parent.SetAttributes(AttrAPITraceID.String(traceId))
+				}
+			}
+		}
+		t.requestDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+		return res, err
+	}
+}
+
+// peekAPITraceID エラー応答のボディからトレースIDを読み取る。ボディは読み取り前の状態に戻される
+func peekAPITraceID(res *http.Response) string {
+	if res.Body == nil {
+		return ""
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxErrorBodyLen))
+	res.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), res.Body), res.Body}
+	if err != nil {
+		return ""
+	}
+
+	var payload struct {
+		Error struct {
+			TraceID string `json:"trace_id"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	return payload.Error.TraceID
+}
+
+func errorType(err error) string {
+	switch {
+	case saclient.IsNotFoundError(err):
+		return "not_found"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return errorTypeOther
+	}
+}
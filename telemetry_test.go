@@ -0,0 +1,138 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstorage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sacloud/saclient-go"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newTestClient handlerで応答するテスト用のクライアントとAPIルートURLを返す
+func newTestClient(t *testing.T, handler http.Handler, middlewares ...saclient.Middleware) (*saclient.Client, string) {
+	t.Helper()
+	t.Setenv("SAKURA_ACCESS_TOKEN", "token")
+	t.Setenv("SAKURA_ACCESS_TOKEN_SECRET", "secret")
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	var client saclient.Client
+	require.NoError(t, client.SetWith(saclient.WithTestServer(server), saclient.WithMiddleware(middlewares...)))
+	return &client, server.URL + "/"
+}
+
+func TestTelemetry(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	tel, err := NewTelemetry(WithTracerProvider(tp), WithMeterProvider(mp))
+	require.NoError(t, err)
+
+	client, apiRootURL := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodPut:
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"data":{"cluster_id":"isk01","name":"bucket1"}}`)) //nolint:errcheck,gosec
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"code":400,"message":"bad request","trace_id":"trace-123"}}`)) //nolint:errcheck,gosec
+		}
+	}), tel.Middleware())
+	fed, err := NewFedClientWithAPIRootURL(client, apiRootURL)
+	require.NoError(t, err)
+	buckets := InterceptBucketAPI(NewBucketOp(fed, nil), "isk01", tel.Interceptor())
+
+	ctx := context.Background()
+	_, err = buckets.Create(ctx, &BucketCreateParams{Bucket: "bucket1", SiteId: "isk01"})
+	require.NoError(t, err)
+	err = buckets.Delete(ctx, "bucket1")
+	require.Error(t, err)
+
+	ended := spans.Ended()
+	require.Len(t, ended, 4)
+	createHTTP, create, deleteHTTP, del := ended[0], ended[1], ended[2], ended[3]
+
+	require.Equal(t, "Buckets.Create", create.Name())
+	require.Equal(t, "CreateBucket", createHTTP.Name())
+	require.Equal(t, create.SpanContext().SpanID(), createHTTP.Parent().SpanID())
+	require.Contains(t, create.Attributes(), AttrSiteID.String("isk01"))
+	require.Contains(t, create.Attributes(), AttrBucket.String("bucket1"))
+	require.Contains(t, createHTTP.Attributes(), AttrOperation.String("CreateBucket"))
+	require.Contains(t, createHTTP.Attributes(), AttrHTTPStatus.Int(http.StatusCreated))
+	require.Equal(t, codes.Unset, create.Status().Code)
+
+	require.Equal(t, "Buckets.Delete", del.Name())
+	require.Equal(t, codes.Error, del.Status().Code)
+	require.Contains(t, del.Attributes(), AttrAPITraceID.String("trace-123"))
+	require.Contains(t, deleteHTTP.Attributes(), AttrAPITraceID.String("trace-123"))
+	require.Contains(t, deleteHTTP.Attributes(), AttrHTTPStatus.Int(http.StatusBadRequest))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &rm))
+	metrics := map[string]metricdata.Aggregation{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+
+	errorsData, ok := metrics["objectstorage.client.call.errors"].(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.Len(t, errorsData.DataPoints, 1)
+	require.EqualValues(t, 1, errorsData.DataPoints[0].Value)
+	method, _ := errorsData.DataPoints[0].Attributes.Value(AttrMethod)
+	require.Equal(t, attribute.StringValue("Buckets.Delete"), method)
+
+	calls, ok := metrics["objectstorage.client.call.duration"].(metricdata.Histogram[float64])
+	require.True(t, ok)
+	require.Len(t, calls.DataPoints, 2)
+
+	requests, ok := metrics["objectstorage.client.request.duration"].(metricdata.Histogram[float64])
+	require.True(t, ok)
+	require.Len(t, requests.DataPoints, 2)
+}
+
+func TestResolveOperation(t *testing.T) {
+	tests := []struct {
+		method, url string
+		want        string
+		siteId      string
+		params      map[string]string
+	}{
+		{http.MethodPut, DefaultAPIRootURL + "fed/v1/buckets/foo", "CreateBucket", "", map[string]string{"name": "foo"}},
+		{http.MethodGet, DefaultAPIRootURL + "fed/v1/clusters", "GetClusters", "", map[string]string{}},
+		{http.MethodGet, DefaultAPIRootURL + "isk01/v2/buckets/v2/quota", "GetBucketQuota", "isk01", map[string]string{"name": "v2"}},
+		{http.MethodDelete, DefaultAPIRootURL + "tky01/v2/permissions/12/keys/AK", "DeletePermissionKey", "tky01", map[string]string{"id": "12", "key_id": "AK"}},
+		{http.MethodGet, DefaultAPIRootURL + "isk01/v2/metering/buckets/foo?start_date=2026-01-01", "GetBucketMetering", "isk01", map[string]string{"name": "foo"}},
+		{http.MethodPatch, DefaultAPIRootURL + "isk01/v2/buckets", "", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.url, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, nil)
+			got, ok := ResolveOperation(req.Method, req.URL)
+			if tt.want == "" {
+				require.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			require.Equal(t, tt.want, got.Operation.Name)
+			require.Equal(t, tt.siteId, got.SiteID)
+			require.Equal(t, tt.params, got.Params)
+		})
+	}
+}
@@ -0,0 +1,664 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/sacloud/saclient-go"
+)
+
+// WatchResource Watcherが取得するリソースの種類
+type WatchResource string
+
+const (
+	// WatchSiteStatus サイトのステータス
+	WatchSiteStatus WatchResource = "site_status"
+	// WatchBuckets バケットの一覧
+	WatchBuckets WatchResource = "buckets"
+	// WatchBucketSettings バケットの暗号化とレプリケーションの設定
+	WatchBucketSettings WatchResource = "bucket_settings"
+	// WatchPermissions パーミッションの一覧
+	WatchPermissions WatchResource = "permissions"
+	// WatchKeys パーミッションのアクセスキーのID
+	WatchKeys WatchResource = "keys"
+)
+
+// WatchResources Watcherが取得する全てのリソース。取得はこの順に行う
+var WatchResources = []WatchResource{WatchSiteStatus, WatchBuckets, WatchBucketSettings, WatchPermissions, WatchKeys}
+
+// DefaultWatchIntervals リソースごとの取得間隔のデフォルト値
+var DefaultWatchIntervals = map[WatchResource]time.Duration{
+	WatchSiteStatus:     time.Minute,
+	WatchBuckets:        time.Minute,
+	WatchBucketSettings: 10 * time.Minute,
+	WatchPermissions:    time.Minute,
+	WatchKeys:           5 * time.Minute,
+}
+
+// EventType Watcherが通知するイベントの種類
+type EventType string
+
+const (
+	EventSiteStatusChanged         EventType = "SiteStatusChanged"
+	EventBucketCreated             EventType = "BucketCreated"
+	EventBucketDeleted             EventType = "BucketDeleted"
+	EventEncryptionChanged         EventType = "EncryptionChanged"
+	EventReplicationStatusChanged  EventType = "ReplicationStatusChanged"
+	EventPermissionCreated         EventType = "PermissionCreated"
+	EventPermissionDeleted         EventType = "PermissionDeleted"
+	EventPermissionControlsChanged EventType = "PermissionControlsChanged"
+	EventKeyCreated                EventType = "KeyCreated"
+	EventKeyDeleted                EventType = "KeyDeleted"
+)
+
+// Event Watcherが通知するイベント。具体的な型はSiteStatusChangedなどのいずれか
+type Event interface {
+	EventType() EventType
+	EventSiteID() string
+	EventTime() time.Time
+}
+
+// EventHeader 全てのイベントに共通する項目
+type EventHeader struct {
+	Type   EventType `json:"type"`
+	SiteID string    `json:"site_id"`
+	// Time 変更を検出した取得の時刻
+	Time time.Time `json:"time"`
+}
+
+func (h EventHeader) EventType() EventType { return h.Type }
+func (h EventHeader) EventSiteID() string  { return h.SiteID }
+func (h EventHeader) EventTime() time.Time { return h.Time }
+
+// SiteStatusChanged サイトのステータスが変化した
+type SiteStatusChanged struct {
+	EventHeader
+	Before SiteStatusState `json:"before"`
+	After  SiteStatusState `json:"after"`
+}
+
+// BucketCreated バケットが作成された
+type BucketCreated struct {
+	EventHeader
+	Bucket string `json:"bucket"`
+}
+
+// BucketDeleted バケットが削除された
+type BucketDeleted struct {
+	EventHeader
+	Bucket string `json:"bucket"`
+}
+
+// EncryptionChanged バケットの暗号化の設定が変化した。KMSキーIDが空の場合は暗号化されていない
+type EncryptionChanged struct {
+	EventHeader
+	Bucket string `json:"bucket"`
+	Before string `json:"before_kms_key_id"`
+	After  string `json:"after_kms_key_id"`
+}
+
+// ReplicationStatusChanged バケットのレプリケーションの設定もしくは状態が変化した。nilの場合は設定されていない
+type ReplicationStatusChanged struct {
+	EventHeader
+	Bucket string            `json:"bucket"`
+	Before *ReplicationState `json:"before"`
+	After  *ReplicationState `json:"after"`
+}
+
+// PermissionCreated パーミッションが作成された
+type PermissionCreated struct {
+	EventHeader
+	PermissionID string          `json:"permission_id"`
+	Permission   PermissionState `json:"permission"`
+}
+
+// PermissionDeleted パーミッションが削除された
+type PermissionDeleted struct {
+	EventHeader
+	PermissionID string          `json:"permission_id"`
+	Permission   PermissionState `json:"permission"`
+}
+
+// PermissionControlsChanged パーミッションの名前もしくはバケットへのアクセスが変化した
+type PermissionControlsChanged struct {
+	EventHeader
+	PermissionID string          `json:"permission_id"`
+	Before       PermissionState `json:"before"`
+	After        PermissionState `json:"after"`
+}
+
+// KeyCreated パーミッションのアクセスキーが作成された
+type KeyCreated struct {
+	EventHeader
+	PermissionID string `json:"permission_id"`
+	KeyID        string `json:"key_id"`
+}
+
+// KeyDeleted パーミッションのアクセスキーが削除された
+type KeyDeleted struct {
+	EventHeader
+	PermissionID string `json:"permission_id"`
+	KeyID        string `json:"key_id"`
+}
+
+// SiteStatusState チェックポイントに記録するサイトのステータス
+type SiteStatusState struct {
+	Status    string `json:"status"`
+	AcceptNew bool   `json:"accept_new"`
+	Message   string `json:"message"`
+}
+
+// ReplicationState チェックポイントに記録するレプリケーションの設定
+type ReplicationState struct {
+	DestBucket string `json:"dest_bucket"`
+	DestSiteID string `json:"dest_site_id"`
+	Status     string `json:"status"`
+}
+
+// BucketState チェックポイントに記録するバケットの状態
+type BucketState struct {
+	// SettingsObserved 暗号化とレプリケーションの設定を取得済みの場合はtrue
+	SettingsObserved bool              `json:"settings_observed"`
+	KMSKeyID         string            `json:"kms_key_id,omitempty"`
+	Replication      *ReplicationState `json:"replication,omitempty"`
+}
+
+// BucketAccess パーミッションが許可するバケットへのアクセス
+type BucketAccess struct {
+	Bucket string `json:"bucket"`
+	Read   bool   `json:"read"`
+	Write  bool   `json:"write"`
+}
+
+// PermissionState チェックポイントに記録するパーミッションの状態
+type PermissionState struct {
+	DisplayName string         `json:"display_name"`
+	Buckets     []BucketAccess `json:"buckets"`
+	// KeyIDs アクセスキーのID。取得前はnil
+	KeyIDs []string `json:"key_ids,omitempty"`
+}
+
+// SiteState チェックポイントに記録するサイトの状態
+type SiteState struct {
+	// Observed 取得済みのリソース。初めて取得したリソースはイベントを通知せずに記録のみ行う
+	Observed    map[WatchResource]bool      `json:"observed"`
+	Status      *SiteStatusState            `json:"status,omitempty"`
+	Buckets     map[string]*BucketState     `json:"buckets"`
+	Permissions map[string]*PermissionState `json:"permissions"`
+}
+
+// Checkpoint Watcherの状態。JSONで保存し、WatchOptions.Checkpointに指定すると前回の続きから監視を再開できる
+type Checkpoint struct {
+	Sites map[string]*SiteState `json:"sites"`
+	// Polled リソースごとの最後の取得の時刻
+	Polled map[WatchResource]time.Time `json:"polled"`
+}
+
+// WatchOptions NewWatcherのオプション
+type WatchOptions struct {
+	// SiteIDs 対象のサイトのID。空の場合はSiteAPI.Listで取得した全てのサイトで、一覧はWatchSiteStatusの取得ごとに更新する
+	SiteIDs []string
+	// Intervals リソースごとの取得間隔。指定しないリソースはDefaultWatchIntervals。負の値の場合は取得しない
+	Intervals map[WatchResource]time.Duration
+	// Checkpoint 再開する場合の前回の状態
+	Checkpoint *Checkpoint
+	// Clock 現在時刻を返す関数。nilの場合はtime.Now
+	Clock func() time.Time
+}
+
+// Watcher サイト、バケット、パーミッションなどを定期的に取得し、前回との差分をイベントとして通知する
+//
+// 初めて取得したリソースは基準として記録し、イベントは通知しない。
+// 取得に失敗したリソースは前回の状態を維持するため、失敗が削除として通知されることはない
+type Watcher struct {
+	backend    Backend
+	siteIds    []string
+	intervals  map[WatchResource]time.Duration
+	now        func() time.Time
+	checkpoint *Checkpoint
+}
+
+// NewWatcher Watcherを作成する
+func NewWatcher(backend Backend, opts *WatchOptions) *Watcher {
+	if opts == nil {
+		opts = &WatchOptions{}
+	}
+	w := &Watcher{
+		backend:   backend,
+		siteIds:   opts.SiteIDs,
+		intervals: maps.Clone(DefaultWatchIntervals),
+		now:       opts.Clock,
+		checkpoint: &Checkpoint{
+			Sites:  map[string]*SiteState{},
+			Polled: map[WatchResource]time.Time{},
+		},
+	}
+	maps.Copy(w.intervals, opts.Intervals)
+	if w.now == nil {
+		w.now = time.Now
+	}
+	if opts.Checkpoint != nil {
+		w.checkpoint = cloneCheckpoint(opts.Checkpoint)
+	}
+	return w
+}
+
+// cloneCheckpoint JSONを経由せずにチェックポイントを複製する
+func cloneCheckpoint(c *Checkpoint) *Checkpoint {
+	res := &Checkpoint{
+		Sites:  make(map[string]*SiteState, len(c.Sites)),
+		Polled: maps.Clone(c.Polled),
+	}
+	if res.Polled == nil {
+		res.Polled = map[WatchResource]time.Time{}
+	}
+	for siteId, site := range c.Sites {
+		s := &SiteState{
+			Observed:    maps.Clone(site.Observed),
+			Buckets:     make(map[string]*BucketState, len(site.Buckets)),
+			Permissions: make(map[string]*PermissionState, len(site.Permissions)),
+		}
+		if s.Observed == nil {
+			s.Observed = map[WatchResource]bool{}
+		}
+		if site.Status != nil {
+			status := *site.Status
+			s.Status = &status
+		}
+		for name, bucket := range site.Buckets {
+			b := *bucket
+			if bucket.Replication != nil {
+				replication := *bucket.Replication
+				b.Replication = &replication
+			}
+			s.Buckets[name] = &b
+		}
+		for id, permission := range site.Permissions {
+			p := *permission
+			p.Buckets = slices.Clone(permission.Buckets)
+			p.KeyIDs = slices.Clone(permission.KeyIDs)
+			s.Permissions[id] = &p
+		}
+		res.Sites[siteId] = s
+	}
+	return res
+}
+
+// Checkpoint 現在の状態の複製を返す
+func (w *Watcher) Checkpoint() *Checkpoint {
+	return cloneCheckpoint(w.checkpoint)
+}
+
+// next 次に取得するリソースの時刻を返す。取得するリソースがない場合はfalse
+func (w *Watcher) next() (time.Time, bool) {
+	var next time.Time
+	found := false
+	for _, resource := range WatchResources {
+		interval := w.intervals[resource]
+		if interval < 0 {
+			continue
+		}
+		at := w.checkpoint.Polled[resource].Add(interval)
+		if !found || at.Before(next) {
+			next, found = at, true
+		}
+	}
+	return next, found
+}
+
+// Poll 取得間隔が経過したリソースを取得し、前回との差分のイベントを返す
+//
+// 取得に失敗したサイトやリソースがある場合は、他の結果のイベントとともにエラーを返す
+func (w *Watcher) Poll(ctx context.Context) ([]Event, error) {
+	now := w.now()
+	var due []WatchResource
+	for _, resource := range WatchResources {
+		interval := w.intervals[resource]
+		if interval >= 0 && !now.Before(w.checkpoint.Polled[resource].Add(interval)) {
+			due = append(due, resource)
+		}
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+
+	var events []Event
+	var errs []error
+	siteIds, err := w.sites(ctx, slices.Contains(due, WatchSiteStatus))
+	if err != nil {
+		// サイトの一覧の取得に失敗した場合は前回までのサイトを対象とする
+		if len(siteIds) == 0 {
+			return nil, err
+		}
+		errs = append(errs, err)
+	}
+	for _, resource := range due {
+		for _, siteId := range siteIds {
+			site := w.site(siteId)
+			baseline := !site.Observed[resource]
+			header := func(t EventType) EventHeader {
+				return EventHeader{Type: t, SiteID: siteId, Time: now}
+			}
+			found, err := w.poll(ctx, resource, siteId, site, baseline, header)
+			if err != nil {
+				errs = append(errs, NewError(fmt.Sprintf("failed to watch %s in site %s", resource, siteId), err))
+				continue
+			}
+			site.Observed[resource] = true
+			if !baseline {
+				events = append(events, found...)
+			}
+		}
+		w.checkpoint.Polled[resource] = now
+	}
+	return events, errors.Join(errs...)
+}
+
+// Watch 取得間隔ごとにPollを行い、イベントを順に返すイテレーター
+//
+// 取得に失敗した場合はエラーを返して監視を続ける。ctxがキャンセルされるか、呼び出し元がループを抜けると終了する
+func (w *Watcher) Watch(ctx context.Context) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		for {
+			events, err := w.Poll(ctx)
+			for _, event := range events {
+				if !yield(event, nil) {
+					return
+				}
+			}
+			if err != nil && ctx.Err() == nil && !yield(nil, err) {
+				return
+			}
+
+			next, ok := w.next()
+			if !ok {
+				return
+			}
+			timer := time.NewTimer(max(next.Sub(w.now()), 0))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+// sites 対象のサイトのIDを返す
+//
+// WatchOptions.SiteIDsが空の場合は、初回とrefreshがtrue(サイトのステータスの取得時)にサイトの一覧を取得し直し、
+// 後から追加されたサイトも対象とする。WatchSiteStatusを取得しない場合は初回以降のサイトの追加は反映されない。
+// 一覧の取得に失敗した場合は前回までのサイトをエラーとともに返す
+func (w *Watcher) sites(ctx context.Context, refresh bool) ([]string, error) {
+	if len(w.siteIds) > 0 {
+		return w.siteIds, nil
+	}
+	known := slices.Sorted(maps.Keys(w.checkpoint.Sites))
+	if len(known) > 0 && !refresh {
+		return known, nil
+	}
+	siteIds, err := ListSiteIDs(ctx, w.backend)
+	if err != nil {
+		return known, NewError("failed to list sites", err)
+	}
+	return siteIds, nil
+}
+
+func (w *Watcher) site(siteId string) *SiteState {
+	site, ok := w.checkpoint.Sites[siteId]
+	if !ok {
+		site = &SiteState{
+			Observed:    map[WatchResource]bool{},
+			Buckets:     map[string]*BucketState{},
+			Permissions: map[string]*PermissionState{},
+		}
+		w.checkpoint.Sites[siteId] = site
+	}
+	return site
+}
+
+// poll リソースを取得してsiteを更新し、変化のイベントを返す。失敗した場合はsiteを変更しない
+func (w *Watcher) poll(ctx context.Context, resource WatchResource, siteId string, site *SiteState, baseline bool, header func(EventType) EventHeader) ([]Event, error) {
+	switch resource {
+	case WatchSiteStatus:
+		return w.pollSiteStatus(ctx, siteId, site, header)
+	case WatchBuckets:
+		return w.pollBuckets(ctx, siteId, site, header)
+	case WatchBucketSettings:
+		return w.pollBucketSettings(ctx, siteId, site, baseline, header)
+	case WatchPermissions:
+		return w.pollPermissions(ctx, siteId, site, header)
+	case WatchKeys:
+		return w.pollKeys(ctx, siteId, site, baseline, header)
+	default:
+		return nil, NewError(fmt.Sprintf("unknown watch resource: %s", resource), nil)
+	}
+}
+
+func (w *Watcher) pollSiteStatus(ctx context.Context, siteId string, site *SiteState, header func(EventType) EventHeader) ([]Event, error) {
+	api, err := w.backend.SiteStatus(siteId)
+	if err != nil {
+		return nil, err
+	}
+	data, err := api.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status := SiteStatusState{
+		Status:    data.StatusCode.Value.Status.Value,
+		AcceptNew: data.AcceptNew.Value,
+		Message:   data.Message.Value,
+	}
+	var events []Event
+	if site.Status != nil && *site.Status != status {
+		events = append(events, &SiteStatusChanged{EventHeader: header(EventSiteStatusChanged), Before: *site.Status, After: status})
+	}
+	site.Status = &status
+	return events, nil
+}
+
+func (w *Watcher) pollBuckets(ctx context.Context, siteId string, site *SiteState, header func(EventType) EventHeader) ([]Event, error) {
+	api, err := w.backend.Buckets(siteId)
+	if err != nil {
+		return nil, err
+	}
+	list, err := api.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var events []Event
+	current := map[string]bool{}
+	for _, bucket := range list {
+		name := string(bucket.Name)
+		current[name] = true
+		if _, ok := site.Buckets[name]; !ok {
+			site.Buckets[name] = &BucketState{}
+			events = append(events, &BucketCreated{EventHeader: header(EventBucketCreated), Bucket: name})
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(site.Buckets)) {
+		if !current[name] {
+			delete(site.Buckets, name)
+			events = append(events, &BucketDeleted{EventHeader: header(EventBucketDeleted), Bucket: name})
+		}
+	}
+	return events, nil
+}
+
+// pollBucketSettings 既知のバケットの暗号化とレプリケーションの設定を取得する。
+// 基準の記録後に初めて取得するバケットは設定なしの状態と比較する
+func (w *Watcher) pollBucketSettings(ctx context.Context, siteId string, site *SiteState, baseline bool, header func(EventType) EventHeader) ([]Event, error) {
+	type settings struct {
+		kmsKeyId    string
+		replication *ReplicationState
+	}
+	names := slices.Sorted(maps.Keys(site.Buckets))
+	read := make([]settings, len(names))
+	for i, name := range names {
+		api, err := w.backend.BucketExtra(siteId, name)
+		if err != nil {
+			return nil, err
+		}
+		encryption, err := api.ReadEncryption(ctx)
+		if err != nil {
+			return nil, err
+		}
+		read[i].kmsKeyId = string(encryption.KmsKeyID.Value)
+
+		replication, err := api.ReadReplication(ctx)
+		if err != nil && !saclient.IsNotFoundError(err) {
+			return nil, err
+		}
+		if err == nil {
+			read[i].replication = &ReplicationState{
+				DestBucket: replication.DestBucket.Name.Value,
+				DestSiteID: replication.DestBucket.ClusterID.Value,
+				Status:     string(replication.ConfigStatus),
+			}
+		}
+	}
+
+	var events []Event
+	for i, name := range names {
+		bucket := site.Buckets[name]
+		if !baseline || bucket.SettingsObserved {
+			if bucket.KMSKeyID != read[i].kmsKeyId {
+				events = append(events, &EncryptionChanged{EventHeader: header(EventEncryptionChanged), Bucket: name, Before: bucket.KMSKeyID, After: read[i].kmsKeyId})
+			}
+			if !equalReplication(bucket.Replication, read[i].replication) {
+				events = append(events, &ReplicationStatusChanged{EventHeader: header(EventReplicationStatusChanged), Bucket: name, Before: bucket.Replication, After: read[i].replication})
+			}
+		}
+		bucket.SettingsObserved = true
+		bucket.KMSKeyID = read[i].kmsKeyId
+		bucket.Replication = read[i].replication
+	}
+	return events, nil
+}
+
+func equalReplication(a, b *ReplicationState) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func (w *Watcher) pollPermissions(ctx context.Context, siteId string, site *SiteState, header func(EventType) EventHeader) ([]Event, error) {
+	api, err := w.backend.Permissions(siteId)
+	if err != nil {
+		return nil, err
+	}
+	list, err := api.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var events []Event
+	current := map[string]bool{}
+	for _, permission := range list {
+		id := strconv.FormatInt(int64(permission.ID.Value), 10)
+		current[id] = true
+		state := PermissionState{DisplayName: string(permission.DisplayName.Value), Buckets: bucketAccesses(permission.BucketControls)}
+
+		previous, ok := site.Permissions[id]
+		switch {
+		case !ok:
+			site.Permissions[id] = &state
+			events = append(events, &PermissionCreated{EventHeader: header(EventPermissionCreated), PermissionID: id, Permission: state})
+		case previous.DisplayName != state.DisplayName || !slices.Equal(previous.Buckets, state.Buckets):
+			before := *previous
+			before.KeyIDs = nil
+			previous.DisplayName, previous.Buckets = state.DisplayName, state.Buckets
+			events = append(events, &PermissionControlsChanged{EventHeader: header(EventPermissionControlsChanged), PermissionID: id, Before: before, After: state})
+		}
+	}
+	for _, id := range slices.Sorted(maps.Keys(site.Permissions)) {
+		if !current[id] {
+			state := *site.Permissions[id]
+			state.KeyIDs = nil
+			delete(site.Permissions, id)
+			events = append(events, &PermissionDeleted{EventHeader: header(EventPermissionDeleted), PermissionID: id, Permission: state})
+		}
+	}
+	return events, nil
+}
+
+// bucketAccesses 比較のためバケット名の順に並べたアクセスを返す
+func bucketAccesses(controls v2.BucketControls) []BucketAccess {
+	res := []BucketAccess{}
+	for _, control := range controls {
+		res = append(res, BucketAccess{
+			Bucket: string(control.BucketName.Value),
+			Read:   bool(control.CanRead.Value),
+			Write:  bool(control.CanWrite.Value),
+		})
+	}
+	slices.SortFunc(res, func(a, b BucketAccess) int {
+		if a.Bucket < b.Bucket {
+			return -1
+		}
+		if a.Bucket > b.Bucket {
+			return 1
+		}
+		return 0
+	})
+	return res
+}
+
+// pollKeys 既知のパーミッションのアクセスキーのIDを取得する。
+// 基準の記録後に初めて取得するパーミッションはアクセスキーなしの状態と比較する
+func (w *Watcher) pollKeys(ctx context.Context, siteId string, site *SiteState, baseline bool, header func(EventType) EventHeader) ([]Event, error) {
+	api, err := w.backend.Permissions(siteId)
+	if err != nil {
+		return nil, err
+	}
+	ids := slices.Sorted(maps.Keys(site.Permissions))
+	read := make([][]string, len(ids))
+	for i, id := range ids {
+		keys, err := api.ListAccessKeys(ctx, id)
+		if saclient.IsNotFoundError(err) {
+			// 前回のパーミッションの取得以降に削除された
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		read[i] = []string{}
+		for _, key := range keys {
+			read[i] = append(read[i], string(key.ID.Value))
+		}
+		slices.Sort(read[i])
+	}
+
+	var events []Event
+	for i, id := range ids {
+		if read[i] == nil {
+			continue
+		}
+		permission := site.Permissions[id]
+		if !baseline || permission.KeyIDs != nil {
+			for _, keyId := range read[i] {
+				if !slices.Contains(permission.KeyIDs, keyId) {
+					events = append(events, &KeyCreated{EventHeader: header(EventKeyCreated), PermissionID: id, KeyID: keyId})
+				}
+			}
+			for _, keyId := range permission.KeyIDs {
+				if !slices.Contains(read[i], keyId) {
+					events = append(events, &KeyDeleted{EventHeader: header(EventKeyDeleted), PermissionID: id, KeyID: keyId})
+				}
+			}
+		}
+		permission.KeyIDs = read[i]
+	}
+	return events, nil
+}
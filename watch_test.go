@@ -0,0 +1,305 @@
+// Copyright 2022-2026 The object-storage-api-go Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package objectstorage_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	objectstorage "github.com/sacloud/object-storage-api-go"
+	v2 "github.com/sacloud/object-storage-api-go/apis/v2"
+	"github.com/sacloud/object-storage-api-go/objectstoragetest"
+	"github.com/stretchr/testify/require"
+)
+
+// watchClock テスト用の進められる時計
+type watchClock struct {
+	now time.Time
+}
+
+func (c *watchClock) Now() time.Time { return c.now }
+
+func (c *watchClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newWatchClock() *watchClock {
+	return &watchClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func eventTypes(events []objectstorage.Event) []objectstorage.EventType {
+	var res []objectstorage.EventType
+	for _, event := range events {
+		res = append(res, event.EventType())
+	}
+	return res
+}
+
+func readControls(bucket string, write bool) v2.BucketControls {
+	return v2.BucketControls{{
+		BucketName: v2.NewOptBucketName(v2.BucketName(bucket)),
+		CanRead:    v2.NewOptCanRead(true),
+		CanWrite:   v2.NewOptCanWrite(v2.CanWrite(write)),
+	}}
+}
+
+func TestWatcher_Poll(t *testing.T) {
+	ctx := context.Background()
+	fake := objectstoragetest.NewFake()
+	buckets, err := fake.Buckets("isk01")
+	require.NoError(t, err)
+	_, err = buckets.Create(ctx, &objectstorage.BucketCreateParams{Bucket: "bucket1"})
+	require.NoError(t, err)
+	permissions, err := fake.Permissions("isk01")
+	require.NoError(t, err)
+	permission, err := permissions.Create(ctx, "perm1", readControls("bucket1", false))
+	require.NoError(t, err)
+	permissionId := strconv.FormatInt(int64(permission.ID.Value), 10)
+
+	clock := newWatchClock()
+	w := objectstorage.NewWatcher(fake, &objectstorage.WatchOptions{
+		SiteIDs:   []string{"isk01"},
+		Intervals: map[objectstorage.WatchResource]time.Duration{objectstorage.WatchBucketSettings: time.Minute, objectstorage.WatchKeys: time.Minute},
+		Clock:     clock.Now,
+	})
+
+	// 初回は基準として記録するのみ
+	events, err := w.Poll(ctx)
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	t.Run("buckets", func(t *testing.T) {
+		_, err := buckets.Create(ctx, &objectstorage.BucketCreateParams{Bucket: "bucket2"})
+		require.NoError(t, err)
+		clock.Advance(time.Minute)
+		events, err := w.Poll(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []objectstorage.EventType{objectstorage.EventBucketCreated}, eventTypes(events))
+		created := events[0].(*objectstorage.BucketCreated)
+		require.Equal(t, "bucket2", created.Bucket)
+		require.Equal(t, "isk01", created.EventSiteID())
+		require.Equal(t, clock.Now(), created.EventTime())
+
+		require.NoError(t, buckets.Delete(ctx, "bucket2"))
+		clock.Advance(time.Minute)
+		events, err = w.Poll(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []objectstorage.EventType{objectstorage.EventBucketDeleted}, eventTypes(events))
+	})
+
+	t.Run("permissions and keys", func(t *testing.T) {
+		_, err := permissions.Update(ctx, permissionId, "perm1", readControls("bucket1", true))
+		require.NoError(t, err)
+		key, err := permissions.CreateAccessKey(ctx, permissionId)
+		require.NoError(t, err)
+		clock.Advance(time.Minute)
+		events, err := w.Poll(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []objectstorage.EventType{objectstorage.EventPermissionControlsChanged, objectstorage.EventKeyCreated}, eventTypes(events))
+		changed := events[0].(*objectstorage.PermissionControlsChanged)
+		require.Equal(t, []objectstorage.BucketAccess{{Bucket: "bucket1", Read: true}}, changed.Before.Buckets)
+		require.Equal(t, []objectstorage.BucketAccess{{Bucket: "bucket1", Read: true, Write: true}}, changed.After.Buckets)
+		require.Equal(t, string(key.ID.Value), events[1].(*objectstorage.KeyCreated).KeyID)
+
+		require.NoError(t, permissions.DeleteAccessKey(ctx, permissionId, string(key.ID.Value)))
+		clock.Advance(time.Minute)
+		events, err = w.Poll(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []objectstorage.EventType{objectstorage.EventKeyDeleted}, eventTypes(events))
+	})
+
+	t.Run("replication", func(t *testing.T) {
+		tky, err := fake.Buckets("tky01")
+		require.NoError(t, err)
+		_, err = tky.Create(ctx, &objectstorage.BucketCreateParams{Bucket: "replica1"})
+		require.NoError(t, err)
+		extra, err := fake.BucketExtra("isk01", "bucket1")
+		require.NoError(t, err)
+		_, err = extra.EnableReplication(ctx, "replica1")
+		require.NoError(t, err)
+
+		clock.Advance(time.Minute)
+		events, err := w.Poll(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []objectstorage.EventType{objectstorage.EventReplicationStatusChanged}, eventTypes(events))
+		changed := events[0].(*objectstorage.ReplicationStatusChanged)
+		require.Nil(t, changed.Before)
+		require.Equal(t, &objectstorage.ReplicationState{DestBucket: "replica1", DestSiteID: "tky01", Status: "created"}, changed.After)
+
+		require.NoError(t, extra.DisableReplication(ctx))
+		clock.Advance(time.Minute)
+		events, err = w.Poll(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []objectstorage.EventType{objectstorage.EventReplicationStatusChanged}, eventTypes(events))
+		require.Nil(t, events[0].(*objectstorage.ReplicationStatusChanged).After)
+	})
+
+	t.Run("site status", func(t *testing.T) {
+		require.NoError(t, fake.SetSiteStatus("isk01", v2.StatusData{
+			AcceptNew:  v2.NewOptBool(false),
+			Message:    v2.NewOptString("maintenance"),
+			StatusCode: v2.NewOptStatusDataStatusCode(v2.StatusDataStatusCode{Status: v2.NewOptString("maintenance")}),
+		}))
+		clock.Advance(time.Minute)
+		events, err := w.Poll(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []objectstorage.EventType{objectstorage.EventSiteStatusChanged}, eventTypes(events))
+		changed := events[0].(*objectstorage.SiteStatusChanged)
+		require.Equal(t, objectstorage.SiteStatusState{Status: "maintenance", Message: "maintenance"}, changed.After)
+	})
+}
+
+func TestWatcher_Intervals(t *testing.T) {
+	ctx := context.Background()
+	fake := objectstoragetest.NewFake()
+	clock := newWatchClock()
+	w := objectstorage.NewWatcher(fake, &objectstorage.WatchOptions{
+		SiteIDs: []string{"isk01"},
+		Intervals: map[objectstorage.WatchResource]time.Duration{
+			objectstorage.WatchBuckets:        time.Minute,
+			objectstorage.WatchSiteStatus:     -1,
+			objectstorage.WatchBucketSettings: -1,
+			objectstorage.WatchPermissions:    5 * time.Minute,
+			objectstorage.WatchKeys:           -1,
+		},
+		Clock: clock.Now,
+	})
+
+	_, err := w.Poll(ctx)
+	require.NoError(t, err)
+	require.Len(t, fake.CallsTo("Buckets.List"), 1)
+	require.Len(t, fake.CallsTo("Permissions.List"), 1)
+	require.Empty(t, fake.CallsTo("SiteStatus.Read"))
+
+	// 取得間隔が経過していない場合は取得しない
+	clock.Advance(30 * time.Second)
+	_, err = w.Poll(ctx)
+	require.NoError(t, err)
+	require.Len(t, fake.CallsTo("Buckets.List"), 1)
+
+	clock.Advance(30 * time.Second)
+	_, err = w.Poll(ctx)
+	require.NoError(t, err)
+	require.Len(t, fake.CallsTo("Buckets.List"), 2)
+	require.Len(t, fake.CallsTo("Permissions.List"), 1)
+}
+
+func TestWatcher_Checkpoint(t *testing.T) {
+	ctx := context.Background()
+	fake := objectstoragetest.NewFake()
+	clock := newWatchClock()
+	opts := &objectstorage.WatchOptions{SiteIDs: []string{"isk01"}, Clock: clock.Now}
+	w := objectstorage.NewWatcher(fake, opts)
+	_, err := w.Poll(ctx)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(w.Checkpoint())
+	require.NoError(t, err)
+
+	// 停止中の変更は再開後に通知する
+	buckets, err := fake.Buckets("isk01")
+	require.NoError(t, err)
+	_, err = buckets.Create(ctx, &objectstorage.BucketCreateParams{Bucket: "bucket1"})
+	require.NoError(t, err)
+
+	var checkpoint objectstorage.Checkpoint
+	require.NoError(t, json.Unmarshal(data, &checkpoint))
+	opts.Checkpoint = &checkpoint
+	clock.Advance(time.Minute)
+	events, err := objectstorage.NewWatcher(fake, opts).Poll(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []objectstorage.EventType{objectstorage.EventBucketCreated}, eventTypes(events))
+}
+
+func TestWatcher_NewSite(t *testing.T) {
+	ctx := context.Background()
+	clock := newWatchClock()
+	isk01 := v2.ModelCluster{ID: v2.NewOptString("isk01")}
+	w := objectstorage.NewWatcher(objectstoragetest.NewFake(objectstoragetest.WithSites(isk01)), &objectstorage.WatchOptions{Clock: clock.Now})
+	_, err := w.Poll(ctx)
+	require.NoError(t, err)
+	require.Len(t, w.Checkpoint().Sites, 1)
+
+	// 後から追加されたサイトもサイトのステータスの取得時に対象とする
+	fake := objectstoragetest.NewFake()
+	opts := &objectstorage.WatchOptions{Checkpoint: w.Checkpoint(), Clock: clock.Now}
+	clock.Advance(objectstorage.DefaultWatchIntervals[objectstorage.WatchSiteStatus])
+	_, err = objectstorage.NewWatcher(fake, opts).Poll(ctx)
+	require.NoError(t, err)
+	var siteIds []string
+	for _, call := range fake.CallsTo("SiteStatus.Read") {
+		siteIds = append(siteIds, call.SiteID)
+	}
+	require.ElementsMatch(t, []string{"isk01", "tky01"}, siteIds)
+
+	// サイトの一覧の取得に失敗した場合は前回までのサイトを対象とする
+	fake.FailOn("Site.List", errors.New("unavailable"))
+	fake.ResetCalls()
+	clock.Advance(objectstorage.DefaultWatchIntervals[objectstorage.WatchSiteStatus])
+	w = objectstorage.NewWatcher(fake, opts)
+	_, err = w.Poll(ctx)
+	require.Error(t, err)
+	require.Len(t, fake.CallsTo("SiteStatus.Read"), 1)
+}
+
+func TestWatcher_Error(t *testing.T) {
+	ctx := context.Background()
+	fake := objectstoragetest.NewFake()
+	buckets, err := fake.Buckets("isk01")
+	require.NoError(t, err)
+	_, err = buckets.Create(ctx, &objectstorage.BucketCreateParams{Bucket: "bucket1"})
+	require.NoError(t, err)
+
+	clock := newWatchClock()
+	w := objectstorage.NewWatcher(fake, &objectstorage.WatchOptions{SiteIDs: []string{"isk01"}, Clock: clock.Now})
+	_, err = w.Poll(ctx)
+	require.NoError(t, err)
+
+	// 取得に失敗しても削除として通知しない
+	fake.FailOn("Buckets.List", errors.New("unavailable"))
+	clock.Advance(time.Minute)
+	events, err := w.Poll(ctx)
+	require.Error(t, err)
+	require.Empty(t, events)
+
+	fake.ClearHooks()
+	clock.Advance(time.Minute)
+	events, err = w.Poll(ctx)
+	require.NoError(t, err)
+	require.Empty(t, events)
+}
+
+func TestWatcher_Watch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	fake := objectstoragetest.NewFake()
+	w := objectstorage.NewWatcher(fake, &objectstorage.WatchOptions{
+		SiteIDs: []string{"isk01"},
+		Intervals: map[objectstorage.WatchResource]time.Duration{
+			objectstorage.WatchBuckets:        10 * time.Millisecond,
+			objectstorage.WatchSiteStatus:     -1,
+			objectstorage.WatchBucketSettings: -1,
+			objectstorage.WatchPermissions:    -1,
+			objectstorage.WatchKeys:           -1,
+		},
+	})
+
+	// 基準の記録後にバケットを作成する
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		buckets, err := fake.Buckets("isk01")
+		if err == nil {
+			buckets.Create(ctx, &objectstorage.BucketCreateParams{Bucket: "bucket1"}) //nolint:errcheck
+		}
+	}()
+	for event, err := range w.Watch(ctx) {
+		require.NoError(t, err)
+		require.Equal(t, objectstorage.EventBucketCreated, event.EventType())
+		require.Equal(t, "bucket1", event.(*objectstorage.BucketCreated).Bucket)
+		break
+	}
+	require.NoError(t, ctx.Err())
+}